@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/evleria/cats-app/internal/config"
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/internal/tenant"
+	"github.com/evleria/cats-app/protocol/pb"
+)
+
+// catsCommands are client commands calling gRPC server at GRPC_TARGET, e.g. `server cats get <id>`
+var catsCommands = map[string]func(ctx context.Context, client pb.CatsServiceClient, args []string) error{
+	"get":    getCat,
+	"list":   listCats,
+	"add":    addCat,
+	"price":  updatePrice,
+	"delete": deleteCat,
+}
+
+// runCats runs a client command, results are printed as JSON
+func runCats(cfg *config.Сonfig, _ *config.Loader, args []string) {
+	flags := flag.NewFlagSet("cats", flag.ExitOnError)
+	timeout := flags.Duration("timeout", 10*time.Second, "timeout of a call")
+	tenantID := flags.String("tenant", "", "tenant sent in x-tenant-id metadata, server default is used if empty")
+	token := flags.String("token", "", "bearer token carrying tenant, used when server verifies tenant tokens")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: cats [flags] get|list|add|price|delete [command flags]\n")
+		flags.PrintDefaults()
+	}
+	check(flags.Parse(args))
+
+	command, ok := catsCommands[flags.Arg(0)]
+	if !ok {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	opts := []grpc.DialOption{grpc.WithInsecure()}
+	if creds := getGrpcClientCredentials(cfg); creds != nil {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	}
+	conn, err := grpc.Dial(cfg.GrpcDialTarget(), opts...)
+	check(err)
+	defer conn.Close() //nolint:errcheck,gocritic
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	if *tenantID != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, strings.ToLower(tenant.Header), *tenantID)
+	}
+	if *token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, strings.ToLower(tenant.AuthorizationHeader), "Bearer "+*token)
+	}
+	check(command(ctx, pb.NewCatsServiceClient(conn), flags.Args()[1:]))
+}
+
+func getCat(ctx context.Context, client pb.CatsServiceClient, args []string) error {
+	flags := flag.NewFlagSet("get", flag.ContinueOnError)
+	currency := flags.String("currency", "", "currency price is shown in, e.g. EUR")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	id, err := singleArg("get [-currency <code>] <id>", flags.Args())
+	if err != nil {
+		return err
+	}
+	resp, err := client.GetCat(ctx, &pb.GetCatRequest{Id: id, Currency: *currency})
+	if err != nil {
+		return err
+	}
+	return printMessage(resp.Cat)
+}
+
+func listCats(ctx context.Context, client pb.CatsServiceClient, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	req := new(pb.GetAllCatsRequest)
+	flags.StringVar(&req.Color, "color", "", "color of cats")
+	flags.StringVar(&req.Breed, "breed", "", "breed of cats")
+	flags.Int64Var(&req.Limit, "limit", 0, "maximal number of cats, 0 means no limit")
+	flags.Int64Var(&req.Offset, "offset", 0, "number of cats to skip")
+	flags.StringVar(&req.Currency, "currency", "", "currency prices are shown in, e.g. EUR")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	stream, err := client.GetAllCats(ctx, req)
+	if err != nil {
+		return err
+	}
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := printMessage(resp.Cat); err != nil {
+			return err
+		}
+	}
+}
+
+func addCat(ctx context.Context, client pb.CatsServiceClient, args []string) error {
+	flags := flag.NewFlagSet("add", flag.ContinueOnError)
+	req := new(pb.AddNewCatRequest)
+	flags.StringVar(&req.Name, "name", "", "name of the cat")
+	flags.StringVar(&req.Color, "color", "", "color of the cat")
+	price := flags.String("price", "", "price of the cat in major units, e.g. 9.99")
+	currency := flags.String("currency", money.DefaultCurrency, "ISO 4217 currency of the price")
+	flags.StringVar(&req.Breed, "breed", "", "breed of the cat")
+	flags.StringVar(&req.Description, "description", "", "description of the cat")
+	flags.StringVar(&req.IdempotencyKey, "idempotency-key", "", "key making retries of the call safe")
+	sex := flags.String("sex", "", "sex of the cat: male or female")
+	birthDate := flags.String("birth-date", "", "birth date of the cat, YYYY-MM-DD")
+	tags := flags.String("tags", "", "comma separated tags")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	m, err := money.Parse(*price, *currency)
+	if err != nil {
+		return fmt.Errorf("invalid price: %w", err)
+	}
+	req.Price = &pb.Money{Amount: m.Amount, Currency: m.Currency}
+
+	switch *sex {
+	case "":
+	case "male":
+		req.Sex = pb.Sex_SEX_MALE
+	case "female":
+		req.Sex = pb.Sex_SEX_FEMALE
+	default:
+		return fmt.Errorf("unknown sex %q", *sex)
+	}
+	if *birthDate != "" {
+		date, err := time.Parse("2006-01-02", *birthDate)
+		if err != nil {
+			return fmt.Errorf("invalid birth date: %w", err)
+		}
+		req.BirthDate = timestamppb.New(date)
+	}
+	if *tags != "" {
+		req.Tags = strings.Split(*tags, ",")
+	}
+
+	resp, err := client.AddNewCat(ctx, req)
+	if err != nil {
+		return err
+	}
+	return printMessage(resp)
+}
+
+func updatePrice(ctx context.Context, client pb.CatsServiceClient, args []string) error {
+	if len(args) != 3 {
+		return errors.New("usage: price <id> <amount> <currency>, e.g. price <id> 9.99 USD")
+	}
+	price, err := money.Parse(args[1], args[2])
+	if err != nil {
+		return fmt.Errorf("invalid price: %w", err)
+	}
+	_, err = client.UpdatePrice(ctx, &pb.UpdatePriceRequest{
+		Id:    args[0],
+		Price: &pb.Money{Amount: price.Amount, Currency: price.Currency},
+	})
+	return err
+}
+
+func deleteCat(ctx context.Context, client pb.CatsServiceClient, args []string) error {
+	id, err := singleArg("delete <id>", args)
+	if err != nil {
+		return err
+	}
+	_, err = client.DeleteCat(ctx, &pb.DeleteCatRequest{Id: id})
+	return err
+}
+
+func singleArg(usage string, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errors.New("usage: " + usage)
+	}
+	return args[0], nil
+}
+
+func printMessage(m proto.Message) error {
+	data, err := protojson.Marshal(m)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -3,14 +3,22 @@ module github.com/evleria/cats-app
 go 1.16
 
 require (
+	github.com/BurntSushi/toml v0.4.1
 	github.com/caarlos0/env/v6 v6.6.2
 	github.com/go-redis/redis/v8 v8.11.1
-	github.com/golang/protobuf v1.4.3
+	github.com/golang-jwt/jwt v3.2.2+incompatible
+	github.com/golang/protobuf v1.5.2
 	github.com/google/uuid v1.3.0
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.5.0
 	github.com/labstack/echo/v4 v4.5.0
+	github.com/nats-io/nats-server/v2 v2.6.1
+	github.com/nats-io/nats.go v1.12.3
 	github.com/streadway/amqp v1.0.0
 	github.com/stretchr/testify v1.7.0
 	go.mongodb.org/mongo-driver v1.7.0
+	golang.org/x/image v0.0.0-20210628002857-a66eb6448b8d
+	google.golang.org/genproto v0.0.0-20210617175327-b9e0b3197ced
 	google.golang.org/grpc v1.39.1
-	google.golang.org/protobuf v1.25.0
+	google.golang.org/protobuf v1.26.0
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
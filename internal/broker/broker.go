@@ -0,0 +1,54 @@
+// Package broker describes message broker backends used to deliver events between instances
+package broker
+
+import (
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/nats-io/nats.go"
+	"github.com/streadway/amqp"
+)
+
+// Kind is a message broker backend
+type Kind string
+
+const (
+	// RedisRabbit produces to redis stream, a leader bridges it to rabbitMQ fanout exchange consumed by instances
+	RedisRabbit Kind = "redis-rabbit"
+	// Redis uses a redis stream read by every instance
+	Redis Kind = "redis"
+	// Rabbit uses a rabbitMQ fanout exchange with a queue per instance
+	Rabbit Kind = "rabbit"
+	// NATS uses a NATS subject every instance subscribes to
+	NATS Kind = "nats"
+	// InProcess delivers events within a single instance
+	InProcess Kind = "inprocess"
+)
+
+// ParseKind validates name of a broker backend
+func ParseKind(name string) (Kind, error) {
+	switch kind := Kind(name); kind {
+	case RedisRabbit, Redis, Rabbit, NATS, InProcess:
+		return kind, nil
+	default:
+		return "", fmt.Errorf("unknown broker %q", name)
+	}
+}
+
+// UsesRabbit reports whether the backend requires a rabbitMQ connection
+func (k Kind) UsesRabbit() bool {
+	return k == RedisRabbit || k == Rabbit
+}
+
+// UsesRedis reports whether the backend keeps events in redis streams
+func (k Kind) UsesRedis() bool {
+	return k == RedisRabbit || k == Redis
+}
+
+// Connections holds clients of message brokers, only the ones required by selected kind have to be set
+type Connections struct {
+	Redis     *redis.Client
+	Rabbit    *amqp.Channel
+	NATS      *nats.Conn
+	InProcess *Memory
+}
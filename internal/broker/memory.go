@@ -0,0 +1,75 @@
+package broker
+
+import (
+	"sync"
+
+	"github.com/evleria/cats-app/internal/logging"
+)
+
+// memoryBuffer is a number of messages a slow subscriber may lag behind, further messages to it are dropped
+const memoryBuffer = 256
+
+type memorySubscription struct {
+	messages chan []byte
+	done     chan struct{}
+}
+
+// Memory is an in-process fanout of messages by subject
+type Memory struct {
+	mu          sync.RWMutex
+	subscribers map[string][]*memorySubscription
+}
+
+// NewMemory creates new in-process broker
+func NewMemory() *Memory {
+	return &Memory{
+		subscribers: make(map[string][]*memorySubscription),
+	}
+}
+
+// Publish delivers a copy of data to every subscriber of the subject without blocking,
+// a subscriber lagging memoryBuffer messages behind misses the message, as a stuck consumer must not stop producers
+func (m *Memory) Publish(subject string, data []byte) {
+	m.mu.RLock()
+	subscribers := m.subscribers[subject]
+	m.mu.RUnlock()
+
+	for _, subscriber := range subscribers {
+		select {
+		case subscriber.messages <- append([]byte(nil), data...):
+		case <-subscriber.done:
+		default:
+			logging.Warnf("dropped message of %s, subscriber is %d messages behind\n", subject, memoryBuffer)
+		}
+	}
+}
+
+// Subscribe returns channel of messages published to the subject after subscription,
+// and a function that cancels the subscription
+func (m *Memory) Subscribe(subject string) (<-chan []byte, func()) {
+	subscription := &memorySubscription{
+		messages: make(chan []byte, memoryBuffer),
+		done:     make(chan struct{}),
+	}
+
+	m.mu.Lock()
+	m.subscribers[subject] = append(m.subscribers[subject], subscription)
+	m.mu.Unlock()
+
+	var once sync.Once
+	return subscription.messages, func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+
+			subscribers := m.subscribers[subject]
+			for i, subscriber := range subscribers {
+				if subscriber == subscription {
+					m.subscribers[subject] = append(subscribers[:i:i], subscribers[i+1:]...)
+					break
+				}
+			}
+			close(subscription.done)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package broker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryFanout(t *testing.T) {
+	// Arrange
+	m := NewMemory()
+	first, cancelFirst := m.Subscribe("price")
+	defer cancelFirst()
+	second, cancelSecond := m.Subscribe("price")
+	defer cancelSecond()
+	other, cancelOther := m.Subscribe("status")
+	defer cancelOther()
+
+	// Act
+	m.Publish("price", []byte("event"))
+
+	// Assert
+	require.Equal(t, []byte("event"), <-first)
+	require.Equal(t, []byte("event"), <-second)
+	require.Empty(t, other)
+}
+
+func TestMemoryCancel(t *testing.T) {
+	// Arrange
+	m := NewMemory()
+	ch, cancel := m.Subscribe("price")
+
+	// Act
+	cancel()
+	cancel()
+	m.Publish("price", []byte("event"))
+
+	// Assert
+	require.Empty(t, ch)
+}
+
+func TestMemoryDropsMessagesOfFullSubscriber(t *testing.T) {
+	// Arrange
+	m := NewMemory()
+	ch, cancel := m.Subscribe("price")
+	defer cancel()
+
+	// Act
+	for i := 0; i <= memoryBuffer; i++ {
+		m.Publish("price", []byte("event"))
+	}
+
+	// Assert
+	require.Len(t, ch, memoryBuffer)
+}
+
+func TestParseKind(t *testing.T) {
+	kind, err := ParseKind("nats")
+	require.NoError(t, err)
+	require.Equal(t, NATS, kind)
+
+	_, err = ParseKind("kafka")
+	require.Error(t, err)
+}
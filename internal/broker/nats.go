@@ -0,0 +1,31 @@
+package broker
+
+import (
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+)
+
+// embeddedStartTimeout limits waiting for embedded NATS server to accept connections
+const embeddedStartTimeout = 10 * time.Second
+
+// StartEmbeddedNATS starts NATS server within the process, it is meant for local runs and small deployments
+func StartEmbeddedNATS(host string, port int) (*server.Server, error) {
+	s, err := server.NewServer(&server.Options{
+		Host:   host,
+		Port:   port,
+		NoLog:  true,
+		NoSigs: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go s.Start()
+	if !s.ReadyForConnections(embeddedStartTimeout) {
+		s.Shutdown()
+		return nil, errors.New("embedded nats server is not ready for connections")
+	}
+	return s, nil
+}
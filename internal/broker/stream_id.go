@@ -0,0 +1,55 @@
+package broker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StreamID is an ID of redis stream entry, it consists of milliseconds time and a sequence number
+type StreamID struct {
+	Millis   uint64
+	Sequence uint64
+}
+
+// ParseStreamID parses ID of redis stream entry, sequence number may be omitted
+func ParseStreamID(id string) (StreamID, error) {
+	parts := strings.SplitN(id, "-", 2)
+	millis, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return StreamID{}, fmt.Errorf("invalid stream ID %q", id)
+	}
+	streamID := StreamID{Millis: millis}
+	if len(parts) == 2 {
+		streamID.Sequence, err = strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return StreamID{}, fmt.Errorf("invalid stream ID %q", id)
+		}
+	}
+	return streamID, nil
+}
+
+// StreamIDAt returns the lowest stream ID of entries added at given time or later
+func StreamIDAt(t time.Time) StreamID {
+	return StreamID{Millis: uint64(t.UnixNano() / int64(time.Millisecond))}
+}
+
+// Time returns time the entry was added at
+func (id StreamID) Time() time.Time {
+	return time.Unix(0, int64(id.Millis)*int64(time.Millisecond)).UTC()
+}
+
+// Next returns the lowest stream ID greater than id
+func (id StreamID) Next() StreamID {
+	return StreamID{Millis: id.Millis, Sequence: id.Sequence + 1}
+}
+
+// Less reports whether id goes before other in a stream
+func (id StreamID) Less(other StreamID) bool {
+	return id.Millis < other.Millis || (id.Millis == other.Millis && id.Sequence < other.Sequence)
+}
+
+func (id StreamID) String() string {
+	return fmt.Sprintf("%d-%d", id.Millis, id.Sequence)
+}
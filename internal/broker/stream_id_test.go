@@ -0,0 +1,35 @@
+package broker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStreamID(t *testing.T) {
+	id, err := ParseStreamID("1627804800000-3")
+	require.NoError(t, err)
+	require.Equal(t, StreamID{Millis: 1627804800000, Sequence: 3}, id)
+	require.Equal(t, "1627804800000-3", id.String())
+	require.Equal(t, time.Date(2021, 8, 1, 8, 0, 0, 0, time.UTC), id.Time())
+
+	id, err = ParseStreamID("1627804800000")
+	require.NoError(t, err)
+	require.Equal(t, StreamID{Millis: 1627804800000}, id)
+
+	_, err = ParseStreamID("1627804800000-x")
+	require.Error(t, err)
+	_, err = ParseStreamID("$")
+	require.Error(t, err)
+}
+
+func TestStreamIDOrder(t *testing.T) {
+	id := StreamID{Millis: 10, Sequence: 5}
+
+	require.True(t, id.Less(id.Next()))
+	require.True(t, id.Less(StreamID{Millis: 11}))
+	require.False(t, id.Less(id))
+	require.False(t, id.Next().Less(id))
+	require.Equal(t, StreamID{Millis: 1627804800000}, StreamIDAt(time.Date(2021, 8, 1, 8, 0, 0, 0, time.UTC)))
+}
@@ -0,0 +1,157 @@
+// Package certs provides TLS configs with certificates that are reloaded when their files change
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/evleria/cats-app/internal/logging"
+)
+
+// ErrNoServerName means a peer cannot be verified against custom CAs, because connection has no server name
+var ErrNoServerName = errors.New("server name is required to verify server certificate")
+
+// Files are paths of PEM encoded certificate chain, its private key and CAs trusted to issue peer certificates.
+// All of them are optional, but certificate and key go together.
+type Files struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+}
+
+// Enabled reports whether any of the files is set
+func (f Files) Enabled() bool {
+	return f.CertFile != "" || f.KeyFile != "" || f.CAFile != ""
+}
+
+// Store holds certificate and CA pool read from files, Watch replaces them when files change
+type Store struct {
+	files Files
+
+	mu     sync.RWMutex
+	cert   *tls.Certificate
+	pool   *x509.CertPool
+	stamps map[string]stamp
+}
+
+// stamp identifies a version of a file, editors and secret mounts replace files, so size is checked along with time
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewStore reads files, it fails if any of them cannot be read or parsed
+func NewStore(files Files) (*Store, error) {
+	if (files.CertFile == "") != (files.KeyFile == "") {
+		return nil, errors.New("certificate and key files must be set together")
+	}
+	s := &Store{files: files}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Watch checks files every interval and reloads them when they change until ctx is done.
+// Files that fail to load are logged and the previous certificates are kept.
+func (s *Store) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !s.changed() {
+				continue
+			}
+			if err := s.load(); err != nil {
+				logging.Errorf("cannot reload certificates: %v\n", err)
+				continue
+			}
+			logging.Infof("reloaded certificates %+v\n", s.files)
+		}
+	}
+}
+
+// Certificate returns current certificate, or nil if store has no certificate file
+func (s *Store) Certificate() *tls.Certificate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cert
+}
+
+// Pool returns current CA pool, or nil if store has no CA file
+func (s *Store) Pool() *x509.CertPool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.pool
+}
+
+func (s *Store) load() error {
+	stamps := make(map[string]stamp)
+	for _, path := range []string{s.files.CertFile, s.files.KeyFile, s.files.CAFile} {
+		if path == "" {
+			continue
+		}
+		st, err := statFile(path)
+		if err != nil {
+			return err
+		}
+		stamps[path] = st
+	}
+
+	var cert *tls.Certificate
+	if s.files.CertFile != "" {
+		c, err := tls.LoadX509KeyPair(s.files.CertFile, s.files.KeyFile)
+		if err != nil {
+			return fmt.Errorf("cannot load certificate %s: %w", s.files.CertFile, err)
+		}
+		cert = &c
+	}
+
+	var pool *x509.CertPool
+	if s.files.CAFile != "" {
+		data, err := os.ReadFile(s.files.CAFile)
+		if err != nil {
+			return err
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("no certificates found in %s", s.files.CAFile)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cert, s.pool, s.stamps = cert, pool, stamps
+	return nil
+}
+
+func (s *Store) changed() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for path, old := range s.stamps {
+		st, err := statFile(path)
+		// a missing file is reported by load, so it is not swallowed while a secret is being replaced
+		if err != nil || st != old {
+			return true
+		}
+	}
+	return false
+}
+
+func statFile(path string) (stamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return stamp{}, err
+	}
+	return stamp{modTime: info.ModTime(), size: info.Size()}, nil
+}
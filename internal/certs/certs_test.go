@@ -0,0 +1,146 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStore(t *testing.T) {
+	// Arrange
+	ca := newTestCA(t, "ca")
+	files := ca.issueFiles(t, t.TempDir(), "server", x509.ExtKeyUsageServerAuth)
+
+	// Act
+	store, err := NewStore(files)
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, store.Certificate())
+	require.NotNil(t, store.Pool())
+}
+
+func TestNewStoreRequiresKey(t *testing.T) {
+	// Act
+	_, err := NewStore(Files{CertFile: "server.pem"})
+
+	// Assert
+	require.Error(t, err)
+}
+
+func TestNewStoreInvalidCA(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(path, []byte("not a certificate"), 0o600))
+
+	// Act
+	_, err := NewStore(Files{CAFile: path})
+
+	// Assert
+	require.Error(t, err)
+}
+
+func TestStoreWatchReloads(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	ca := newTestCA(t, "ca")
+	files := ca.issueFiles(t, dir, "server", x509.ExtKeyUsageServerAuth)
+	store, err := NewStore(files)
+	require.NoError(t, err)
+	old := store.Certificate()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go store.Watch(ctx, 10*time.Millisecond)
+
+	// Act
+	ca.issueFiles(t, dir, "server", x509.ExtKeyUsageServerAuth)
+
+	// Assert
+	require.Eventually(t, func() bool { return store.Certificate() != old }, time.Second, 10*time.Millisecond)
+}
+
+func TestStoreWatchKeepsValidCertificate(t *testing.T) {
+	// Arrange
+	ca := newTestCA(t, "ca")
+	files := ca.issueFiles(t, t.TempDir(), "server", x509.ExtKeyUsageServerAuth)
+	store, err := NewStore(files)
+	require.NoError(t, err)
+	old := store.Certificate()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go store.Watch(ctx, 10*time.Millisecond)
+
+	// Act
+	require.NoError(t, os.WriteFile(files.KeyFile, []byte("broken key"), 0o600))
+	time.Sleep(50 * time.Millisecond)
+
+	// Assert
+	require.Same(t, old, store.Certificate())
+}
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issueFiles writes certificate for localhost issued by the CA, its key and the CA into dir
+func (ca *testCA) issueFiles(t *testing.T, dir, name string, usage x509.ExtKeyUsage) Files {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	files := Files{
+		CertFile: filepath.Join(dir, name+".pem"),
+		KeyFile:  filepath.Join(dir, name+"-key.pem"),
+		CAFile:   filepath.Join(dir, name+"-ca.pem"),
+	}
+	require.NoError(t, os.WriteFile(files.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(files.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.WriteFile(files.CAFile, ca.pem, 0o600))
+	return files
+}
@@ -0,0 +1,69 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+)
+
+// ServerConfig creates TLS config of a listener serving store's certificate.
+// When store has CA file, clients must present certificates issued by one of its CAs, i.e. mutual TLS is used.
+func ServerConfig(store *Store) *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return store.Certificate(), nil
+		},
+	}
+	if store.Pool() != nil {
+		// client certificates are verified by VerifyConnection, so CAs reloaded from file are used right away
+		cfg.ClientAuth = tls.RequireAnyClientCert
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyChain(store.Pool(), cs.PeerCertificates, "", x509.ExtKeyUsageClientAuth)
+		}
+	}
+	return cfg
+}
+
+// ClientConfig creates TLS config of a client connecting to serverName, it presents store's certificate if it has one.
+// Servers are verified against store's CAs if it has CA file, or against system CAs otherwise.
+// Empty serverName is allowed for clients that set it per connection, e.g. mongo driver.
+func ClientConfig(store *Store, serverName string) *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+	}
+	if store.Certificate() != nil {
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return store.Certificate(), nil
+		}
+	}
+	if store.Pool() != nil {
+		// default verification is replaced by the same one against CAs reloaded from file
+		cfg.InsecureSkipVerify = true //nolint:gosec
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			if cs.ServerName == "" {
+				return ErrNoServerName
+			}
+			return verifyChain(store.Pool(), cs.PeerCertificates, cs.ServerName, x509.ExtKeyUsageServerAuth)
+		}
+	}
+	return cfg
+}
+
+func verifyChain(pool *x509.CertPool, certs []*x509.Certificate, dnsName string, usage x509.ExtKeyUsage) error {
+	if len(certs) == 0 {
+		return errors.New("peer has not presented a certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         pool,
+		Intermediates: intermediates,
+		DNSName:       dnsName,
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	})
+	return err
+}
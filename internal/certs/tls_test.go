@@ -0,0 +1,101 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMutualTLS(t *testing.T) {
+	// Arrange
+	ca := newTestCA(t, "ca")
+	server := mustStore(t, ca.issueFiles(t, t.TempDir(), "server", x509.ExtKeyUsageServerAuth))
+	client := mustStore(t, ca.issueFiles(t, t.TempDir(), "client", x509.ExtKeyUsageClientAuth))
+
+	// Act
+	serverErr, clientErr := handshake(t, ServerConfig(server), ClientConfig(client, "localhost"))
+
+	// Assert
+	require.NoError(t, serverErr)
+	require.NoError(t, clientErr)
+}
+
+func TestMutualTLSRejectsUnknownClient(t *testing.T) {
+	// Arrange
+	ca := newTestCA(t, "ca")
+	server := mustStore(t, ca.issueFiles(t, t.TempDir(), "server", x509.ExtKeyUsageServerAuth))
+	files := newTestCA(t, "other").issueFiles(t, t.TempDir(), "client", x509.ExtKeyUsageClientAuth)
+	files.CAFile = server.files.CAFile
+	client := mustStore(t, files)
+
+	// Act
+	serverErr, _ := handshake(t, ServerConfig(server), ClientConfig(client, "localhost"))
+
+	// Assert
+	require.Error(t, serverErr)
+}
+
+func TestMutualTLSRejectsClientWithoutCertificate(t *testing.T) {
+	// Arrange
+	ca := newTestCA(t, "ca")
+	server := mustStore(t, ca.issueFiles(t, t.TempDir(), "server", x509.ExtKeyUsageServerAuth))
+	client := mustStore(t, Files{CAFile: server.files.CAFile})
+
+	// Act
+	serverErr, _ := handshake(t, ServerConfig(server), ClientConfig(client, "localhost"))
+
+	// Assert
+	require.Error(t, serverErr)
+}
+
+func TestClientVerifiesServerName(t *testing.T) {
+	// Arrange
+	ca := newTestCA(t, "ca")
+	files := ca.issueFiles(t, t.TempDir(), "server", x509.ExtKeyUsageServerAuth)
+	server := mustStore(t, Files{CertFile: files.CertFile, KeyFile: files.KeyFile})
+	client := mustStore(t, Files{CAFile: files.CAFile})
+
+	// Act
+	_, wrongNameErr := handshake(t, ServerConfig(server), ClientConfig(client, "cats.example.com"))
+	_, noNameErr := handshake(t, ServerConfig(server), ClientConfig(client, ""))
+	_, err := handshake(t, ServerConfig(server), ClientConfig(client, "localhost"))
+
+	// Assert
+	require.Error(t, wrongNameErr)
+	require.ErrorIs(t, noNameErr, ErrNoServerName)
+	require.NoError(t, err)
+}
+
+func mustStore(t *testing.T, files Files) *Store {
+	store, err := NewStore(files)
+	require.NoError(t, err)
+	return store
+}
+
+func handshake(t *testing.T, serverConfig, clientConfig *tls.Config) (serverErr, clientErr error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close() //nolint:errcheck,gosec
+
+	done := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer conn.Close() //nolint:errcheck,gosec
+		done <- tls.Server(conn, serverConfig).Handshake()
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close() //nolint:errcheck,gosec
+	clientConn := tls.Client(conn, clientConfig)
+	// with TLS 1.3 client finishes before server verifies its certificate, so rejection shows up only on server side
+	clientErr = clientConn.Handshake()
+	return <-done, clientErr
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrpcDialTarget(t *testing.T) {
+	for _, test := range []struct {
+		addr, target string
+		expected     string
+	}{
+		{addr: ":6000", expected: "localhost:6000"},
+		{addr: "0.0.0.0:6000", expected: "localhost:6000"},
+		{addr: "[::]:6000", expected: "localhost:6000"},
+		{addr: "127.0.0.1:6000", expected: "127.0.0.1:6000"},
+		{addr: "[::1]:6000", expected: "[::1]:6000"},
+		{addr: "cats:6000", expected: "cats:6000"},
+		{addr: "6000", expected: "6000"},
+		{addr: ":6000", target: "dns:///cats-grpc:6000", expected: "dns:///cats-grpc:6000"},
+	} {
+		// Arrange
+		cfg := Сonfig{GrpcAddr: test.addr, GrpcTarget: test.target}
+
+		// Act
+		actual := cfg.GrpcDialTarget()
+
+		// Assert
+		require.Equal(t, test.expected, actual, "%s %s", test.addr, test.target)
+	}
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/caarlos0/env/v6"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv is an environment variable with path to config file, used when -config flag is not given
+const FileEnv = "CONFIG_FILE"
+
+// Loader reads config from layers, each of them overrides the previous one:
+// defaults, YAML or TOML file, environment variables and command line flags.
+//
+// File keys are environment variable names in any case, nested tables are joined with underscore,
+// so `mongo_uri: ...` and `mongo: {uri: ...}` set the same MONGO_URI setting.
+// Flags are environment variable names in lower case with dashes, e.g. -mongo-uri.
+type Loader struct {
+	file    string
+	flags   map[string]string
+	environ func() []string
+}
+
+// NewLoader creates a loader and registers -config flag and a flag for every setting in flags.
+// Flags are remembered when they are parsed, so every Load applies them again.
+func NewLoader(flags *flag.FlagSet) *Loader {
+	l := &Loader{
+		flags:   make(map[string]string),
+		environ: os.Environ,
+	}
+	flags.StringVar(&l.file, "config", "", "path to YAML or TOML config file, overrides "+FileEnv)
+	for _, s := range settings() {
+		flags.Var(&settingFlag{name: s.name, isBool: s.isBool, values: l.flags}, flagName(s.name), "overrides "+s.name)
+	}
+	return l
+}
+
+// Load reads and validates config, all problems found are returned at once as *ValidationError
+func (l *Loader) Load() (*Сonfig, error) {
+	environment := toMap(l.environ())
+
+	vars, problems := l.readFile(environment)
+	for name, value := range environment {
+		vars[name] = value
+	}
+	for name, value := range l.flags {
+		vars[name] = value
+	}
+
+	cfg := new(Сonfig)
+	if err := env.Parse(cfg, env.Options{Environment: vars}); err != nil {
+		// env stops at the first malformed value, remaining settings cannot be validated without it
+		return nil, &ValidationError{Problems: append(problems, err.Error())}
+	}
+	if err := cfg.Validate(); err != nil {
+		problems = append(problems, err.(*ValidationError).Problems...)
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+// readFile reads settings from config file as environment variables, file is optional
+func (l *Loader) readFile(environment map[string]string) (map[string]string, []string) {
+	vars := make(map[string]string)
+	path := l.file
+	if path == "" {
+		path = environment[FileEnv]
+	}
+	if path == "" {
+		return vars, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return vars, []string{err.Error()}
+	}
+
+	var tree map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return vars, []string{fmt.Sprintf("unsupported config file extension %q, must be .yaml, .yml or .toml", ext)}
+	}
+	if err != nil {
+		return vars, []string{fmt.Sprintf("cannot parse %s: %v", path, err)}
+	}
+
+	known := make(map[string]setting)
+	for _, s := range settings() {
+		known[s.name] = s
+	}
+	var problems []string
+	flatten("", tree, func(name string, value interface{}) {
+		s, ok := known[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("unknown setting %s in %s", name, path))
+			return
+		}
+		vars[name] = formatValue(value, s.separator)
+	})
+	sort.Strings(problems)
+	return vars, problems
+}
+
+// flatten walks nested tables of config file and reports values by environment variable names
+func flatten(prefix string, tree map[string]interface{}, visit func(name string, value interface{})) {
+	for key, value := range tree {
+		name := strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+		if table, ok := value.(map[string]interface{}); ok {
+			flatten(name, table, visit)
+			continue
+		}
+		visit(name, value)
+	}
+}
+
+// formatValue formats value of config file the way it would be given in an environment variable
+func formatValue(value interface{}, separator string) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, separator)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// setting describes a field of Сonfig read from an environment variable
+type setting struct {
+	name      string
+	separator string
+	isBool    bool
+}
+
+func settings() []setting {
+	t := reflect.TypeOf(Сonfig{})
+	result := make([]setting, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := field.Tag.Lookup("env")
+		if !ok {
+			continue
+		}
+		separator := field.Tag.Get("envSeparator")
+		if separator == "" {
+			separator = ","
+		}
+		result = append(result, setting{name: name, separator: separator, isBool: field.Type.Kind() == reflect.Bool})
+	}
+	return result
+}
+
+func flagName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+}
+
+// settingFlag records a flag value as environment variable, so it is parsed the same way as other layers
+type settingFlag struct {
+	name   string
+	isBool bool
+	values map[string]string
+}
+
+func (f *settingFlag) String() string {
+	if f.values == nil {
+		return ""
+	}
+	return f.values[f.name]
+}
+
+func (f *settingFlag) Set(value string) error {
+	f.values[f.name] = value
+	return nil
+}
+
+// IsBoolFlag lets boolean settings be given as a bare flag, e.g. -nats-embedded
+func (f *settingFlag) IsBoolFlag() bool {
+	return f.isBool
+}
+
+func toMap(environ []string) map[string]string {
+	result := make(map[string]string, len(environ))
+	for _, variable := range environ {
+		if i := strings.IndexByte(variable, '='); i > 0 {
+			result[variable[:i]] = variable[i+1:]
+		}
+	}
+	return result
+}
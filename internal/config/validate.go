@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/evleria/cats-app/internal/broker"
+	"github.com/evleria/cats-app/internal/certs"
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+// ValidationError lists all problems of config
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks settings that cannot be checked by parsing alone, all problems are returned at once as *ValidationError
+func (c *Сonfig) Validate() error {
+	v := new(validator)
+
+	v.check(validAddr(c.HTTPAddr), "HTTP_ADDR must be host:port, got %q", c.HTTPAddr)
+	v.check(validAddr(c.GrpcAddr), "GRPC_ADDR must be host:port, got %q", c.GrpcAddr)
+	v.check(validAddr(c.HealthAddr), "HEALTH_ADDR must be host:port, got %q", c.HealthAddr)
+	v.check(len(c.Roles) > 0, "ROLES must not be empty")
+	v.checkPositive("SHUTDOWN_TIMEOUT", c.ShutdownTimeout)
+	v.check(len(c.Tenants) > 0, "TENANTS must not be empty")
+	for _, id := range c.Tenants {
+		if err := tenant.Validate(id); err != nil {
+			v.add("TENANTS: %v", err)
+		}
+	}
+	v.check(c.TenantJWTSecret == "" || c.TenantJWTClaim != "", "TENANT_JWT_CLAIM must not be empty when TENANT_JWT_SECRET is set")
+	// tenant header is not authenticated, so without tokens any client could pick any tenant
+	multiTenant := len(c.Tenants) > 1 || (len(c.Tenants) == 1 && c.Tenants[0] != tenant.Default)
+	v.check(c.TenantJWTSecret != "" || (!multiTenant && !c.TenantRequired),
+		"TENANT_JWT_SECRET must be set when TENANTS has tenants other than %s or TENANT_REQUIRED is true", tenant.Default)
+
+	v.checkURL("MONGO_URI", c.MongoConnectionURI(), "mongodb", "mongodb+srv")
+	v.check(c.MongoDB != "", "MONGO_DB must not be empty")
+	v.checkPositive("MIGRATION_LOCK_TTL", c.MigrationLockTTL)
+	if c.UsesRedis() {
+		v.checkURL("REDIS_URL", c.RedisConnectionURL(), "redis", "rediss")
+	}
+	v.checkURL("RABBIT_URL", c.RabbitConnectionURL(), "amqp", "amqps")
+	for _, server := range strings.Split(c.NatsConnectionURL(), ",") {
+		v.checkURL("NATS_URL", strings.TrimSpace(server), "nats", "tls")
+	}
+
+	v.checkCertPair("HTTP_TLS", c.HTTPTLS())
+	v.checkCertPair("GRPC_TLS", c.GrpcTLS())
+	v.check(c.GrpcTLSClientCAFile == "" || c.GrpcTLSCertFile != "", "GRPC_TLS_CLIENT_CA_FILE requires GRPC_TLS_CERT_FILE")
+	// REST gateway calls gRPC server, so it needs a client certificate when mutual TLS is on
+	v.check(c.GrpcTLSClientCAFile == "" || c.GrpcClientCertFile != "", "GRPC_TLS_CLIENT_CA_FILE requires GRPC_CLIENT_CERT_FILE")
+	v.checkCertPair("GRPC_CLIENT", c.GrpcClientTLS())
+	v.checkCertPair("MONGO_TLS", c.MongoTLS())
+	v.checkCertPair("REDIS_TLS", c.RedisTLS())
+	v.check(!c.RedisTLS().Enabled() || strings.HasPrefix(c.RedisConnectionURL(), "rediss:"), "REDIS_TLS files require rediss scheme of REDIS_URL")
+	v.checkCertPair("RABBIT_TLS", c.RabbitTLS())
+	v.check(!c.RabbitTLS().Enabled() || strings.HasPrefix(c.RabbitConnectionURL(), "amqps:"), "RABBIT_TLS files require amqps scheme of RABBIT_URL")
+	v.checkPositive("TLS_RELOAD_INTERVAL", c.TLSReloadInterval)
+
+	if _, err := broker.ParseKind(c.Broker); err != nil {
+		v.add("BROKER: %v", err)
+	}
+	if _, err := event.NewCodec(event.Format(c.EventFormat), c.EventSource); err != nil {
+		v.add("EVENT_FORMAT: %v", err)
+	}
+	v.check(c.DedupStore == "memory" || c.DedupStore == "redis", "DEDUP_STORE must be memory or redis, got %q", c.DedupStore)
+	v.checkStore("RATE_LIMIT_STORE", c.RateLimitStore, "memory")
+	v.checkStore("IDEMPOTENCY_STORE", c.IdempotencyStore, "mongo")
+	v.checkStore("LEADER_STORE", c.LeaderStore, "mongo")
+	v.checkStore("REPLAY_SOURCE", c.ReplaySource, "mongo")
+
+	v.check(c.ConsumerNumber >= 0, "CONSUMER_NUMBER must not be negative")
+	v.check(c.StreamMaxLen >= 0, "STREAM_MAX_LEN must not be negative")
+	v.check(c.StreamMaxAge >= 0, "STREAM_MAX_AGE must not be negative")
+	v.checkPositive("LEADER_TTL", c.LeaderTTL)
+	v.checkPositive("STREAM_TRIM_INTERVAL", c.StreamTrimInterval)
+	v.checkPositive("DEDUP_TTL", c.DedupTTL)
+	v.checkPositive("RESERVATION_TTL", c.ReservationTTL)
+	v.checkPositive("RESERVATION_CHECK_INTERVAL", c.ReservationCheckInterval)
+	v.checkPositive("SCHEDULE_CHECK_INTERVAL", c.ScheduleCheckInterval)
+	v.checkPositive("PRICING_INTERVAL", c.PricingInterval)
+	v.checkPositive("IDEMPOTENCY_TTL", c.IdempotencyTTL)
+	v.checkPositive("GRPC_UNARY_TIMEOUT", c.GrpcUnaryTimeout)
+	v.checkPositive("GRPC_STREAM_TIMEOUT", c.GrpcStreamTimeout)
+
+	v.check(c.PhotoMaxSize > 0, "PHOTO_MAX_SIZE must be positive")
+	v.check(c.PhotoThumbnailSize > 0, "PHOTO_THUMBNAIL_SIZE must be positive")
+	v.check(len(c.PhotoAllowedTypes) > 0, "PHOTO_ALLOWED_TYPES must not be empty")
+
+	v.check(c.RateLimitReadRate >= 0, "RATE_LIMIT_READ_RATE must not be negative")
+	v.check(c.RateLimitReadBurst >= 0, "RATE_LIMIT_READ_BURST must not be negative")
+	v.check(c.RateLimitWriteRate >= 0, "RATE_LIMIT_WRITE_RATE must not be negative")
+	v.check(c.RateLimitWriteBurst >= 0, "RATE_LIMIT_WRITE_BURST must not be negative")
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+// validator collects problems instead of stopping at the first one
+type validator struct {
+	problems []string
+}
+
+func (v *validator) add(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) check(ok bool, format string, args ...interface{}) {
+	if !ok {
+		v.add(format, args...)
+	}
+}
+
+func (v *validator) checkPositive(name string, d time.Duration) {
+	v.check(d > 0, "%s must be positive, got %s", name, d)
+}
+
+// checkStore checks store setting of a component, which is redis, its alternative or auto
+func (v *validator) checkStore(name, setting, alternative string) {
+	v.check(setting == "redis" || setting == alternative || setting == "auto",
+		"%s must be redis, %s or auto, got %q", name, alternative, setting)
+}
+
+func (v *validator) checkCertPair(prefix string, files certs.Files) {
+	v.check((files.CertFile == "") == (files.KeyFile == ""), "%s_CERT_FILE and %s_KEY_FILE must be set together", prefix, prefix)
+}
+
+func (v *validator) checkURL(name, raw string, schemes ...string) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		// url errors quote the whole URL, it must not get into logs with a password
+		v.add("%s is not a valid URL", name)
+		return
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			v.check(u.Host != "", "%s must have a host", name)
+			return
+		}
+	}
+	v.add("%s must have %s scheme, got %q", name, strings.Join(schemes, " or "), u.Scheme)
+}
+
+func validAddr(addr string) bool {
+	_, port, err := net.SplitHostPort(addr)
+	return err == nil && port != ""
+}
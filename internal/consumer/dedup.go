@@ -0,0 +1,183 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/logging"
+)
+
+// DedupStore remembers processed events to detect duplicates and stale events.
+// Events without sequence are only checked for duplicates.
+type DedupStore interface {
+	IsProcessed(ctx context.Context, e event.PriceChanged) (bool, error)
+	MarkProcessed(ctx context.Context, e event.PriceChanged) error
+}
+
+type deduplicatingPrice struct {
+	consumer Price
+	store    DedupStore
+}
+
+// NewDeduplicatingPriceConsumer wraps consumer so that duplicated and out-of-order stale events are dropped
+func NewDeduplicatingPriceConsumer(consumer Price, store DedupStore) Price {
+	return &deduplicatingPrice{
+		consumer: consumer,
+		store:    store,
+	}
+}
+
+func (d *deduplicatingPrice) Consume(ctx context.Context, callbackFunc func(e event.PriceChanged) error) error {
+	return d.consumer.Consume(ctx, func(e event.PriceChanged) error {
+		processed, err := d.store.IsProcessed(ctx, e)
+		if err != nil {
+			return err
+		}
+		if processed {
+			logging.Debugf("skipped duplicate or stale message: {%v, %v, %d}\n", e.ID, e.CatID, e.Sequence)
+			return nil
+		}
+
+		if err := callbackFunc(e); err != nil {
+			return err
+		}
+		return d.store.MarkProcessed(ctx, e)
+	})
+}
+
+type memoryDedup struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	events    map[uuid.UUID]time.Time
+	sequences map[uuid.UUID]lastSequence
+	nextSweep time.Time
+}
+
+// lastSequence is the highest processed sequence of a cat
+type lastSequence struct {
+	sequence  uint64
+	expiresAt time.Time
+}
+
+// NewMemoryDedupStore creates dedup store kept in process memory, event IDs are remembered for ttl,
+// the last sequence of a cat is remembered for ttl since its last event
+func NewMemoryDedupStore(ttl time.Duration) DedupStore {
+	return &memoryDedup{
+		ttl:       ttl,
+		events:    map[uuid.UUID]time.Time{},
+		sequences: map[uuid.UUID]lastSequence{},
+	}
+}
+
+func (m *memoryDedup) IsProcessed(_ context.Context, e event.PriceChanged) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if expiresAt, ok := m.events[e.ID]; ok && now.Before(expiresAt) {
+		return true, nil
+	}
+	last, ok := m.sequences[e.CatID]
+	return ok && now.Before(last.expiresAt) && e.Sequence > 0 && e.Sequence <= last.sequence, nil
+}
+
+func (m *memoryDedup) MarkProcessed(_ context.Context, e event.PriceChanged) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.events[e.ID] = now.Add(m.ttl)
+	last := m.sequences[e.CatID]
+	if now.After(last.expiresAt) {
+		last.sequence = 0
+	}
+	if e.Sequence > last.sequence {
+		last.sequence = e.Sequence
+	}
+	last.expiresAt = now.Add(m.ttl)
+	m.sequences[e.CatID] = last
+
+	if now.After(m.nextSweep) {
+		for id, expiresAt := range m.events {
+			if now.After(expiresAt) {
+				delete(m.events, id)
+			}
+		}
+		for catID, last := range m.sequences {
+			if now.After(last.expiresAt) {
+				delete(m.sequences, catID)
+			}
+		}
+		m.nextSweep = now.Add(m.ttl)
+	}
+	return nil
+}
+
+// markProcessedScript remembers event ID and raises last sequence of a cat, never lowering it.
+// Both keys expire after ttl, the sequence one is prolonged by every event of the cat.
+const markProcessedScript = `
+redis.call("SET", KEYS[1], 1, "PX", ARGV[1])
+if tonumber(ARGV[2]) > tonumber(redis.call("GET", KEYS[2]) or "0") then
+	redis.call("SET", KEYS[2], ARGV[2], "PX", ARGV[1])
+else
+	redis.call("PEXPIRE", KEYS[2], ARGV[1])
+end
+return 1
+`
+
+type redisDedup struct {
+	redis         *redis.Client
+	namespace     string
+	ttl           time.Duration
+	markProcessed *redis.Script
+}
+
+// NewRedisDedupStore creates dedup store kept in redis, so it survives restarts, entries expire the same way as in memory.
+// Namespace must be unique per consumer, e.g. a queue name, because every consumer processes events independently.
+func NewRedisDedupStore(redisClient *redis.Client, namespace string, ttl time.Duration) DedupStore {
+	return &redisDedup{
+		redis:         redisClient,
+		namespace:     namespace,
+		ttl:           ttl,
+		markProcessed: redis.NewScript(markProcessedScript),
+	}
+}
+
+func (r *redisDedup) IsProcessed(ctx context.Context, e event.PriceChanged) (bool, error) {
+	values, err := r.redis.MGet(ctx, r.eventKey(e), r.sequenceKey(e)).Result()
+	if err != nil {
+		return false, err
+	}
+	if values[0] != nil {
+		return true, nil
+	}
+	if e.Sequence == 0 || values[1] == nil {
+		return false, nil
+	}
+
+	last, err := strconv.ParseUint(values[1].(string), 10, 64)
+	if err != nil {
+		return false, err
+	}
+	return e.Sequence <= last, nil
+}
+
+func (r *redisDedup) MarkProcessed(ctx context.Context, e event.PriceChanged) error {
+	keys := []string{r.eventKey(e), r.sequenceKey(e)}
+	return r.markProcessed.Run(ctx, r.redis, keys, r.ttl.Milliseconds(), e.Sequence).Err()
+}
+
+func (r *redisDedup) eventKey(e event.PriceChanged) string {
+	return fmt.Sprintf("dedup:%s:event:%s", r.namespace, e.ID)
+}
+
+func (r *redisDedup) sequenceKey(e event.PriceChanged) string {
+	return fmt.Sprintf("dedup:%s:sequence:%s", r.namespace, e.CatID)
+}
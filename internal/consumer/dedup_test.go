@@ -0,0 +1,86 @@
+package consumer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/money"
+)
+
+// sliceConsumer delivers a fixed list of events
+type sliceConsumer []event.PriceChanged
+
+func (s sliceConsumer) Consume(_ context.Context, callbackFunc func(e event.PriceChanged) error) error {
+	for _, e := range s {
+		if err := callbackFunc(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestDeduplicatingPriceConsumer(t *testing.T) {
+	// Arrange
+	catID := uuid.New()
+	first := event.NewPriceChanged(catID, 1, usd(0), usd(999))
+	second := event.NewPriceChanged(catID, 2, usd(999), usd(799))
+	third := event.NewPriceChanged(catID, 3, usd(799), usd(599))
+	legacy := event.NewPriceChanged(catID, 0, usd(0), usd(499))
+	c := NewDeduplicatingPriceConsumer(sliceConsumer{first, second, second, third, second, legacy, legacy}, NewMemoryDedupStore(time.Hour))
+
+	// Act
+	var consumed []event.PriceChanged
+	err := c.Consume(context.Background(), func(e event.PriceChanged) error {
+		consumed = append(consumed, e)
+		return nil
+	})
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, []event.PriceChanged{first, second, third, legacy}, consumed)
+}
+
+func TestDeduplicatingPriceConsumerRetriesFailedEvent(t *testing.T) {
+	// Arrange
+	e := event.NewPriceChanged(uuid.New(), 1, usd(0), usd(999))
+	store := NewMemoryDedupStore(time.Hour)
+	c := NewDeduplicatingPriceConsumer(sliceConsumer{e}, store)
+
+	// Act
+	err := c.Consume(context.Background(), func(event.PriceChanged) error {
+		return context.Canceled
+	})
+	processed, _ := store.IsProcessed(context.Background(), e)
+
+	// Assert
+	require.ErrorIs(t, err, context.Canceled)
+	require.False(t, processed)
+}
+
+func TestMemoryDedupStoreEvictsExpiredEntries(t *testing.T) {
+	// Arrange
+	store := NewMemoryDedupStore(time.Millisecond).(*memoryDedup)
+	old := event.NewPriceChanged(uuid.New(), 5, usd(0), usd(999))
+	recent := event.NewPriceChanged(uuid.New(), 1, usd(0), usd(799))
+	require.NoError(t, store.MarkProcessed(context.Background(), old))
+	time.Sleep(5 * time.Millisecond)
+
+	// Act
+	err := store.MarkProcessed(context.Background(), recent)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, store.events, 1)
+	require.Len(t, store.sequences, 1)
+	require.Contains(t, store.sequences, recent.CatID)
+}
+
+// usd returns money of amount in cents
+func usd(cents int64) money.Money {
+	return money.Money{Amount: cents, Currency: money.DefaultCurrency}
+}
@@ -0,0 +1,40 @@
+package consumer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/evleria/cats-app/internal/broker"
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+// PriceTopic is a name of stream, exchange or subject price events of default tenant are consumed from,
+// topics of other tenants are named by tenant.Topic
+const PriceTopic = "price"
+
+// NewPriceConsumer creates consumer of price events of a tenant for given broker backend, every instance receives all events.
+// Consumer gets events of the tenant only, see NewTenantPriceConsumer.
+func NewPriceConsumer(kind broker.Kind, conns broker.Connections, codec *event.Codec, consumerNumber int, tenantID string) (Price, error) {
+	topic := tenant.Topic(PriceTopic, tenantID)
+	var (
+		consumer Price
+		err      error
+	)
+	switch kind {
+	case broker.RedisRabbit, broker.Rabbit:
+		consumer, err = NewRabbitPriceConsumer(conns.Rabbit, fmt.Sprintf("%s_%d", topic, consumerNumber), topic, codec)
+	case broker.Redis:
+		consumer = NewRedisPriceConsumer(conns.Redis, topic, fmt.Sprintf("%d-0", time.Now().UnixNano()/int64(time.Millisecond)), codec)
+	case broker.NATS:
+		consumer = NewNATSPriceConsumer(conns.NATS, topic, codec)
+	case broker.InProcess:
+		consumer = NewMemoryPriceConsumer(conns.InProcess, topic, codec)
+	default:
+		err = fmt.Errorf("unknown broker %q", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewTenantPriceConsumer(consumer, tenantID), nil
+}
@@ -0,0 +1,53 @@
+package consumer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/broker"
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+func TestPriceConsumersStopOnCancel(t *testing.T) {
+	codec, err := event.NewCodec(event.FormatJSON, "/test")
+	require.NoError(t, err)
+	addr, _ := serveEmptyStreams(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { redisClient.Close() })
+	natsServer, err := broker.StartEmbeddedNATS("127.0.0.1", -1)
+	require.NoError(t, err)
+	t.Cleanup(natsServer.Shutdown)
+	natsConn, err := nats.Connect(natsServer.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(natsConn.Close)
+	conns := broker.Connections{Redis: redisClient, NATS: natsConn, InProcess: broker.NewMemory()}
+
+	for _, kind := range []broker.Kind{broker.Redis, broker.NATS, broker.InProcess} {
+		// Arrange
+		c, err := NewPriceConsumer(kind, conns, codec, 1, tenant.Default)
+		require.NoError(t, err, kind)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- c.Consume(ctx, func(event.PriceChanged) error { return nil })
+		}()
+
+		// Act
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+
+		// Assert
+		select {
+		case err := <-done:
+			require.ErrorIs(t, err, context.Canceled, kind)
+		case <-time.After(readBlockTimeout + time.Second):
+			t.Fatalf("%s consumer has not stopped", kind)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package consumer
+
+import (
+	"context"
+
+	"github.com/evleria/cats-app/internal/broker"
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/logging"
+)
+
+type memoryPrice struct {
+	memory  *broker.Memory
+	subject string
+	codec   *event.Codec
+}
+
+// NewMemoryPriceConsumer creates new consumer of events delivered within the process
+func NewMemoryPriceConsumer(memory *broker.Memory, subject string, codec *event.Codec) Price {
+	return &memoryPrice{
+		memory:  memory,
+		subject: subject,
+		codec:   codec,
+	}
+}
+
+func (p *memoryPrice) Consume(ctx context.Context, callbackFunc func(e event.PriceChanged) error) error {
+	msgs, cancel := p.memory.Subscribe(p.subject)
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case data := <-msgs:
+			e, err := p.codec.DecodePriceChanged(data)
+			if err != nil {
+				return err
+			}
+
+			logging.Debugf("consumed message in process: {%v, %v, %v}\n", e.ID, e.CatID, e.NewPrice)
+			err = callbackFunc(e)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package consumer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/broker"
+	"github.com/evleria/cats-app/internal/event"
+)
+
+func TestMemoryPriceConsume(t *testing.T) {
+	// Arrange
+	codec, err := event.NewCodec(event.FormatJSON, "/test")
+	require.NoError(t, err)
+	memory := broker.NewMemory()
+	c := NewMemoryPriceConsumer(memory, PriceTopic, codec)
+	sent := event.NewPriceChanged(uuid.New(), 1, usd(0), usd(1000))
+	sent.OccurredAt = sent.OccurredAt.UTC()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	received := make(chan event.PriceChanged, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Consume(ctx, func(e event.PriceChanged) error {
+			select {
+			case received <- e:
+			default:
+			}
+			cancel()
+			return nil
+		})
+	}()
+
+	// Act
+	data, err := codec.EncodePriceChanged(sent)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		memory.Publish(PriceTopic, data)
+		return len(received) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	// Assert
+	require.Equal(t, sent.ID, (<-received).ID)
+	require.ErrorIs(t, <-done, context.Canceled)
+}
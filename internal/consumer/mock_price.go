@@ -5,8 +5,9 @@ package consumer
 import (
 	context "context"
 
-	uuid "github.com/google/uuid"
 	mock "github.com/stretchr/testify/mock"
+
+	event "github.com/evleria/cats-app/internal/event"
 )
 
 // MockPrice is an autogenerated mock type for the Price type
@@ -15,11 +16,11 @@ type MockPrice struct {
 }
 
 // Consume provides a mock function with given fields: ctx, callbackFunc
-func (_m *MockPrice) Consume(ctx context.Context, callbackFunc func(uuid.UUID, float64) error) error {
+func (_m *MockPrice) Consume(ctx context.Context, callbackFunc func(event.PriceChanged) error) error {
 	ret := _m.Called(ctx, callbackFunc)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, func(uuid.UUID, float64) error) error); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, func(event.PriceChanged) error) error); ok {
 		r0 = rf(ctx, callbackFunc)
 	} else {
 		r0 = ret.Error(0)
@@ -0,0 +1,55 @@
+package consumer
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/logging"
+)
+
+// natsBuffer is a number of messages buffered for a subscription
+const natsBuffer = 256
+
+type natsPrice struct {
+	conn    *nats.Conn
+	subject string
+	codec   *event.Codec
+}
+
+// NewNATSPriceConsumer creates new NATS price consumer, every consumer receives all messages of the subject
+func NewNATSPriceConsumer(conn *nats.Conn, subject string, codec *event.Codec) Price {
+	return &natsPrice{
+		conn:    conn,
+		subject: subject,
+		codec:   codec,
+	}
+}
+
+func (p *natsPrice) Consume(ctx context.Context, callbackFunc func(e event.PriceChanged) error) error {
+	msgs := make(chan *nats.Msg, natsBuffer)
+	subscription, err := p.conn.ChanSubscribe(p.subject, msgs)
+	if err != nil {
+		return err
+	}
+	defer subscription.Unsubscribe() //nolint:errcheck
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg := <-msgs:
+			e, err := p.codec.DecodePriceChanged(msg.Data)
+			if err != nil {
+				return err
+			}
+
+			logging.Debugf("consumed message from nats: {%v, %v, %v}\n", e.ID, e.CatID, e.NewPrice)
+			err = callbackFunc(e)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
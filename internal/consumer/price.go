@@ -4,10 +4,10 @@ package consumer
 import (
 	"context"
 
-	"github.com/google/uuid"
+	"github.com/evleria/cats-app/internal/event"
 )
 
-// Price consuming price messages
+// Price consuming price messages, Consume returns ctx.Err() soon after ctx is cancelled
 type Price interface {
-	Consume(ctx context.Context, callbackFunc func(id uuid.UUID, price float64) error) error
+	Consume(ctx context.Context, callbackFunc func(e event.PriceChanged) error) error
 }
@@ -2,20 +2,27 @@ package consumer
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/streadway/amqp"
+
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/logging"
 )
 
 type rabbitPrice struct {
 	channel   *amqp.Channel
 	queueName string
+	codec     *event.Codec
 }
 
 // NewRabbitPriceConsumer creates new rabbit price consumer
-func NewRabbitPriceConsumer(channel *amqp.Channel, queueName, exchange string) (Price, error) {
+func NewRabbitPriceConsumer(channel *amqp.Channel, queueName, exchange string, codec *event.Codec) (Price, error) {
+	// exchange is declared by producer as well, consumer may start first
+	err := channel.ExchangeDeclare(exchange, amqp.ExchangeFanout, true, false, false, false, nil)
+	if err != nil {
+		return nil, err
+	}
 	q, err := channel.QueueDeclare(queueName, true, false, false, false, nil)
 	if err != nil {
 		return nil, err
@@ -28,42 +35,39 @@ func NewRabbitPriceConsumer(channel *amqp.Channel, queueName, exchange string) (
 	return &rabbitPrice{
 		channel:   channel,
 		queueName: q.Name,
+		codec:     codec,
 	}, nil
 }
 
-func (p *rabbitPrice) Consume(_ context.Context, callbackFunc func(id uuid.UUID, price float64) error) error {
-	msgs, err := p.channel.Consume(p.queueName, "", true, false, false, false, nil)
+func (p *rabbitPrice) Consume(ctx context.Context, callbackFunc func(e event.PriceChanged) error) error {
+	tag := "price-" + uuid.New().String()
+	msgs, err := p.channel.Consume(p.queueName, tag, true, false, false, false, nil)
 	if err != nil {
 		return err
 	}
 
+	// cancelling the consumer closes msgs after deliveries already sent, so the loop below ends on ctx
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			p.channel.Cancel(tag, false) //nolint:errcheck,gosec
+		case <-stop:
+		}
+	}()
+
 	for msg := range msgs {
-		id, price, err := decodeRabbitMessage(msg.Body)
+		e, err := p.codec.DecodePriceChanged(msg.Body)
 		if err != nil {
 			return err
 		}
 
-		fmt.Printf("consumed message from rabbit: {%v, %f}\n", id, price)
-		err = callbackFunc(id, price)
+		logging.Debugf("consumed message from rabbit: {%v, %v, %v}\n", e.ID, e.CatID, e.NewPrice)
+		err = callbackFunc(e)
 		if err != nil {
 			return err
 		}
 	}
-	return nil
-}
-
-func decodeRabbitMessage(bytes []byte) (id uuid.UUID, price float64, err error) {
-	var message struct {
-		ID    string  `json:"id"`
-		Price float64 `json:"price"`
-	}
-	err = json.Unmarshal(bytes, &message)
-	if err != nil {
-		return uuid.UUID{}, 0, err
-	}
-	id, err = uuid.Parse(message.ID)
-	if err != nil {
-		return uuid.UUID{}, 0, err
-	}
-	return id, message.Price, nil
+	return ctx.Err()
 }
@@ -0,0 +1,87 @@
+package consumer
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/logging"
+)
+
+type redisGroupPrice struct {
+	redisPrice
+	group    string
+	consumer string
+}
+
+// NewRedisGroupPriceConsumer creates redis price consumer reading through a consumer group.
+// Messages are acknowledged after successful callback, so the next reader of the group resumes
+// from the first unacknowledged message, including the ones left pending by a previous reader.
+func NewRedisGroupPriceConsumer(redisClient *redis.Client, stream, group, consumer string, codec *event.Codec) Price {
+	return &redisGroupPrice{
+		redisPrice: redisPrice{
+			redis:  redisClient,
+			stream: stream,
+			codec:  codec,
+		},
+		group:    group,
+		consumer: consumer,
+	}
+}
+
+func (p *redisGroupPrice) Consume(ctx context.Context, callbackFunc func(e event.PriceChanged) error) error {
+	err := p.redis.XGroupCreateMkStream(ctx, p.stream, p.group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	// pending messages are read first, then new ones
+	lastID := "0"
+	for ctx.Err() == nil {
+		args := &redis.XReadGroupArgs{
+			Group:    p.group,
+			Consumer: p.consumer,
+			Streams:  []string{p.stream, lastID},
+			Block:    readBlockTimeout,
+		}
+		r, err := p.redis.XReadGroup(ctx, args).Result()
+		if err == redis.Nil {
+			continue
+		} else if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return err
+		}
+
+		messages := r[0].Messages
+		if len(messages) == 0 && lastID != ">" {
+			lastID = ">"
+			continue
+		}
+
+		for _, message := range messages {
+			e, err := DecodeRedisMessage(p.codec, message)
+			if err != nil {
+				return err
+			}
+
+			logging.Debugf("consumed message from redis group %s: {%v, %v, %v}\n", p.group, e.ID, e.CatID, e.NewPrice)
+			err = callbackFunc(e)
+			if err != nil {
+				return err
+			}
+
+			err = p.redis.XAck(ctx, p.stream, p.group, message.ID).Err()
+			if err != nil {
+				return err
+			}
+			if lastID != ">" {
+				lastID = message.ID
+			}
+		}
+	}
+	return ctx.Err()
+}
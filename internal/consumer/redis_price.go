@@ -3,44 +3,60 @@ package consumer
 import (
 	"context"
 	"errors"
-	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/go-redis/redis/v8"
-	"github.com/google/uuid"
+
+	"github.com/evleria/cats-app/internal/broker"
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/logging"
 )
 
+// readBlockTimeout limits how long a single read blocks, so cancellation of context is noticed
+const readBlockTimeout = 2 * time.Second
+
 type redisPrice struct {
 	redis  *redis.Client
+	stream string
 	lastID string
+	codec  *event.Codec
 }
 
-// NewRedisPriceConsumer creates new redis price consumer
-func NewRedisPriceConsumer(redisClient *redis.Client, startID string) Price {
+// NewRedisPriceConsumer creates new consumer of redis price stream
+func NewRedisPriceConsumer(redisClient *redis.Client, stream, startID string, codec *event.Codec) Price {
 	return &redisPrice{
 		redis:  redisClient,
+		stream: stream,
 		lastID: startID,
+		codec:  codec,
 	}
 }
 
-func (p *redisPrice) Consume(ctx context.Context, callbackFunc func(id uuid.UUID, price float64) error) error {
-	for {
+func (p *redisPrice) Consume(ctx context.Context, callbackFunc func(e event.PriceChanged) error) error {
+	for ctx.Err() == nil {
 		args := &redis.XReadArgs{
-			Streams: []string{"price", p.lastID},
+			Streams: []string{p.stream, p.lastID},
+			Block:   readBlockTimeout,
 		}
 		r, err := p.redis.XRead(ctx, args).Result()
-		if err != nil {
+		if err == redis.Nil {
+			continue
+		} else if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
 			return err
 		}
 
 		for _, message := range r[0].Messages {
-			id, price, err := decodeRedisMessage(message)
+			e, err := DecodeRedisMessage(p.codec, message)
 			if err != nil {
 				return err
 			}
 
-			fmt.Printf("consumed message from redis: {%v, %f}\n", id, price)
-			err = callbackFunc(id, price)
+			logging.Debugf("consumed message from redis: {%v, %v, %v}\n", e.ID, e.CatID, e.NewPrice)
+			err = callbackFunc(e)
 			if err != nil {
 				return err
 			}
@@ -48,26 +64,35 @@ func (p *redisPrice) Consume(ctx context.Context, callbackFunc func(id uuid.UUID
 			p.lastID = message.ID
 		}
 	}
+	return ctx.Err()
 }
 
-func decodeRedisMessage(message redis.XMessage) (id uuid.UUID, price float64, err error) {
+// DecodeRedisMessage decodes both versioned events and legacy messages with id and price fields of price stream.
+// Legacy messages get event ID and time derived from stream entry ID, so that every read gives the same event.
+func DecodeRedisMessage(codec *event.Codec, message redis.XMessage) (event.PriceChanged, error) {
+	if data, ok := message.Values["event"].(string); ok {
+		return codec.DecodePriceChanged([]byte(data))
+	}
+
 	idStr, ok := message.Values["id"].(string)
 	if !ok {
-		return id, price, errors.New("cannot convert id to string")
+		return event.PriceChanged{}, errors.New("cannot convert id to string")
 	}
 	priceStr, ok := message.Values["price"].(string)
 	if !ok {
-		return id, price, errors.New("cannot convert price to string")
+		return event.PriceChanged{}, errors.New("cannot convert price to string")
 	}
 
-	id, err = uuid.Parse(idStr)
+	price, err := strconv.ParseFloat(priceStr, 64)
 	if err != nil {
-		return id, price, err
+		return event.PriceChanged{}, err
 	}
-	price, err = strconv.ParseFloat(priceStr, 64)
+	e, err := event.DecodeLegacyPrice(message.ID, idStr, price)
 	if err != nil {
-		return id, price, err
+		return event.PriceChanged{}, err
 	}
-
-	return id, price, nil
+	if id, err := broker.ParseStreamID(message.ID); err == nil {
+		e.OccurredAt = id.Time()
+	}
+	return e, nil
 }
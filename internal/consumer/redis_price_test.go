@@ -0,0 +1,157 @@
+package consumer
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/event"
+)
+
+func TestDecodeRedisMessageLegacy(t *testing.T) {
+	// Arrange
+	codec, err := event.NewCodec(event.FormatJSON, "/test")
+	require.NoError(t, err)
+	catID := uuid.New()
+	message := redis.XMessage{
+		ID:     "1627804800000-3",
+		Values: map[string]interface{}{"id": catID.String(), "price": "12.5"},
+	}
+
+	// Act
+	first, err := DecodeRedisMessage(codec, message)
+	require.NoError(t, err)
+	second, err := DecodeRedisMessage(codec, message)
+	require.NoError(t, err)
+
+	// Assert
+	require.Equal(t, catID, first.CatID)
+	require.Equal(t, usd(1250), first.NewPrice)
+	require.Equal(t, first.ID, second.ID)
+	require.Equal(t, time.Date(2021, 8, 1, 8, 0, 0, 0, time.UTC), first.OccurredAt)
+}
+
+func TestDecodeRedisMessageEvent(t *testing.T) {
+	// Arrange
+	codec, err := event.NewCodec(event.FormatJSON, "/test")
+	require.NoError(t, err)
+	sent := event.NewPriceChanged(uuid.New(), 2, usd(1000), usd(2000))
+	data, err := codec.EncodePriceChanged(sent)
+	require.NoError(t, err)
+	message := redis.XMessage{ID: "1627804800000-0", Values: map[string]interface{}{"event": string(data)}}
+
+	// Act
+	e, err := DecodeRedisMessage(codec, message)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, sent.ID, e.ID)
+	require.Equal(t, sent.Sequence, e.Sequence)
+}
+
+func TestRedisPriceConsumeStopsOnCancel(t *testing.T) {
+	// Arrange
+	codec, err := event.NewCodec(event.FormatJSON, "/test")
+	require.NoError(t, err)
+	addr, blocks := serveEmptyStreams(t)
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { client.Close() })
+	c := NewRedisPriceConsumer(client, PriceTopic, "0-0", codec)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Consume(ctx, func(event.PriceChanged) error { return nil })
+	}()
+
+	// Act
+	block := <-blocks
+	cancel()
+
+	// Assert
+	require.Equal(t, readBlockTimeout, block)
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(readBlockTimeout + time.Second):
+		t.Fatal("consumer has not stopped")
+	}
+}
+
+// serveEmptyStreams starts a fake redis server holding no stream entries and reports BLOCK of every read,
+// reads reply nil after it as redis does, BLOCK 0 never replies
+func serveEmptyStreams(t *testing.T) (string, <-chan time.Duration) {
+	blocks := make(chan time.Duration, 100)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+			go func() {
+				r := bufio.NewReader(conn)
+				for {
+					command, err := readCommand(r)
+					if err != nil {
+						return
+					}
+					var block time.Duration
+					for i := 0; i+1 < len(command); i++ {
+						if strings.EqualFold(command[i], "BLOCK") {
+							ms, _ := strconv.Atoi(command[i+1])
+							block = time.Duration(ms) * time.Millisecond
+						}
+					}
+					select {
+					case blocks <- block:
+					default:
+					}
+					if block == 0 {
+						return
+					}
+					time.Sleep(block)
+					if _, err := conn.Write([]byte("*-1\r\n")); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String(), blocks
+}
+
+// readCommand reads a command sent as RESP array of bulk strings
+func readCommand(r *bufio.Reader) ([]string, error) {
+	var n int
+	if _, err := fmt.Fscanf(r, "*%d\r\n", &n); err != nil {
+		return nil, err
+	}
+	command := make([]string, n)
+	for i := range command {
+		var size int
+		if _, err := fmt.Fscanf(r, "$%d\r\n", &size); err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		command[i] = string(data[:size])
+	}
+	return command, nil
+}
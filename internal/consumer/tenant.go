@@ -0,0 +1,37 @@
+package consumer
+
+import (
+	"context"
+
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+type tenantPrice struct {
+	consumer Price
+	tenantID string
+}
+
+// NewTenantPriceConsumer wraps consumer of a tenant topic, so that callback gets events of the tenant only.
+// Events without tenant were produced before multi-tenancy and belong to default tenant,
+// events of other tenants can get into the topic only by mistake and are dropped.
+func NewTenantPriceConsumer(consumer Price, tenantID string) Price {
+	return &tenantPrice{
+		consumer: consumer,
+		tenantID: tenantID,
+	}
+}
+
+func (t *tenantPrice) Consume(ctx context.Context, callbackFunc func(e event.PriceChanged) error) error {
+	return t.consumer.Consume(ctx, func(e event.PriceChanged) error {
+		if e.TenantID == "" {
+			e.TenantID = tenant.Default
+		}
+		if e.TenantID != t.tenantID {
+			logging.Warnf("dropped message of tenant %q consumed for tenant %q: {%v, %v}\n", e.TenantID, t.tenantID, e.ID, e.CatID)
+			return nil
+		}
+		return callbackFunc(e)
+	})
+}
@@ -0,0 +1,34 @@
+package consumer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+func TestTenantPriceConsumer(t *testing.T) {
+	// Arrange
+	legacy := event.NewPriceChanged(uuid.New(), 1, usd(0), usd(999))
+	own := event.NewPriceChanged(uuid.New(), 1, usd(0), usd(799))
+	own.TenantID = tenant.Default
+	foreign := event.NewPriceChanged(uuid.New(), 1, usd(0), usd(599))
+	foreign.TenantID = "shelter-1"
+	c := NewTenantPriceConsumer(sliceConsumer{legacy, own, foreign}, tenant.Default)
+
+	// Act
+	var consumed []event.PriceChanged
+	err := c.Consume(context.Background(), func(e event.PriceChanged) error {
+		consumed = append(consumed, e)
+		return nil
+	})
+
+	// Assert
+	require.NoError(t, err)
+	legacy.TenantID = tenant.Default
+	require.Equal(t, []event.PriceChanged{legacy, own}, consumed)
+}
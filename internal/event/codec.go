@@ -0,0 +1,178 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/protocol/pb"
+)
+
+// Format is a format of event data inside CloudEvents envelope
+type Format string
+
+const (
+	// FormatJSON encodes event data as JSON
+	FormatJSON Format = "json"
+	// FormatProtobuf encodes event data as protobuf
+	FormatProtobuf Format = "protobuf"
+
+	// ContentType is a content type of encoded events, i.e. CloudEvents structured mode
+	ContentType = "application/cloudevents+json"
+
+	specVersion          = "1.0"
+	jsonDataContentType  = "application/json"
+	protoDataContentType = "application/protobuf"
+)
+
+var (
+	// ErrUnknownEventType means event of an unsupported type was received
+	ErrUnknownEventType = errors.New("unknown event type")
+	// ErrUnknownFormat means codec is configured with unsupported data format
+	ErrUnknownFormat = errors.New("unknown event format")
+)
+
+// Codec encodes events into CloudEvents envelope and decodes them back
+type Codec struct {
+	format Format
+	source string
+}
+
+// NewCodec creates a codec producing event data in given format, source is set on all encoded events
+func NewCodec(format Format, source string) (*Codec, error) {
+	if format != FormatJSON && format != FormatProtobuf {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+	return &Codec{
+		format: format,
+		source: source,
+	}, nil
+}
+
+// envelope is a CloudEvents v1.0 event in structured JSON mode
+type envelope struct {
+	SpecVersion string `json:"specversion"`
+	ID          string `json:"id"`
+	Source      string `json:"source"`
+	Type        string `json:"type"`
+	Subject     string `json:"subject,omitempty"`
+	// TenantID is a CloudEvents extension attribute, extension names allow only lowercase letters and digits
+	TenantID        string          `json:"tenantid,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      []byte          `json:"data_base64,omitempty"`
+}
+
+// legacyPrice is an unversioned price message produced before events had a schema
+type legacyPrice struct {
+	ID    string  `json:"id"`
+	Price float64 `json:"price"`
+}
+
+// EncodePriceChanged encodes price change event
+func (c *Codec) EncodePriceChanged(e PriceChanged) ([]byte, error) {
+	if e.Source == "" {
+		e.Source = c.source
+	}
+	message := mapPriceChanged(e)
+
+	env := envelope{
+		SpecVersion: specVersion,
+		ID:          message.EventId,
+		Source:      message.Source,
+		Type:        message.Type,
+		Subject:     message.CatId,
+		TenantID:    e.TenantID,
+		Time:        e.OccurredAt,
+		DataSchema:  fmt.Sprintf("%s/v%d", PriceChangedType, message.SchemaVersion),
+	}
+
+	var err error
+	switch c.format {
+	case FormatProtobuf:
+		env.DataContentType = protoDataContentType
+		env.DataBase64, err = proto.Marshal(message)
+	default:
+		env.DataContentType = jsonDataContentType
+		env.Data, err = protojson.Marshal(message)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(env)
+}
+
+// DecodePriceChanged decodes price change event of any known schema version and data format
+func (c *Codec) DecodePriceChanged(data []byte) (PriceChanged, error) {
+	env := envelope{}
+	if err := json.Unmarshal(data, &env); err != nil {
+		return PriceChanged{}, err
+	}
+	if env.SpecVersion == "" {
+		return decodeLegacyPrice(data)
+	}
+	if env.Type != PriceChangedType {
+		return PriceChanged{}, fmt.Errorf("%w: %q", ErrUnknownEventType, env.Type)
+	}
+
+	message := new(pb.PriceChanged)
+	var err error
+	switch env.DataContentType {
+	case protoDataContentType:
+		err = proto.Unmarshal(env.DataBase64, message)
+	case jsonDataContentType, "":
+		err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(env.Data, message)
+	default:
+		err = fmt.Errorf("%w: unsupported data content type %q", ErrUnknownFormat, env.DataContentType)
+	}
+	if err != nil {
+		return PriceChanged{}, err
+	}
+
+	// envelope attributes take precedence, as they are always set by producer
+	message.EventId, message.Source = env.ID, env.Source
+	if message.OccurredAt == nil {
+		message.OccurredAt = timestamppb.New(env.Time)
+	}
+	e, err := unmapPriceChanged(message)
+	e.TenantID = env.TenantID
+	return e, err
+}
+
+// legacyEventNamespace is a namespace of IDs derived for legacy messages from what identifies them
+var legacyEventNamespace = uuid.MustParse("0c3b4e8e-5d3f-4a55-9a52-5a8f8f0a6c21")
+
+// DecodeLegacyPrice creates an event from fields of an unversioned price message, its price was always in default currency.
+// Event ID is derived from key identifying the message, so that every read of it gives the same event.
+func DecodeLegacyPrice(key, id string, price float64) (PriceChanged, error) {
+	catID, err := uuid.Parse(id)
+	if err != nil {
+		return PriceChanged{}, err
+	}
+	newPrice, err := money.FromMajor(price, money.DefaultCurrency)
+	if err != nil {
+		return PriceChanged{}, err
+	}
+	e := NewPriceChanged(catID, 0, money.Money{Currency: money.DefaultCurrency}, newPrice)
+	e.ID = uuid.NewSHA1(legacyEventNamespace, []byte(key))
+	e.SchemaVersion = 0
+	return e, nil
+}
+
+func decodeLegacyPrice(data []byte) (PriceChanged, error) {
+	message := legacyPrice{}
+	if err := json.Unmarshal(data, &message); err != nil {
+		return PriceChanged{}, err
+	}
+	// a legacy message has nothing but its payload to identify it
+	return DecodeLegacyPrice(string(data), message.ID, message.Price)
+}
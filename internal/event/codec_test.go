@@ -0,0 +1,108 @@
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/money"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	for _, format := range []Format{FormatJSON, FormatProtobuf} {
+		t.Run(string(format), func(t *testing.T) {
+			// Arrange
+			codec, err := NewCodec(format, "/test")
+			require.NoError(t, err)
+			e := NewPriceChanged(uuid.New(), 3, money.Money{Amount: 999, Currency: "USD"}, money.Money{Amount: 799, Currency: "USD"})
+			e.OccurredAt = e.OccurredAt.Truncate(time.Millisecond)
+			e.TenantID = "shelter-1"
+
+			// Act
+			data, err := codec.EncodePriceChanged(e)
+			require.NoError(t, err)
+			decoded, err := codec.DecodePriceChanged(data)
+
+			// Assert
+			require.NoError(t, err)
+			e.Source = "/test"
+			require.Equal(t, e, decoded)
+		})
+	}
+}
+
+func TestCodecDecodesLegacyMessage(t *testing.T) {
+	// Arrange
+	codec, _ := NewCodec(FormatJSON, "/test")
+	catID := uuid.New()
+
+	// Act
+	decoded, err := codec.DecodePriceChanged([]byte(`{"id":"` + catID.String() + `","price":5.5}`))
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, catID, decoded.CatID)
+	require.Equal(t, money.Money{Amount: 550, Currency: money.DefaultCurrency}, decoded.NewPrice)
+	require.Equal(t, 0, decoded.SchemaVersion)
+}
+
+func TestCodecDecodesLegacyMessageWithTheSameID(t *testing.T) {
+	// Arrange
+	codec, _ := NewCodec(FormatJSON, "/test")
+	data := []byte(`{"id":"` + uuid.New().String() + `","price":5.5}`)
+	first, err := codec.DecodePriceChanged(data)
+	require.NoError(t, err)
+
+	// Act
+	second, err := codec.DecodePriceChanged(data)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, first.ID, second.ID)
+}
+
+func TestCodecIgnoresUnknownFields(t *testing.T) {
+	// Arrange
+	codec, _ := NewCodec(FormatJSON, "/test")
+	data := `{"specversion":"1.0","id":"` + uuid.New().String() + `","source":"/other","type":"` + PriceChangedType + `",` +
+		`"time":"2021-08-01T12:00:00Z","datacontenttype":"application/json",` +
+		`"data":{"catId":"` + uuid.New().String() + `","newAmount":300,"currency":"EUR","schemaVersion":3,"discount":0.5}}`
+
+	// Act
+	decoded, err := codec.DecodePriceChanged([]byte(data))
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, money.Money{Amount: 300, Currency: "EUR"}, decoded.NewPrice)
+	require.Equal(t, "/other", decoded.Source)
+	require.Equal(t, uint64(0), decoded.Sequence)
+}
+
+func TestCodecRoundsPricesOfSchemaVersion2(t *testing.T) {
+	// Arrange
+	codec, _ := NewCodec(FormatJSON, "/test")
+	data := `{"specversion":"1.0","id":"` + uuid.New().String() + `","source":"/other","type":"` + PriceChangedType + `",` +
+		`"time":"2021-08-01T12:00:00Z","datacontenttype":"application/json",` +
+		`"data":{"catId":"` + uuid.New().String() + `","oldPrice":1500,"newPrice":9.995,"currency":"JPY","schemaVersion":2}}`
+
+	// Act
+	decoded, err := codec.DecodePriceChanged([]byte(data))
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, money.Money{Amount: 1500, Currency: "JPY"}, decoded.OldPrice)
+	require.Equal(t, money.Money{Amount: 10, Currency: "JPY"}, decoded.NewPrice)
+}
+
+func TestCodecRejectsUnknownType(t *testing.T) {
+	// Arrange
+	codec, _ := NewCodec(FormatJSON, "/test")
+
+	// Act
+	_, err := codec.DecodePriceChanged([]byte(`{"specversion":"1.0","type":"com.example.other"}`))
+
+	// Assert
+	require.ErrorIs(t, err, ErrUnknownEventType)
+}
@@ -0,0 +1,109 @@
+// Package event describes events exchanged through message brokers and their wire format
+package event
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/protocol/pb"
+)
+
+const (
+	// PriceChangedType is a CloudEvents type of price change event
+	PriceChangedType = "com.evleria.cats.price.changed"
+	// PriceChangedSchemaVersion is a current version of price change event schema
+	PriceChangedSchemaVersion = 3
+)
+
+// PriceChanged is an event of a cat price change.
+// Sequence grows with every price change of a cat, zero means unknown sequence of events before schema version 2.
+// TenantID is empty in events produced before multi-tenancy, they belong to default tenant.
+// Prices of events before schema version 3 are rounded from major units.
+type PriceChanged struct {
+	ID            uuid.UUID
+	TenantID      string
+	SchemaVersion int
+	OccurredAt    time.Time
+	CatID         uuid.UUID
+	Sequence      uint64
+	OldPrice      money.Money
+	NewPrice      money.Money
+	Source        string
+}
+
+// NewPriceChanged creates a new price change event of current schema version
+func NewPriceChanged(catID uuid.UUID, sequence uint64, oldPrice, newPrice money.Money) PriceChanged {
+	return PriceChanged{
+		ID:            uuid.New(),
+		SchemaVersion: PriceChangedSchemaVersion,
+		OccurredAt:    time.Now().UTC(),
+		CatID:         catID,
+		Sequence:      sequence,
+		OldPrice:      oldPrice,
+		NewPrice:      newPrice,
+	}
+}
+
+func mapPriceChanged(e PriceChanged) *pb.PriceChanged {
+	return &pb.PriceChanged{
+		EventId:       e.ID.String(),
+		Type:          PriceChangedType,
+		SchemaVersion: int32(e.SchemaVersion),
+		OccurredAt:    timestamppb.New(e.OccurredAt),
+		CatId:         e.CatID.String(),
+		Sequence:      e.Sequence,
+		OldPrice:      e.OldPrice.Major(),
+		NewPrice:      e.NewPrice.Major(),
+		Currency:      e.NewPrice.Currency,
+		Source:        e.Source,
+		OldAmount:     e.OldPrice.Amount,
+		NewAmount:     e.NewPrice.Amount,
+		OldCurrency:   e.OldPrice.Currency,
+	}
+}
+
+func unmapPriceChanged(message *pb.PriceChanged) (PriceChanged, error) {
+	id, err := uuid.Parse(message.EventId)
+	if err != nil {
+		return PriceChanged{}, err
+	}
+	catID, err := uuid.Parse(message.CatId)
+	if err != nil {
+		return PriceChanged{}, err
+	}
+
+	e := PriceChanged{
+		ID:            id,
+		SchemaVersion: int(message.SchemaVersion),
+		OccurredAt:    message.OccurredAt.AsTime(),
+		CatID:         catID,
+		Sequence:      message.Sequence,
+		Source:        message.Source,
+	}
+	e.OldPrice, e.NewPrice, err = unmapPrices(message)
+	return e, err
+}
+
+// unmapPrices takes exact prices of current schema, or rounds prices in major units of older ones
+func unmapPrices(message *pb.PriceChanged) (oldPrice, newPrice money.Money, err error) {
+	newCurrency := message.Currency
+	if newCurrency == "" {
+		newCurrency = money.DefaultCurrency
+	}
+	oldCurrency := message.OldCurrency
+	if oldCurrency == "" {
+		oldCurrency = newCurrency
+	}
+
+	if message.SchemaVersion >= 3 {
+		return money.Money{Amount: message.OldAmount, Currency: oldCurrency}, money.Money{Amount: message.NewAmount, Currency: newCurrency}, nil
+	}
+	if oldPrice, err = money.FromMajor(message.OldPrice, oldCurrency); err != nil {
+		return oldPrice, newPrice, err
+	}
+	newPrice, err = money.FromMajor(message.NewPrice, newCurrency)
+	return oldPrice, newPrice, err
+}
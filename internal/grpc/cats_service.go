@@ -3,41 +3,50 @@ package grpc
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/evleria/cats-app/internal/money"
 	"github.com/evleria/cats-app/internal/repository"
 	"github.com/evleria/cats-app/internal/repository/entities"
 	"github.com/evleria/cats-app/internal/service"
 	"github.com/evleria/cats-app/protocol/pb"
 )
 
-// CatsService grpc service implementation of pb.CatsServiceServer
+// CatsService grpc service implementation of pb.CatsServiceServer.
+// Domain errors are returned as is, they are translated to statuses by ErrorUnaryInterceptor and ErrorStreamInterceptor.
 type CatsService struct {
 	pb.UnimplementedCatsServiceServer
 	service service.Cats
+	prices  service.Prices
 }
 
 // NewCatsService returns a new pb.CatsService
-func NewCatsService(catsService service.Cats) pb.CatsServiceServer {
+func NewCatsService(catsService service.Cats, pricesService service.Prices) pb.CatsServiceServer {
 	return &CatsService{
 		service: catsService,
+		prices:  pricesService,
 	}
 }
 
-// GetAllCats fetches all cats from cats collection
-func (s *CatsService) GetAllCats(_ *empty.Empty, stream pb.CatsService_GetAllCatsServer) error {
-	cats, err := s.service.GetAll(stream.Context())
+// GetAllCats fetches all cats from cats collection matching request filters
+func (s *CatsService) GetAllCats(request *pb.GetAllCatsRequest, stream pb.CatsService_GetAllCatsServer) error {
+	cats, err := s.service.GetAll(stream.Context(), mapFilter(request), mapPage(request))
 	if err != nil {
-		return status.Error(codes.Internal, err.Error())
+		return err
+	}
+	displayed, err := s.displayCats(stream.Context(), cats, request.Currency)
+	if err != nil {
+		return err
 	}
 
-	for _, cat := range mapCats(cats) {
+	for _, cat := range displayed {
 		<-time.After(time.Millisecond * 100)
 		err := stream.Send(&pb.GetAllCatsResponse{
 			Cat: cat,
@@ -49,6 +58,34 @@ func (s *CatsService) GetAllCats(_ *empty.Empty, stream pb.CatsService_GetAllCat
 	return nil
 }
 
+// SearchCats performs full-text search over cats matching request filters
+func (s *CatsService) SearchCats(ctx context.Context, request *pb.SearchCatsRequest) (*pb.SearchCatsResponse, error) {
+	results, err := s.service.Search(ctx, request.Query, mapFilter(request), mapPage(request))
+	if err != nil {
+		return nil, err
+	}
+	cats := make([]entities.Cat, 0, len(results))
+	for _, result := range results {
+		cats = append(cats, result.Cat)
+	}
+	displayed, err := s.displayCats(ctx, cats, request.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &pb.SearchCatsResponse{
+		Results: make([]*pb.SearchResult, 0, len(results)),
+	}
+	for i, result := range results {
+		response.Results = append(response.Results, &pb.SearchResult{
+			Cat:        displayed[i],
+			Score:      result.Score,
+			Highlights: result.Highlights,
+		})
+	}
+	return response, nil
+}
+
 // GetCat fetches a cat from cats collection by ID
 func (s *CatsService) GetCat(ctx context.Context, request *pb.GetCatRequest) (*pb.GetCatResponse, error) {
 	id, err := uuid.Parse(request.Id)
@@ -56,23 +93,29 @@ func (s *CatsService) GetCat(ctx context.Context, request *pb.GetCatRequest) (*p
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 	cat, err := s.service.GetOne(ctx, id)
-	if errors.Is(err, repository.ErrNotFound) {
-		return nil, status.Error(codes.NotFound, err.Error())
-	} else if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+	if err != nil {
+		return nil, err
+	}
+	displayed, err := s.displayCats(ctx, []entities.Cat{cat}, request.Currency)
+	if err != nil {
+		return nil, err
 	}
 
 	response := &pb.GetCatResponse{
-		Cat: mapCat(cat),
+		Cat: displayed[0],
 	}
 	return response, nil
 }
 
 // AddNewCat creates new cat in cats collection
 func (s *CatsService) AddNewCat(ctx context.Context, request *pb.AddNewCatRequest) (*pb.AddNewCatResponse, error) {
-	id, err := s.service.CreateNew(ctx, request.Name, request.Color, int(request.Age), request.Price)
+	cat, err := mapNewCat(request)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, err
+	}
+	id, err := s.service.CreateNew(ctx, cat)
+	if err != nil {
+		return nil, err
 	}
 	response := &pb.AddNewCatResponse{
 		Id: id.String(),
@@ -88,7 +131,20 @@ func (s *CatsService) DeleteCat(ctx context.Context, request *pb.DeleteCatReques
 	}
 	err = s.service.Delete(ctx, id)
 	if err != nil {
-		return nil, status.Error(codes.NotFound, err.Error())
+		return nil, err
+	}
+	return &empty.Empty{}, nil
+}
+
+// RecordView counts a view of a cat by a client showing it
+func (s *CatsService) RecordView(ctx context.Context, request *pb.RecordViewRequest) (*empty.Empty, error) {
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	err = s.service.RecordView(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 	return &empty.Empty{}, nil
 }
@@ -99,29 +155,315 @@ func (s *CatsService) UpdatePrice(ctx context.Context, request *pb.UpdatePriceRe
 	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
-	err = s.service.UpdatePrice(ctx, id, request.Price)
-	if errors.Is(err, repository.ErrNotFound) {
-		return nil, status.Error(codes.NotFound, err.Error())
-	} else if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+	price, err := unmapPrice(request.GetPrice(), request.GetLegacyPrice())
+	if err != nil {
+		return nil, err
+	}
+	err = s.service.UpdatePrice(ctx, id, price)
+	if err != nil {
+		return nil, err
+	}
+	return &empty.Empty{}, nil
+}
+
+// ReserveCat places a temporary hold on a cat by ID
+func (s *CatsService) ReserveCat(ctx context.Context, request *pb.ReserveCatRequest) (*pb.ReserveCatResponse, error) {
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	reservation, err := s.service.Reserve(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &pb.ReserveCatResponse{
+		ReservationId: reservation.ID.String(),
+		LegacyPrice:   reservation.Price.Major(),
+		Price:         mapMoney(reservation.Price),
+		ExpiresAt:     timestamppb.New(reservation.ExpiresAt),
+	}
+	return response, nil
+}
+
+// CancelReservation releases a hold on a cat by cat ID and reservation ID
+func (s *CatsService) CancelReservation(ctx context.Context, request *pb.CancelReservationRequest) (*empty.Empty, error) {
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	reservationID, err := uuid.Parse(request.ReservationId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	err = s.service.CancelReservation(ctx, id, reservationID)
+	if err != nil {
+		return nil, err
 	}
 	return &empty.Empty{}, nil
 }
 
+// PurchaseCat sells a reserved cat at the price locked by reservation
+func (s *CatsService) PurchaseCat(ctx context.Context, request *pb.PurchaseCatRequest) (*pb.PurchaseCatResponse, error) {
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	reservationID, err := uuid.Parse(request.ReservationId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	sale, err := s.service.Purchase(ctx, id, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &pb.PurchaseCatResponse{
+		LegacyPrice: sale.Price.Major(),
+		Price:       mapMoney(sale.Price),
+		SoldAt:      timestamppb.New(sale.SoldAt),
+	}
+	return response, nil
+}
+
+// filterRequest is implemented by requests that support cats filtering and pagination
+type filterRequest interface {
+	GetColor() string
+	GetBreed() string
+	GetSex() pb.Sex
+	GetStatus() pb.Status
+	GetTags() []string
+	GetVaccination() string
+	GetMinAge() int64
+	GetMaxAge() int64
+	GetLimit() int64
+	GetOffset() int64
+}
+
+func mapFilter(request filterRequest) repository.Filter {
+	filter := repository.Filter{
+		Color:       request.GetColor(),
+		Breed:       request.GetBreed(),
+		Sex:         unmapSex(request.GetSex()),
+		Status:      unmapStatus(request.GetStatus()),
+		Tags:        request.GetTags(),
+		Vaccination: request.GetVaccination(),
+	}
+	// optional fields are distinguished from zero by pointers in generated messages
+	switch r := request.(type) {
+	case *pb.GetAllCatsRequest:
+		filter.MinAge, filter.MaxAge = int64ToIntPtr(r.MinAge), int64ToIntPtr(r.MaxAge)
+	case *pb.SearchCatsRequest:
+		filter.MinAge, filter.MaxAge = int64ToIntPtr(r.MinAge), int64ToIntPtr(r.MaxAge)
+	}
+	return filter
+}
+
+func mapPage(request filterRequest) repository.Page {
+	return repository.Page{
+		Limit:  request.GetLimit(),
+		Offset: request.GetOffset(),
+	}
+}
+
+func int64ToIntPtr(value *int64) *int {
+	if value == nil {
+		return nil
+	}
+	result := int(*value)
+	return &result
+}
+
+func mapNewCat(request *pb.AddNewCatRequest) (entities.Cat, error) {
+	price, err := unmapPrice(request.GetPrice(), request.GetLegacyPrice())
+	if err != nil {
+		return entities.Cat{}, err
+	}
+	cat := entities.Cat{
+		Name:        request.Name,
+		Color:       request.Color,
+		Breed:       request.Breed,
+		Sex:         unmapSex(request.Sex),
+		Age:         int(request.Age),
+		Description: request.Description,
+		Tags:        request.Tags,
+		Price:       price,
+	}
+	if request.BirthDate != nil {
+		if err := request.BirthDate.CheckValid(); err != nil {
+			return cat, service.NewValidationError("birth_date", "must be a valid timestamp")
+		}
+		birthDate := request.BirthDate.AsTime()
+		cat.BirthDate = &birthDate
+	}
+	for i, vaccination := range request.Vaccinations {
+		if err := vaccination.Date.CheckValid(); err != nil {
+			return cat, service.NewValidationError(fmt.Sprintf("vaccinations[%d].date", i), "must be a valid timestamp")
+		}
+		v := entities.Vaccination{
+			Name: vaccination.Name,
+			Date: vaccination.Date.AsTime(),
+		}
+		if vaccination.ValidUntil != nil {
+			if err := vaccination.ValidUntil.CheckValid(); err != nil {
+				return cat, service.NewValidationError(fmt.Sprintf("vaccinations[%d].valid_until", i), "must be a valid timestamp")
+			}
+			validUntil := vaccination.ValidUntil.AsTime()
+			v.ValidUntil = &validUntil
+		}
+		cat.Vaccinations = append(cat.Vaccinations, v)
+	}
+	return cat, nil
+}
+
 func mapCat(cat entities.Cat) *pb.Cat {
-	return &pb.Cat{
-		Id:    cat.ID.String(),
-		Name:  cat.Name,
-		Color: cat.Color,
-		Age:   int64(cat.Age),
-		Price: cat.Price,
+	result := &pb.Cat{
+		Id:           cat.ID.String(),
+		Name:         cat.Name,
+		Color:        cat.Color,
+		Age:          int64(cat.AgeAt(time.Now())),
+		LegacyPrice:  cat.Price.Major(),
+		Price:        mapMoney(cat.Price),
+		Status:       mapStatus(cat.Status),
+		Breed:        cat.Breed,
+		Sex:          mapSex(cat.Sex),
+		Description:  cat.Description,
+		Tags:         cat.Tags,
+		Vaccinations: mapVaccinations(cat.Vaccinations),
+		Photos:       mapPhotos(cat.ID, cat.Photos),
+	}
+	if cat.BirthDate != nil {
+		result.BirthDate = timestamppb.New(*cat.BirthDate)
 	}
+	return result
 }
 
-func mapCats(cats []entities.Cat) []*pb.Cat {
-	result := make([]*pb.Cat, 0, len(cats))
-	for _, cat := range cats {
-		result = append(result, mapCat(cat))
+func mapVaccinations(vaccinations []entities.Vaccination) []*pb.Vaccination {
+	result := make([]*pb.Vaccination, 0, len(vaccinations))
+	for _, vaccination := range vaccinations {
+		v := &pb.Vaccination{
+			Name: vaccination.Name,
+			Date: timestamppb.New(vaccination.Date),
+		}
+		if vaccination.ValidUntil != nil {
+			v.ValidUntil = timestamppb.New(*vaccination.ValidUntil)
+		}
+		result = append(result, v)
+	}
+	return result
+}
+
+func mapPhotos(catID uuid.UUID, photos []entities.Photo) []*pb.Photo {
+	result := make([]*pb.Photo, 0, len(photos))
+	for _, photo := range photos {
+		url := fmt.Sprintf("/api/cats/%s/photos/%s", catID, photo.ID)
+		result = append(result, &pb.Photo{
+			Id:           photo.ID.String(),
+			ContentType:  photo.ContentType,
+			Size:         photo.Size,
+			Url:          url,
+			ThumbnailUrl: url + "?thumbnail=true",
+			UploadedAt:   timestamppb.New(photo.UploadedAt),
+		})
 	}
 	return result
 }
+
+func mapSex(sex entities.Sex) pb.Sex {
+	switch sex {
+	case entities.SexMale:
+		return pb.Sex_SEX_MALE
+	case entities.SexFemale:
+		return pb.Sex_SEX_FEMALE
+	default:
+		return pb.Sex_SEX_UNSPECIFIED
+	}
+}
+
+func unmapSex(sex pb.Sex) entities.Sex {
+	switch sex {
+	case pb.Sex_SEX_MALE:
+		return entities.SexMale
+	case pb.Sex_SEX_FEMALE:
+		return entities.SexFemale
+	default:
+		return ""
+	}
+}
+
+func unmapStatus(catStatus pb.Status) entities.Status {
+	switch catStatus {
+	case pb.Status_STATUS_AVAILABLE:
+		return entities.StatusAvailable
+	case pb.Status_STATUS_RESERVED:
+		return entities.StatusReserved
+	case pb.Status_STATUS_SOLD:
+		return entities.StatusSold
+	default:
+		return ""
+	}
+}
+
+func mapStatus(catStatus entities.Status) pb.Status {
+	switch catStatus {
+	case entities.StatusAvailable:
+		return pb.Status_STATUS_AVAILABLE
+	case entities.StatusReserved:
+		return pb.Status_STATUS_RESERVED
+	case entities.StatusSold:
+		return pb.Status_STATUS_SOLD
+	default:
+		return pb.Status_STATUS_UNSPECIFIED
+	}
+}
+
+// displayCats maps cats with prices in display currency, prices they are sold for are set as list prices then
+func (s *CatsService) displayCats(ctx context.Context, cats []entities.Cat, currency string) ([]*pb.Cat, error) {
+	prices := make([]money.Money, 0, len(cats))
+	for _, cat := range cats {
+		prices = append(prices, cat.Price)
+	}
+	displayed, err := s.prices.Display(ctx, prices, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*pb.Cat, 0, len(cats))
+	for i, cat := range cats {
+		c := mapCat(cat)
+		if displayed[i] != cat.Price {
+			c.ListPrice = c.Price
+			c.LegacyPrice = displayed[i].Major()
+			c.Price = mapMoney(displayed[i])
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+func mapMoney(m money.Money) *pb.Money {
+	return &pb.Money{
+		Amount:   m.Amount,
+		Currency: m.Currency,
+	}
+}
+
+// unmapPrice maps price of a request, deprecated legacy price in major units of default currency is used when price is not set
+func unmapPrice(price *pb.Money, legacyPrice float64) (money.Money, error) {
+	if price != nil || legacyPrice == 0 {
+		return unmapMoney(price), nil
+	}
+	legacy, err := money.FromMajor(legacyPrice, money.DefaultCurrency)
+	if err != nil {
+		return money.Money{}, service.NewValidationError("legacy_price", "is out of range")
+	}
+	return legacy, nil
+}
+
+// unmapMoney maps missing money to zero value, so it is rejected by validation of currency
+func unmapMoney(m *pb.Money) money.Money {
+	return money.Money{
+		Amount:   m.GetAmount(),
+		Currency: m.GetCurrency(),
+	}
+}
@@ -0,0 +1,79 @@
+package grpc
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/service"
+	"github.com/evleria/cats-app/protocol/pb"
+)
+
+func newCatsService(catsService service.Cats) pb.CatsServiceServer {
+	rates := money.Rates{Base: "USD", Rates: map[string]*big.Rat{"EUR": big.NewRat(92, 100)}}
+	return NewCatsService(catsService, service.NewPricesService(money.NewConverter(rates)))
+}
+
+func TestAddNewCatLegacyPrice(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	s := new(service.MockCats)
+	s.On("CreateNew", mock.Anything, entities.Cat{Name: "Bella", Color: "black", Price: money.Money{Amount: 799, Currency: money.DefaultCurrency}}).Return(id, nil)
+
+	// Act
+	response, err := newCatsService(s).AddNewCat(context.Background(), &pb.AddNewCatRequest{Name: "Bella", Color: "black", LegacyPrice: 7.99})
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, id.String(), response.Id)
+}
+
+func TestAddNewCatPrefersPrice(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	s := new(service.MockCats)
+	s.On("CreateNew", mock.Anything, entities.Cat{Name: "Bella", Color: "black", Price: money.Money{Amount: 500, Currency: "EUR"}}).Return(id, nil)
+	request := &pb.AddNewCatRequest{Name: "Bella", Color: "black", LegacyPrice: 7.99, Price: &pb.Money{Amount: 500, Currency: "EUR"}}
+
+	// Act
+	_, err := newCatsService(s).AddNewCat(context.Background(), request)
+
+	// Assert
+	require.NoError(t, err)
+	s.AssertExpectations(t)
+}
+
+func TestUpdatePriceLegacyPrice(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	s := new(service.MockCats)
+	s.On("UpdatePrice", mock.Anything, id, money.Money{Amount: 1250, Currency: money.DefaultCurrency}).Return(nil)
+
+	// Act
+	_, err := newCatsService(s).UpdatePrice(context.Background(), &pb.UpdatePriceRequest{Id: id.String(), LegacyPrice: 12.5})
+
+	// Assert
+	require.NoError(t, err)
+	s.AssertExpectations(t)
+}
+
+func TestGetCatSetsLegacyPrice(t *testing.T) {
+	// Arrange
+	cat := entities.Cat{ID: uuid.New(), Name: "Bella", Price: money.Money{Amount: 1000, Currency: "USD"}}
+	s := new(service.MockCats)
+	s.On("GetOne", mock.Anything, cat.ID).Return(cat, nil)
+
+	// Act
+	response, err := newCatsService(s).GetCat(context.Background(), &pb.GetCatRequest{Id: cat.ID.String(), Currency: "EUR"})
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, 9.2, response.Cat.LegacyPrice)
+	require.Equal(t, int64(920), response.Cat.Price.Amount)
+}
@@ -0,0 +1,127 @@
+package grpc
+
+import (
+	"context"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/labstack/echo/v4"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/evleria/cats-app/internal/handler"
+	"github.com/evleria/cats-app/internal/ratelimit"
+	"github.com/evleria/cats-app/internal/service"
+	"github.com/evleria/cats-app/protocol/pb"
+)
+
+// NewGateway creates REST/JSON reverse proxy to gRPC server listening on addr, routes are defined by HTTP annotations of RPCs.
+// Calls go through the gRPC server, so they pass the same interceptors as native gRPC calls.
+// Errors are reported as the same problem details with the same status codes as REST API reports.
+// Nil creds mean the server is called in plaintext.
+func NewGateway(ctx context.Context, addr string, creds credentials.TransportCredentials) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(gatewayOutgoingHeaderMatcher),
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+		runtime.WithErrorHandler(gatewayErrorHandler),
+	)
+
+	opts := []grpc.DialOption{grpc.WithInsecure()}
+	if creds != nil {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	}
+	if err := pb.RegisterCatsServiceHandlerFromEndpoint(ctx, mux, addr, opts); err != nil {
+		return nil, err
+	}
+	if err := pb.RegisterPriceSchedulesServiceHandlerFromEndpoint(ctx, mux, addr, opts); err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
+
+// gatewayHeaderMatcher passes idempotency, API key and tenant headers as metadata in addition to headers passed by default
+func gatewayHeaderMatcher(key string) (string, bool) {
+	for _, name := range []string{idempotencyKeyMetadata, apiKeyMetadata, tenantMetadata} {
+		if strings.EqualFold(key, name) {
+			return name, true
+		}
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// gatewayOutgoingHeaderMatcher returns rate limit metadata as standard headers, other metadata gets Grpc-Metadata- prefix
+func gatewayOutgoingHeaderMatcher(key string) (string, bool) {
+	if strings.HasPrefix(key, "ratelimit-") {
+		return textproto.CanonicalMIMEHeaderKey(key), true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
+
+// gatewayErrorHandler writes an error of a call as problem details, headers returned with it, like rate limits, are kept
+func gatewayErrorHandler(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	if md, ok := runtime.ServerMetadataFromContext(ctx); ok {
+		for key, values := range md.HeaderMD {
+			if name, ok := gatewayOutgoingHeaderMatcher(key); ok {
+				for _, value := range values {
+					w.Header().Add(name, value)
+				}
+			}
+		}
+	}
+
+	s := status.Convert(err)
+	for _, detail := range s.Details() {
+		if retry, ok := detail.(*errdetails.RetryInfo); ok {
+			w.Header().Set(handler.HeaderRetryAfter, strconv.FormatInt(ratelimit.Seconds(retry.RetryDelay.AsDuration()), 10))
+		}
+	}
+	handler.ServeProblem(w, r, gatewayError(s))
+}
+
+// gatewayError turns a status back into an error REST API reports for the same failure
+func gatewayError(s *status.Status) error {
+	var (
+		info       *errdetails.ErrorInfo
+		badRequest *errdetails.BadRequest
+	)
+	for _, detail := range s.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			info = detail
+		case *errdetails.BadRequest:
+			badRequest = detail
+		}
+	}
+
+	httpStatus := runtime.HTTPStatusFromCode(s.Code())
+	switch {
+	case badRequest != nil && len(badRequest.FieldViolations) > 0:
+		violation := badRequest.FieldViolations[0]
+		return service.NewValidationError(violation.Field, violation.Description)
+	case info != nil && info.Domain == errorDomain:
+		for kind, name := range kindNames {
+			if info.Metadata[kindMetadata] == name {
+				return &service.Error{Kind: kind, Code: info.Reason, Message: s.Message()}
+			}
+		}
+		return handler.NewProblem(httpStatus, info.Reason, s.Message())
+	case s.Code() == codes.InvalidArgument:
+		// malformed requests and arguments rejected before reaching services have no details
+		return handler.NewProblem(http.StatusBadRequest, service.ValidationCode, s.Message())
+	case httpStatus < http.StatusInternalServerError:
+		return echo.NewHTTPError(httpStatus, s.Message())
+	default:
+		return s.Err()
+	}
+}
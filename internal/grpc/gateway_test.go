@@ -0,0 +1,180 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/evleria/cats-app/internal/handler"
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/service"
+	"github.com/evleria/cats-app/protocol/pb"
+)
+
+func setupGateway(t *testing.T, catsService service.Cats, interceptors ...grpc.UnaryServerInterceptor) http.Handler {
+	return serveGateway(t, func(server *grpc.Server) {
+		pb.RegisterCatsServiceServer(server, newCatsService(catsService))
+	}, interceptors...)
+}
+
+// serveGateway starts gRPC server with services registered by register and returns REST gateway to it
+func serveGateway(t *testing.T, register func(server *grpc.Server), interceptors ...grpc.UnaryServerInterceptor) http.Handler {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(append(interceptors, ErrorUnaryInterceptor())...),
+		grpc.ChainStreamInterceptor(ErrorStreamInterceptor()),
+	)
+	register(server)
+	go server.Serve(listener) //nolint:errcheck
+	t.Cleanup(server.Stop)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	gateway, err := NewGateway(ctx, listener.Addr().String(), nil)
+	require.NoError(t, err)
+	return gateway
+}
+
+func TestGatewayGetCat(t *testing.T) {
+	// Arrange
+	cat := entities.Cat{ID: uuid.New(), Name: "Bella", Color: "black", Price: money.Money{Amount: 1000, Currency: "USD"}, Status: entities.StatusAvailable}
+	s := new(service.MockCats)
+	s.On("GetOne", mock.Anything, cat.ID).Return(cat, nil)
+	gateway := setupGateway(t, s)
+	rec := httptest.NewRecorder()
+
+	// Act
+	gateway.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/cats/"+cat.ID.String(), nil))
+
+	// Assert
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `"name":"Bella"`)
+	require.Contains(t, rec.Body.String(), `"status":"STATUS_AVAILABLE"`)
+}
+
+func TestGatewayGetCatInCurrency(t *testing.T) {
+	// Arrange
+	cat := entities.Cat{ID: uuid.New(), Name: "Bella", Color: "black", Price: money.Money{Amount: 1000, Currency: "USD"}}
+	s := new(service.MockCats)
+	s.On("GetOne", mock.Anything, cat.ID).Return(cat, nil)
+	gateway := setupGateway(t, s)
+	rec := httptest.NewRecorder()
+
+	// Act
+	gateway.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/cats/"+cat.ID.String()+"?currency=EUR", nil))
+
+	// Assert
+	require.Equal(t, http.StatusOK, rec.Code)
+	var response struct {
+		Cat struct {
+			Price, ListPrice map[string]string
+		}
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Equal(t, map[string]string{"amount": "920", "currency": "EUR"}, response.Cat.Price)
+	require.Equal(t, map[string]string{"amount": "1000", "currency": "USD"}, response.Cat.ListPrice)
+}
+
+func TestGatewayDeleteCatNotFound(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	s := new(service.MockCats)
+	s.On("Delete", mock.Anything, id).Return(repository.ErrNotFound)
+	gateway := setupGateway(t, s)
+	rec := httptest.NewRecorder()
+
+	// Act
+	gateway.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/v1/cats/"+id.String(), nil))
+
+	// Assert
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestGatewayValidationProblem(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	s := new(service.MockCats)
+	s.On("UpdatePrice", mock.Anything, id, money.Money{Amount: 1250, Currency: "US"}).
+		Return(service.NewValidationError("price.currency", "must be an ISO 4217 code"))
+	gateway := setupGateway(t, s)
+	rec := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPut, "/v1/cats/"+id.String()+"/price", strings.NewReader(`{"price": {"amount": 1250, "currency": "US"}}`))
+
+	// Act
+	gateway.ServeHTTP(rec, request)
+
+	// Assert
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	var problem handler.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	require.Equal(t, service.ValidationCode, problem.Code)
+	require.Equal(t, []handler.InvalidParam{{Name: "price.currency", Reason: "must be an ISO 4217 code"}}, problem.InvalidParams)
+}
+
+func TestGatewayAddNewCatWithoutVaccinationDate(t *testing.T) {
+	// Arrange
+	gateway := setupGateway(t, new(service.MockCats))
+	rec := httptest.NewRecorder()
+	body := `{"name": "Bella", "color": "black", "price": {"amount": 1000, "currency": "USD"}, "vaccinations": [{"name": "rabies"}]}`
+
+	// Act
+	gateway.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/cats", strings.NewReader(body)))
+
+	// Assert
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	var problem handler.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	require.Equal(t, []handler.InvalidParam{{Name: "vaccinations[0].date", Reason: "must be a valid timestamp"}}, problem.InvalidParams)
+}
+
+func TestGatewayGetAllCatsNegativeLimit(t *testing.T) {
+	// Arrange
+	gateway := setupGateway(t, service.NewCatsService(new(repository.MockCats), nil, nil, time.Minute))
+	rec := httptest.NewRecorder()
+
+	// Act
+	gateway.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/cats?limit=-1", nil))
+
+	// Assert
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Contains(t, rec.Body.String(), `"fieldViolations":[{"field":"limit","description":"must not be negative"}]`)
+}
+
+func TestGatewayUpdatePrice(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	s := new(service.MockCats)
+	s.On("UpdatePrice", mock.Anything, id, money.Money{Amount: 1250, Currency: "USD"}).Return(nil)
+	gateway := setupGateway(t, s)
+	rec := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPut, "/v1/cats/"+id.String()+"/price", strings.NewReader(`{"price": {"amount": 1250, "currency": "USD"}}`))
+
+	// Act
+	gateway.ServeHTTP(rec, request)
+
+	// Assert
+	require.Equal(t, http.StatusOK, rec.Code)
+	s.AssertExpectations(t)
+}
+
+func TestGatewayHeaderMatcher(t *testing.T) {
+	key, ok := gatewayHeaderMatcher("Idempotency-Key")
+	require.True(t, ok)
+	require.Equal(t, idempotencyKeyMetadata, key)
+
+	_, ok = gatewayHeaderMatcher("X-Unknown")
+	require.False(t, ok)
+}
@@ -0,0 +1,165 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/repository/entities"
+)
+
+const (
+	// idempotencyKeyMetadata is a metadata key that can be used instead of idempotency_key request field
+	idempotencyKeyMetadata = "idempotency-key"
+
+	// idempotencyKeyReusedReason is a reason of calls reusing a key for a different request
+	idempotencyKeyReusedReason = "idempotency_key_reused"
+	// idempotencyKeyInProgressReason is a reason of calls repeating a request that has not completed yet
+	idempotencyKeyInProgressReason = "idempotency_key_in_progress"
+)
+
+// idempotentRequest is implemented by requests having idempotency_key field
+type idempotentRequest interface {
+	GetIdempotencyKey() string
+}
+
+// IdempotencyInterceptor stores the first response of a unary call with idempotency key and replays it for repeats.
+// Reusing a key for a different request results in AlreadyExists error. Server errors are not stored, so they can be retried.
+func IdempotencyInterceptor(keys repository.IdempotencyKeys) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		message, ok := req.(proto.Message)
+		key := idempotencyKey(ctx, req)
+		if !ok || key == "" {
+			return handler(ctx, req)
+		}
+
+		hash, err := requestFingerprint(message)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		scopedKey := "grpc:" + info.FullMethod + ":" + key
+		record, acquired, err := keys.Acquire(ctx, scopedKey, hash)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		if !acquired {
+			return replay(record, hash)
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			if isRetryable(status.Code(err)) {
+				return nil, releaseKey(ctx, keys, scopedKey, err)
+			}
+			s := status.Convert(err)
+			record.StatusCode = int(s.Code())
+			// status is stored with its details, so a replayed error is reported the same way
+			if record.Body, err = proto.Marshal(s.Proto()); err != nil {
+				return nil, releaseKey(ctx, keys, scopedKey, s.Err())
+			}
+			return nil, completeKey(ctx, keys, scopedKey, record, s.Err())
+		}
+
+		record.StatusCode = int(codes.OK)
+		packed, err := anypb.New(resp.(proto.Message))
+		if err == nil {
+			record.Body, err = proto.Marshal(packed)
+		}
+		if err == nil {
+			err = keys.Complete(ctx, scopedKey, record)
+		}
+		if err != nil {
+			// the call has already succeeded, so its result is returned even if it cannot be stored
+			logging.Warnf("cannot store idempotent response for %s: %v\n", info.FullMethod, err)
+		}
+		return resp, nil
+	}
+}
+
+func idempotencyKey(ctx context.Context, req interface{}) string {
+	if r, ok := req.(idempotentRequest); ok && r.GetIdempotencyKey() != "" {
+		return r.GetIdempotencyKey()
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(idempotencyKeyMetadata); len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// requestFingerprint hashes a request without idempotency_key field, so the same request retried with metadata key matches
+func requestFingerprint(message proto.Message) (string, error) {
+	clone := proto.Clone(message)
+	if field := clone.ProtoReflect().Descriptor().Fields().ByName("idempotency_key"); field != nil {
+		clone.ProtoReflect().Clear(field)
+	}
+	bytes, err := proto.MarshalOptions{Deterministic: true}.Marshal(clone)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(bytes)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func replay(record entities.IdempotencyRecord, fingerprint string) (interface{}, error) {
+	if record.Fingerprint != fingerprint {
+		return nil, withReason(status.New(codes.AlreadyExists, "idempotency key is already used for a different request"),
+			idempotencyKeyReusedReason, nil)
+	}
+	if !record.Completed {
+		return nil, withReason(status.New(codes.Aborted, "request with this idempotency key is in progress"),
+			idempotencyKeyInProgressReason, nil)
+	}
+	if code := codes.Code(record.StatusCode); code != codes.OK {
+		stored := new(spb.Status)
+		if err := proto.Unmarshal(record.Body, stored); err != nil || codes.Code(stored.Code) != code {
+			// records stored before statuses were kept with details hold just a message
+			return nil, status.Error(code, string(record.Body))
+		}
+		return nil, status.ErrorProto(stored)
+	}
+
+	packed := new(anypb.Any)
+	if err := proto.Unmarshal(record.Body, packed); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp, err := packed.UnmarshalNew()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return resp, nil
+}
+
+func isRetryable(code codes.Code) bool {
+	switch code {
+	case codes.Internal, codes.Unknown, codes.Unavailable, codes.DeadlineExceeded, codes.Canceled, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
+
+func releaseKey(ctx context.Context, keys repository.IdempotencyKeys, key string, cause error) error {
+	if err := keys.Release(ctx, key); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return cause
+}
+
+func completeKey(ctx context.Context, keys repository.IdempotencyKeys, key string, record entities.IdempotencyRecord, cause error) error {
+	if err := keys.Complete(ctx, key, record); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return cause
+}
@@ -0,0 +1,190 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/service"
+)
+
+// RecoveryUnaryInterceptor turns a panic in a handler into Internal error instead of crashing the server
+func RecoveryUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer recoverPanic(info.FullMethod, &err)
+		return handler(ctx, req)
+	}
+}
+
+// RecoveryStreamInterceptor turns a panic in a handler into Internal error instead of crashing the server
+func RecoveryStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer recoverPanic(info.FullMethod, &err)
+		return handler(srv, stream)
+	}
+}
+
+func recoverPanic(method string, err *error) {
+	if r := recover(); r != nil {
+		logging.Errorf("panic in %s: %v\n%s", method, r, debug.Stack())
+		*err = status.Error(codes.Internal, "internal error")
+	}
+}
+
+// LoggingUnaryInterceptor logs method, result code and duration of every call
+func LoggingUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// LoggingStreamInterceptor logs method, result code and duration of every call
+func LoggingStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, stream)
+		logCall(info.FullMethod, start, err)
+		return err
+	}
+}
+
+func logCall(method string, start time.Time, err error) {
+	logging.Infof("grpc %s %s %s\n", method, status.Code(err), time.Since(start))
+}
+
+// DeadlineUnaryInterceptor sets a deadline for calls that come without one
+func DeadlineUnaryInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, cancel := withDefaultDeadline(ctx, timeout)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
+
+// DeadlineStreamInterceptor sets a deadline for calls that come without one
+func DeadlineStreamInterceptor(timeout time.Duration) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := withDefaultDeadline(stream.Context(), timeout)
+		defer cancel()
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+func withDefaultDeadline(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// contextStream overrides context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// ErrorUnaryInterceptor translates domain errors returned by handlers to gRPC statuses
+func ErrorUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, mapError(err)
+	}
+}
+
+// ErrorStreamInterceptor translates domain errors returned by handlers to gRPC statuses
+func ErrorStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return mapError(handler(srv, stream))
+	}
+}
+
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var (
+		domainErr     *service.Error
+		validationErr *service.ValidationError
+	)
+	switch {
+	case errors.As(err, &domainErr):
+		return withReason(status.New(domainCode(domainErr.Kind), domainErr.Message), domainErr.Code,
+			map[string]string{kindMetadata: kindNames[domainErr.Kind]})
+	case errors.Is(err, repository.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, repository.ErrConflict):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.As(err, &validationErr):
+		s, detailsErr := status.New(codes.InvalidArgument, err.Error()).WithDetails(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: validationErr.Field, Description: validationErr.Message}},
+		})
+		if detailsErr != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return withReason(s, service.ValidationCode, nil)
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	default:
+		// details of unexpected errors may leak internals, so they are only logged
+		logging.Errorf("internal error: %v\n", err)
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+func domainCode(kind service.Kind) codes.Code {
+	switch kind {
+	case service.KindNotFound:
+		return codes.NotFound
+	case service.KindConflict, service.KindUnprocessable:
+		return codes.FailedPrecondition
+	case service.KindTooLarge, service.KindUnsupported:
+		return codes.InvalidArgument
+	default:
+		return codes.Internal
+	}
+}
+
+const (
+	// errorDomain is a domain of ErrorInfo details, their reasons are the codes REST API reports in problem details
+	errorDomain = "cats-app"
+	// kindMetadata is a key of ErrorInfo metadata holding kind of a domain error, REST gateway picks status code by it
+	kindMetadata = "kind"
+)
+
+var kindNames = map[service.Kind]string{
+	service.KindNotFound:      "not_found",
+	service.KindConflict:      "conflict",
+	service.KindTooLarge:      "too_large",
+	service.KindUnsupported:   "unsupported",
+	service.KindUnprocessable: "unprocessable",
+}
+
+// withReason adds ErrorInfo with given reason to a status, so clients can match errors by the same codes as in REST API
+func withReason(s *status.Status, reason string, metadata map[string]string) error {
+	detailed, err := s.WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: errorDomain, Metadata: metadata})
+	if err != nil {
+		return s.Err()
+	}
+	return detailed.Err()
+}
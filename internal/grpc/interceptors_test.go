@@ -0,0 +1,126 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/service"
+)
+
+var unaryInfo = &grpc.UnaryServerInfo{FullMethod: "/CatsService/GetCat"}
+
+func TestErrorUnaryInterceptor(t *testing.T) {
+	for err, code := range map[error]codes.Code{
+		repository.ErrNotFound:                                         codes.NotFound,
+		service.ErrCatNotFound:                                         codes.NotFound,
+		service.ErrReservationNotActive:                                codes.FailedPrecondition,
+		fmt.Errorf("cat is not available: %w", repository.ErrConflict): codes.FailedPrecondition,
+		service.NewValidationError("price", "must not be negative"):    codes.InvalidArgument,
+		context.DeadlineExceeded:                                       codes.DeadlineExceeded,
+		status.Error(codes.AlreadyExists, "exists"):                    codes.AlreadyExists,
+	} {
+		// Arrange
+		handler := func(context.Context, interface{}) (interface{}, error) {
+			return nil, err
+		}
+
+		// Act
+		_, actual := ErrorUnaryInterceptor()(context.Background(), nil, unaryInfo, handler)
+
+		// Assert
+		require.Equal(t, code, status.Code(actual), err.Error())
+		require.Equal(t, status.Convert(err).Message(), status.Convert(actual).Message())
+	}
+}
+
+func TestErrorUnaryInterceptorAddsDetails(t *testing.T) {
+	// Arrange
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("cannot reserve: %w", service.ErrCatNotAvailable)
+	}
+
+	// Act
+	_, err := ErrorUnaryInterceptor()(context.Background(), nil, unaryInfo, handler)
+
+	// Assert
+	s := status.Convert(err)
+	require.Equal(t, codes.FailedPrecondition, s.Code())
+	require.Equal(t, "cat is not available", s.Message())
+	require.Len(t, s.Details(), 1)
+	info := s.Details()[0].(*errdetails.ErrorInfo)
+	require.Equal(t, "cat_not_available", info.Reason)
+	require.Equal(t, "conflict", info.Metadata[kindMetadata])
+}
+
+func TestErrorUnaryInterceptorHidesInternalError(t *testing.T) {
+	// Arrange
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		return nil, errors.New("connection(localhost:27017) incomplete read of message header")
+	}
+
+	// Act
+	_, err := ErrorUnaryInterceptor()(context.Background(), nil, unaryInfo, handler)
+
+	// Assert
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.Equal(t, "internal error", status.Convert(err).Message())
+}
+
+func TestRecoveryUnaryInterceptor(t *testing.T) {
+	// Arrange
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		panic("oops")
+	}
+
+	// Act
+	resp, err := RecoveryUnaryInterceptor()(context.Background(), nil, unaryInfo, handler)
+
+	// Assert
+	require.Nil(t, resp)
+	require.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestDeadlineUnaryInterceptor(t *testing.T) {
+	// Arrange
+	var deadline time.Time
+	handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		deadline, _ = ctx.Deadline()
+		return nil, nil
+	}
+
+	// Act
+	_, err := DeadlineUnaryInterceptor(time.Minute)(context.Background(), nil, unaryInfo, handler)
+
+	// Assert
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+}
+
+func TestDeadlineUnaryInterceptorKeepsClientDeadline(t *testing.T) {
+	// Arrange
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	expected, _ := ctx.Deadline()
+	var deadline time.Time
+	handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		deadline, _ = ctx.Deadline()
+		return nil, nil
+	}
+
+	// Act
+	_, err := DeadlineUnaryInterceptor(time.Minute)(ctx, nil, unaryInfo, handler)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, expected, deadline)
+}
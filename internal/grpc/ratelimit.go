@@ -0,0 +1,133 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/ratelimit"
+	"github.com/evleria/cats-app/protocol/pb"
+)
+
+const (
+	// apiKeyMetadata identifies a client for rate limiting, clients without it are identified by IP address
+	apiKeyMetadata = "x-api-key"
+	// forwardedForMetadata carries address of a client calling through REST gateway
+	forwardedForMetadata = "x-forwarded-for"
+	// rateLimitedReason is a reason of rejected calls
+	rateLimitedReason = "rate_limited"
+)
+
+// readMethods are methods that do not change state, all other methods are limited as writes
+var readMethods = map[string]bool{
+	"/" + pb.CatsService_ServiceDesc.ServiceName + "/GetAllCats": true,
+	"/" + pb.CatsService_ServiceDesc.ServiceName + "/SearchCats": true,
+	"/" + pb.CatsService_ServiceDesc.ServiceName + "/GetCat":     true,
+
+	"/" + pb.PriceSchedulesService_ServiceDesc.ServiceName + "/ListSchedules": true,
+	"/" + pb.PriceSchedulesService_ServiceDesc.ServiceName + "/GetSchedule":   true,
+}
+
+// RateLimitUnaryInterceptor takes a token from client's bucket of a method class on every call and rejects calls
+// with ResourceExhausted when the bucket is empty. Calls are let through if limiter fails.
+func RateLimitUnaryInterceptor(limiter ratelimit.Limiter, rules *ratelimit.Rules) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, err := checkRateLimit(ctx, limiter, rules, info.FullMethod)
+		if md != nil {
+			if err := grpc.SetHeader(ctx, md); err != nil {
+				logging.Warnf("cannot set rate limit headers: %v\n", err)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// RateLimitStreamInterceptor takes a token from client's bucket of a method class on every stream and rejects streams
+// with ResourceExhausted when the bucket is empty. Streams are let through if limiter fails.
+func RateLimitStreamInterceptor(limiter ratelimit.Limiter, rules *ratelimit.Rules) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		md, err := checkRateLimit(stream.Context(), limiter, rules, info.FullMethod)
+		if md != nil {
+			if err := stream.SetHeader(md); err != nil {
+				logging.Warnf("cannot set rate limit headers: %v\n", err)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// checkRateLimit returns rate limit headers, or nil if the call is not limited, and ResourceExhausted error if it is rejected
+func checkRateLimit(ctx context.Context, limiter ratelimit.Limiter, rules *ratelimit.Rules, method string) (metadata.MD, error) {
+	class := ratelimit.ClassWrite
+	if readMethods[method] {
+		class = ratelimit.ClassRead
+	}
+	limit, ok := rules.Get(class)
+	if !ok {
+		return nil, nil
+	}
+
+	client := clientKey(ctx, rules)
+	result, err := limiter.Allow(ctx, ratelimit.BucketKey(class, client), limit)
+	if err != nil {
+		logging.Warnf("cannot check rate limit of %s: %v\n", client, err)
+		return nil, nil
+	}
+
+	md := metadata.Pairs(
+		"ratelimit-limit", strconv.Itoa(result.Limit),
+		"ratelimit-remaining", strconv.Itoa(result.Remaining),
+		"ratelimit-reset", strconv.FormatInt(ratelimit.Seconds(result.Reset), 10),
+	)
+	if result.Allowed {
+		return md, nil
+	}
+
+	s := status.New(codes.ResourceExhausted, "rate limit of "+string(class)+" calls is exceeded")
+	if detailed, err := s.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(result.RetryAfter)}); err == nil {
+		s = detailed
+	}
+	return md, withReason(s, rateLimitedReason, nil)
+}
+
+// clientKey identifies caller by configured API key or by IP address.
+// Address forwarded by REST gateway is trusted only for calls coming from loopback, where the gateway runs.
+func clientKey(ctx context.Context, rules *ratelimit.Rules) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	apiKey := ""
+	if keys := md.Get(apiKeyMetadata); len(keys) > 0 {
+		apiKey = keys[0]
+	}
+
+	ip := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.IsLoopback() {
+		// gateway appends address of its client to forwarded chain, so the last entry is the one it has seen
+		if forwarded := md.Get(forwardedForMetadata); len(forwarded) > 0 {
+			chain := strings.Split(forwarded[len(forwarded)-1], ",")
+			ip = strings.TrimSpace(chain[len(chain)-1])
+		}
+	}
+	return rules.ClientKey(apiKey, ip)
+}
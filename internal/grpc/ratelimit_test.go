@@ -0,0 +1,130 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/evleria/cats-app/internal/ratelimit"
+	"github.com/evleria/cats-app/internal/service"
+)
+
+var readLimit = ratelimit.Limit{Rate: 10, Burst: 20}
+
+func TestRateLimitUnaryInterceptorExceeded(t *testing.T) {
+	// Arrange
+	l := new(ratelimit.MockLimiter)
+	l.On("Allow", mock.Anything, "read:ip:192.0.2.1", readLimit).
+		Return(ratelimit.Result{Limit: 20, Reset: 2 * time.Second, RetryAfter: 100 * time.Millisecond}, nil)
+	ctx := peerContext("192.0.2.1:5000", nil)
+	called := false
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	}
+
+	// Act
+	_, err := RateLimitUnaryInterceptor(l, rateLimits())(ctx, nil, unaryInfo, handler)
+
+	// Assert
+	require.False(t, called)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	details := status.Convert(err).Details()
+	require.Len(t, details, 2)
+	require.Equal(t, 100*time.Millisecond, details[0].(*errdetails.RetryInfo).RetryDelay.AsDuration())
+	require.Equal(t, rateLimitedReason, details[1].(*errdetails.ErrorInfo).Reason)
+}
+
+func TestRateLimitUnaryInterceptorUnlimitedClass(t *testing.T) {
+	// Arrange
+	l := new(ratelimit.MockLimiter)
+	info := *unaryInfo
+	info.FullMethod = "/CatsService/DeleteCat"
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		return "deleted", nil
+	}
+
+	// Act
+	resp, err := RateLimitUnaryInterceptor(l, rateLimits())(context.Background(), nil, &info, handler)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, "deleted", resp)
+	l.AssertNotCalled(t, "Allow", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRateLimitUnaryInterceptorFailsOpen(t *testing.T) {
+	// Arrange
+	l := new(ratelimit.MockLimiter)
+	l.On("Allow", mock.Anything, mock.Anything, readLimit).Return(ratelimit.Result{}, errors.New("redis is down"))
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		return "cat", nil
+	}
+
+	// Act
+	resp, err := RateLimitUnaryInterceptor(l, rateLimits())(peerContext("192.0.2.1:5000", nil), nil, unaryInfo, handler)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, "cat", resp)
+}
+
+func TestClientKey(t *testing.T) {
+	rules := rateLimits()
+	for expected, ctx := range map[string]context.Context{
+		"ip:192.0.2.1":                    peerContext("192.0.2.1:5000", nil),
+		"ip:192.0.2.2":                    peerContext("192.0.2.2:5000", metadata.Pairs(forwardedForMetadata, "198.51.100.1")),
+		"ip:198.51.100.1":                 peerContext("127.0.0.1:5000", metadata.Pairs(forwardedForMetadata, "203.0.113.9, 198.51.100.1")),
+		"ip:192.0.2.3":                    peerContext("192.0.2.3:5000", metadata.Pairs(apiKeyMetadata, "made-up-key")),
+		rules.ClientKey("client-key", ""): peerContext("192.0.2.1:5000", metadata.Pairs(apiKeyMetadata, "client-key")),
+	} {
+		require.Equal(t, expected, clientKey(ctx, rules))
+	}
+}
+
+func TestGatewayRateLimit(t *testing.T) {
+	// Arrange
+	l := new(ratelimit.MockLimiter)
+	l.On("Allow", mock.Anything, ratelimit.BucketKey(ratelimit.ClassRead, rateLimits().ClientKey("client-key", "")), readLimit).
+		Return(ratelimit.Result{Limit: 20, Reset: 2 * time.Second, RetryAfter: time.Second}, nil)
+	gateway := setupGateway(t, new(service.MockCats), RateLimitUnaryInterceptor(l, rateLimits()))
+	request := httptest.NewRequest(http.MethodGet, "/v1/cats:search?query=grey", nil)
+	request.Header.Set("X-API-Key", "client-key")
+	rec := httptest.NewRecorder()
+
+	// Act
+	gateway.ServeHTTP(rec, request)
+
+	// Assert
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "20", rec.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	require.Equal(t, "2", rec.Header().Get("RateLimit-Reset"))
+}
+
+func rateLimits() *ratelimit.Rules {
+	rules := ratelimit.NewRules(map[ratelimit.Class]ratelimit.Limit{ratelimit.ClassRead: readLimit})
+	rules.SetAPIKeys([]string{"client-key"})
+	return rules
+}
+
+func peerContext(addr string, md metadata.MD) context.Context {
+	tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
+	if err != nil {
+		panic(err)
+	}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: tcpAddr})
+	return metadata.NewIncomingContext(ctx, md)
+}
@@ -0,0 +1,219 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/service"
+	"github.com/evleria/cats-app/protocol/pb"
+)
+
+// PriceSchedulesService grpc service implementation of pb.PriceSchedulesServiceServer
+type PriceSchedulesService struct {
+	pb.UnimplementedPriceSchedulesServiceServer
+	service service.Schedules
+}
+
+// NewPriceSchedulesService returns a new pb.PriceSchedulesServiceServer
+func NewPriceSchedulesService(schedulesService service.Schedules) pb.PriceSchedulesServiceServer {
+	return &PriceSchedulesService{
+		service: schedulesService,
+	}
+}
+
+// ListSchedules fetches price schedules ordered by start
+func (s *PriceSchedulesService) ListSchedules(ctx context.Context, request *pb.ListSchedulesRequest) (*pb.ListSchedulesResponse, error) {
+	schedules, err := s.service.GetAll(ctx, repository.Page{Limit: request.Limit, Offset: request.Offset})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &pb.ListSchedulesResponse{
+		Schedules: make([]*pb.PriceSchedule, 0, len(schedules)),
+	}
+	for _, schedule := range schedules {
+		response.Schedules = append(response.Schedules, mapSchedule(schedule))
+	}
+	return response, nil
+}
+
+// GetSchedule fetches a price schedule by ID along with prices it has changed
+func (s *PriceSchedulesService) GetSchedule(ctx context.Context, request *pb.GetScheduleRequest) (*pb.PriceSchedule, error) {
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	schedule, err := s.service.GetOne(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return mapSchedule(schedule), nil
+}
+
+// CreateSchedule schedules a price change, or a promotion when end is given
+func (s *PriceSchedulesService) CreateSchedule(ctx context.Context, request *pb.CreateScheduleRequest) (*pb.CreateScheduleResponse, error) {
+	schedule, err := unmapSchedule(request)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	id, err := s.service.Create(ctx, schedule)
+	if err != nil {
+		return nil, err
+	}
+	response := &pb.CreateScheduleResponse{
+		Id: id.String(),
+	}
+	return response, nil
+}
+
+// UpdateSchedule replaces a pending schedule, only end of an active promotion is taken from the request
+func (s *PriceSchedulesService) UpdateSchedule(ctx context.Context, request *pb.UpdateScheduleRequest) (*empty.Empty, error) {
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	schedule, err := unmapSchedule(request)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	schedule.ID = id
+	err = s.service.Update(ctx, schedule)
+	if err != nil {
+		return nil, err
+	}
+	return &empty.Empty{}, nil
+}
+
+// DeleteSchedule deletes a pending or completed schedule by ID
+func (s *PriceSchedulesService) DeleteSchedule(ctx context.Context, request *pb.DeleteScheduleRequest) (*empty.Empty, error) {
+	id, err := uuid.Parse(request.Id)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	err = s.service.Delete(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &empty.Empty{}, nil
+}
+
+// scheduleRequest is implemented by requests that carry a price schedule
+type scheduleRequest interface {
+	GetCatId() string
+	GetFilter() *pb.ScheduleFilter
+	GetPrice() *pb.Money
+	GetDiscountPercent() int32
+	GetStartsAt() *timestamppb.Timestamp
+	GetEndsAt() *timestamppb.Timestamp
+}
+
+func unmapSchedule(request scheduleRequest) (entities.PriceSchedule, error) {
+	schedule := entities.PriceSchedule{
+		DiscountPercent: int(request.GetDiscountPercent()),
+	}
+	if request.GetCatId() != "" {
+		catID, err := uuid.Parse(request.GetCatId())
+		if err != nil {
+			return schedule, err
+		}
+		schedule.CatID = &catID
+	}
+	if filter := request.GetFilter(); filter != nil {
+		schedule.Filter = &entities.ScheduleFilter{
+			Color:  filter.Color,
+			Breed:  filter.Breed,
+			Tags:   filter.Tags,
+			MinAge: int64ToIntPtr(filter.MinAge),
+			MaxAge: int64ToIntPtr(filter.MaxAge),
+		}
+	}
+	if request.GetPrice() != nil {
+		price := unmapMoney(request.GetPrice())
+		schedule.Price = &price
+	}
+	if startsAt := request.GetStartsAt(); startsAt != nil {
+		if err := startsAt.CheckValid(); err != nil {
+			return schedule, err
+		}
+		schedule.StartsAt = startsAt.AsTime()
+	}
+	if endsAt := request.GetEndsAt(); endsAt != nil {
+		if err := endsAt.CheckValid(); err != nil {
+			return schedule, err
+		}
+		end := endsAt.AsTime()
+		schedule.EndsAt = &end
+	}
+	return schedule, nil
+}
+
+func mapSchedule(schedule entities.PriceSchedule) *pb.PriceSchedule {
+	result := &pb.PriceSchedule{
+		Id:              schedule.ID.String(),
+		DiscountPercent: int32(schedule.DiscountPercent),
+		StartsAt:        timestamppb.New(schedule.StartsAt),
+		State:           mapScheduleState(schedule.State),
+		LastError:       schedule.LastError,
+		CreatedAt:       timestamppb.New(schedule.CreatedAt),
+		UpdatedAt:       timestamppb.New(schedule.UpdatedAt),
+	}
+	if schedule.CatID != nil {
+		result.CatId = schedule.CatID.String()
+	}
+	if schedule.Filter != nil {
+		result.Filter = &pb.ScheduleFilter{
+			Color:  schedule.Filter.Color,
+			Breed:  schedule.Filter.Breed,
+			Tags:   schedule.Filter.Tags,
+			MinAge: intPtrToInt64(schedule.Filter.MinAge),
+			MaxAge: intPtrToInt64(schedule.Filter.MaxAge),
+		}
+	}
+	if schedule.Price != nil {
+		result.Price = mapMoney(*schedule.Price)
+	}
+	if schedule.EndsAt != nil {
+		result.EndsAt = timestamppb.New(*schedule.EndsAt)
+	}
+	for _, applied := range schedule.Applied {
+		result.Applied = append(result.Applied, &pb.AppliedPrice{
+			CatId:         applied.CatID.String(),
+			OriginalPrice: mapMoney(applied.OriginalPrice),
+			Price:         mapMoney(applied.Price),
+			Reverted:      applied.Reverted,
+		})
+	}
+	return result
+}
+
+func mapScheduleState(state entities.ScheduleState) pb.ScheduleState {
+	switch state {
+	case entities.SchedulePending:
+		return pb.ScheduleState_SCHEDULE_STATE_PENDING
+	case entities.ScheduleApplying:
+		return pb.ScheduleState_SCHEDULE_STATE_APPLYING
+	case entities.ScheduleActive:
+		return pb.ScheduleState_SCHEDULE_STATE_ACTIVE
+	case entities.ScheduleReverting:
+		return pb.ScheduleState_SCHEDULE_STATE_REVERTING
+	case entities.ScheduleCompleted:
+		return pb.ScheduleState_SCHEDULE_STATE_COMPLETED
+	default:
+		return pb.ScheduleState_SCHEDULE_STATE_UNSPECIFIED
+	}
+}
+
+func intPtrToInt64(value *int) *int64 {
+	if value == nil {
+		return nil
+	}
+	result := int64(*value)
+	return &result
+}
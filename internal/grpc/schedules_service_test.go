@@ -0,0 +1,92 @@
+package grpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/evleria/cats-app/internal/handler"
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/service"
+	"github.com/evleria/cats-app/protocol/pb"
+)
+
+func setupSchedulesGateway(t *testing.T, schedulesService service.Schedules) http.Handler {
+	return serveGateway(t, func(server *grpc.Server) {
+		pb.RegisterPriceSchedulesServiceServer(server, NewPriceSchedulesService(schedulesService))
+	})
+}
+
+func TestGatewayCreateSchedule(t *testing.T) {
+	// Arrange
+	id, catID := uuid.New(), uuid.New()
+	startsAt := time.Date(2021, 8, 6, 9, 0, 0, 0, time.UTC)
+	endsAt := time.Date(2021, 8, 9, 9, 0, 0, 0, time.UTC)
+	s := new(service.MockSchedules)
+	s.On("Create", mock.Anything, entities.PriceSchedule{
+		CatID:    &catID,
+		Price:    &money.Money{Amount: 599, Currency: "USD"},
+		StartsAt: startsAt,
+		EndsAt:   &endsAt,
+	}).Return(id, nil)
+	gateway := setupSchedulesGateway(t, s)
+	rec := httptest.NewRecorder()
+	body := `{"catId":"` + catID.String() + `","price":{"amount":"599","currency":"USD"},` +
+		`"startsAt":"2021-08-06T09:00:00Z","endsAt":"2021-08-09T09:00:00Z"}`
+
+	// Act
+	gateway.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/schedules", strings.NewReader(body)))
+
+	// Assert
+	require.Equal(t, http.StatusOK, rec.Code)
+	var response map[string]string
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Equal(t, id.String(), response["id"])
+}
+
+func TestGatewayGetScheduleNotFound(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	s := new(service.MockSchedules)
+	s.On("GetOne", mock.Anything, id).Return(entities.PriceSchedule{}, service.ErrScheduleNotFound)
+	gateway := setupSchedulesGateway(t, s)
+	rec := httptest.NewRecorder()
+
+	// Act
+	gateway.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/schedules/"+id.String(), nil))
+
+	// Assert
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestGatewayUpdateScheduleNotEditable(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	endsAt := time.Date(2021, 8, 9, 9, 0, 0, 0, time.UTC)
+	s := new(service.MockSchedules)
+	s.On("Update", mock.Anything, entities.PriceSchedule{ID: id, EndsAt: &endsAt}).Return(service.ErrScheduleNotEditable)
+	gateway := setupSchedulesGateway(t, s)
+	rec := httptest.NewRecorder()
+
+	// Act
+	gateway.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/v1/schedules/"+id.String(), strings.NewReader(`{"endsAt":"2021-08-09T09:00:00Z"}`)))
+
+	// Assert
+	// conflicts are FailedPrecondition in gRPC, gateway reports them by their kind as REST API does
+	require.Equal(t, http.StatusConflict, rec.Code)
+	require.Equal(t, handler.MIMEApplicationProblemJSON, rec.Header().Get("Content-Type"))
+	var problem handler.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	require.Equal(t, "schedule_not_editable", problem.Code)
+	require.Equal(t, "schedule has already started", problem.Detail)
+	require.Equal(t, "/v1/schedules/"+id.String(), problem.Instance)
+}
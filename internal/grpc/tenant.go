@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/evleria/cats-app/internal/tenant"
+	"github.com/evleria/cats-app/protocol/pb"
+)
+
+const (
+	// tenantMetadata carries tenant of a call when bearer tokens are not used
+	tenantMetadata = "x-tenant-id"
+	// authorizationMetadata carries bearer token, REST gateway passes Authorization header as it
+	authorizationMetadata = "authorization"
+
+	// tenantUnauthenticatedReason is a reason of calls without valid tenant credentials
+	tenantUnauthenticatedReason = "tenant_unauthenticated"
+	// tenantForbiddenReason is a reason of calls of tenants not served here
+	tenantForbiddenReason = "tenant_forbidden"
+)
+
+// tenantScopedPrefixes are prefixes of methods working with data of a tenant, e.g. health and reflection services are not
+var tenantScopedPrefixes = []string{
+	"/" + pb.CatsService_ServiceDesc.ServiceName + "/",
+	"/" + pb.PriceSchedulesService_ServiceDesc.ServiceName + "/",
+}
+
+// TenantUnaryInterceptor resolves tenant of a call and puts it into context, calls without valid tenant credentials
+// are rejected with Unauthenticated, calls of tenants not served here with PermissionDenied
+func TenantUnaryInterceptor(resolver *tenant.Resolver) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !isTenantScoped(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		ctx, err := resolveTenant(ctx, resolver)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// TenantStreamInterceptor resolves tenant of a stream the same way as TenantUnaryInterceptor
+func TenantStreamInterceptor(resolver *tenant.Resolver) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !isTenantScoped(info.FullMethod) {
+			return handler(srv, stream)
+		}
+		ctx, err := resolveTenant(stream.Context(), resolver)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+func isTenantScoped(method string) bool {
+	for _, prefix := range tenantScopedPrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func resolveTenant(ctx context.Context, resolver *tenant.Resolver) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	tenantID, err := resolver.Resolve(firstValue(md, authorizationMetadata), firstValue(md, tenantMetadata))
+	if tenant.IsUnauthenticated(err) {
+		return nil, withReason(status.New(codes.Unauthenticated, err.Error()), tenantUnauthenticatedReason, nil)
+	} else if err != nil {
+		return nil, withReason(status.New(codes.PermissionDenied, err.Error()), tenantForbiddenReason, nil)
+	}
+	return tenant.WithID(ctx, tenantID), nil
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
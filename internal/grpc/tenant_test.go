@@ -0,0 +1,68 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+var catsInfo = &grpc.UnaryServerInfo{FullMethod: tenantScopedPrefixes[0] + "GetCat"}
+
+func TestTenantUnaryInterceptor(t *testing.T) {
+	// Arrange
+	resolver := tenant.NewResolver([]string{tenant.Default, "shelter-1"}, "", "", false)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(tenantMetadata, "shelter-1"))
+	handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return tenant.FromContext(ctx)
+	}
+
+	// Act
+	tenantID, err := TenantUnaryInterceptor(resolver)(ctx, nil, catsInfo, handler)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, "shelter-1", tenantID)
+}
+
+func TestTenantUnaryInterceptorRejectsCall(t *testing.T) {
+	resolver := tenant.NewResolver([]string{tenant.Default}, "", "", true)
+	for value, code := range map[string]codes.Code{
+		"":          codes.Unauthenticated,
+		"shelter-1": codes.PermissionDenied,
+	} {
+		// Arrange
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(tenantMetadata, value))
+		handler := func(context.Context, interface{}) (interface{}, error) {
+			return nil, nil
+		}
+
+		// Act
+		_, err := TenantUnaryInterceptor(resolver)(ctx, nil, catsInfo, handler)
+
+		// Assert
+		require.Equal(t, code, status.Code(err), value)
+	}
+}
+
+func TestTenantUnaryInterceptorSkipsOtherServices(t *testing.T) {
+	// Arrange
+	resolver := tenant.NewResolver([]string{tenant.Default}, "", "", true)
+	info := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	// Act
+	resp, err := TenantUnaryInterceptor(resolver)(context.Background(), nil, info, handler)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, "ok", resp)
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/evleria/cats-app/internal/leader"
+	"github.com/evleria/cats-app/internal/replay"
+	"github.com/evleria/cats-app/internal/service"
+)
+
+const (
+	// HeaderAdminToken carries the token admin routes are authenticated with
+	HeaderAdminToken = "X-Admin-Token"
+
+	// adminUnauthenticatedCode is an error code of admin requests without valid admin token
+	adminUnauthenticatedCode = "admin_unauthenticated"
+	// replayRunningCode is an error code of starting a replay into a handler which is already being replayed into
+	replayRunningCode = "replay_running"
+	// replayNotFoundCode is an error code of unknown replay job
+	replayNotFoundCode = "replay_not_found"
+)
+
+// AdminAuth rejects requests without given token in X-Admin-Token header with 401, all of them when the token is empty
+func AdminAuth(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if token == "" {
+				return NewProblem(http.StatusUnauthorized, adminUnauthenticatedCode, "admin routes are disabled")
+			}
+			sent := ctx.Request().Header.Get(HeaderAdminToken)
+			if subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+				return NewProblem(http.StatusUnauthorized, adminUnauthenticatedCode, "admin token is missing or invalid")
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// GetLeader shows which instance currently leads each election, keyed by role the election is held for
+func GetLeader(electors map[string]leader.Elector) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		infos := make(map[string]leader.Info, len(electors))
+		for role, elector := range electors {
+			info, err := elector.Leader(ctx.Request().Context())
+			if err != nil {
+				return err
+			}
+			infos[role] = info
+		}
+
+		return ctx.JSON(http.StatusOK, infos)
+	}
+}
+
+// StartReplayRequest describes a replay of price stream into a handler
+type StartReplayRequest struct {
+	Handler string  `json:"handler"`
+	From    string  `json:"from"`
+	To      string  `json:"to"`
+	Rate    float64 `json:"rate"`
+}
+
+// StartReplay starts replay of price stream in background, its progress is available by returned ID
+func StartReplay(jobs replay.Jobs) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		request := new(StartReplayRequest)
+		if err := ctx.Bind(request); err != nil {
+			return err
+		}
+
+		progress, err := jobs.Start(ctx.Request().Context(), replay.Request{
+			Handler: request.Handler,
+			From:    request.From,
+			To:      request.To,
+			Rate:    request.Rate,
+		})
+		if errors.Is(err, replay.ErrUnknownHandler) || errors.Is(err, replay.ErrInvalidBound) {
+			return NewProblem(http.StatusBadRequest, service.ValidationCode, err.Error())
+		} else if errors.Is(err, replay.ErrJobRunning) {
+			return NewProblem(http.StatusConflict, replayRunningCode, err.Error())
+		} else if err != nil {
+			return err
+		}
+
+		return ctx.JSON(http.StatusAccepted, progress)
+	}
+}
+
+// GetReplay shows progress of a replay
+func GetReplay(jobs replay.Jobs) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		id, err := parseID(ctx, "jobId")
+		if err != nil {
+			return err
+		}
+
+		progress, err := jobs.Get(ctx.Request().Context(), id)
+		if errors.Is(err, replay.ErrJobNotFound) {
+			return NewProblem(http.StatusNotFound, replayNotFoundCode, err.Error())
+		} else if err != nil {
+			return err
+		}
+
+		return ctx.JSON(http.StatusOK, progress)
+	}
+}
+
+// CancelReplay stops a running replay
+func CancelReplay(jobs replay.Jobs) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		id, err := parseID(ctx, "jobId")
+		if err != nil {
+			return err
+		}
+
+		err = jobs.Cancel(ctx.Request().Context(), id)
+		if errors.Is(err, replay.ErrJobNotFound) {
+			return NewProblem(http.StatusNotFound, replayNotFoundCode, err.Error())
+		} else if err != nil {
+			return err
+		}
+
+		return ctx.NoContent(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/leader"
+	"github.com/evleria/cats-app/internal/replay"
+	"github.com/evleria/cats-app/internal/service"
+)
+
+var replayProgress = replay.Progress{
+	ID:        uuid.New(),
+	Handler:   "price-history",
+	From:      "-",
+	To:        "+",
+	State:     replay.StateRunning,
+	StartedAt: time.Date(2021, 8, 1, 12, 0, 0, 0, time.UTC),
+}
+
+func TestAdminAuth(t *testing.T) {
+	for name, tc := range map[string]struct {
+		token, sent string
+		allowed     bool
+	}{
+		"valid token":    {token: "secret", sent: "secret", allowed: true},
+		"invalid token":  {token: "secret", sent: "guess"},
+		"missing token":  {token: "secret"},
+		"not configured": {sent: ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			ctx, rec := setup(http.MethodGet, nil)
+			if tc.sent != "" {
+				ctx.Request().Header.Set(HeaderAdminToken, tc.sent)
+			}
+
+			// Act
+			err := AdminAuth(tc.token)(func(ctx echo.Context) error { return ctx.NoContent(http.StatusOK) })(ctx)
+
+			// Assert
+			if tc.allowed {
+				require.NoError(t, err)
+				require.Equal(t, http.StatusOK, rec.Code)
+			} else {
+				requireProblem(t, err, http.StatusUnauthorized, adminUnauthenticatedCode)
+			}
+		})
+	}
+}
+
+func TestGetLeader(t *testing.T) {
+	// Arrange
+	bridge := leader.Info{Name: "price-bridge", InstanceID: "cats-1", Token: 7, Elected: true, Self: true}
+	scheduler := leader.Info{Name: "price-scheduler", InstanceID: "cats-2", Token: 3, Elected: true}
+	b := new(leader.MockElector)
+	b.On("Leader", mockContext).Return(bridge, nil)
+	s := new(leader.MockElector)
+	s.On("Leader", mockContext).Return(scheduler, nil)
+	ctx, rec := setup(http.MethodGet, nil)
+
+	// Act
+	err := GetLeader(map[string]leader.Elector{"bridge": b, "scheduler": s})(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, mustEncodeJSON(map[string]leader.Info{"bridge": bridge, "scheduler": scheduler}), rec.Body.String())
+}
+
+func TestGetLeaderError(t *testing.T) {
+	// Arrange
+	e := new(leader.MockElector)
+	e.On("Leader", mockContext).Return(leader.Info{}, errors.New("redis is down"))
+	ctx, _ := setup(http.MethodGet, nil)
+
+	// Act
+	err := GetLeader(map[string]leader.Elector{"scheduler": e})(ctx)
+
+	// Assert
+	require.Error(t, err)
+	requireProblem(t, err, http.StatusInternalServerError, internalErrorCode)
+}
+
+func TestStartReplay(t *testing.T) {
+	// Arrange
+	j := new(replay.MockJobs)
+	j.On("Start", mockContext, replay.Request{Handler: "price-history", Rate: 100}).Return(replayProgress, nil)
+	ctx, rec := setup(http.MethodPost, StartReplayRequest{Handler: "price-history", Rate: 100})
+
+	// Act
+	err := StartReplay(j)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	require.Equal(t, mustEncodeJSON(replayProgress), rec.Body.String())
+}
+
+func TestStartReplayUnknownHandler(t *testing.T) {
+	// Arrange
+	j := new(replay.MockJobs)
+	startErr := fmt.Errorf("%w: %q", replay.ErrUnknownHandler, "nope")
+	j.On("Start", mockContext, replay.Request{Handler: "nope"}).Return(replay.Progress{}, startErr)
+	ctx, _ := setup(http.MethodPost, StartReplayRequest{Handler: "nope"})
+
+	// Act
+	err := StartReplay(j)(ctx)
+
+	// Assert
+	require.Error(t, err)
+	problem := requireProblem(t, err, http.StatusBadRequest, service.ValidationCode)
+	require.Equal(t, startErr.Error(), problem.Detail)
+}
+
+func TestStartReplayAlreadyRunning(t *testing.T) {
+	// Arrange
+	j := new(replay.MockJobs)
+	j.On("Start", mockContext, replay.Request{Handler: "price-history"}).Return(replay.Progress{}, replay.ErrJobRunning)
+	ctx, _ := setup(http.MethodPost, StartReplayRequest{Handler: "price-history"})
+
+	// Act
+	err := StartReplay(j)(ctx)
+
+	// Assert
+	require.Error(t, err)
+	requireProblem(t, err, http.StatusConflict, replayRunningCode)
+}
+
+func TestGetReplay(t *testing.T) {
+	// Arrange
+	j := new(replay.MockJobs)
+	j.On("Get", mockContext, replayProgress.ID).Return(replayProgress, nil)
+	ctx, rec := setup(http.MethodGet, nil)
+	ctx.SetParamNames("jobId")
+	ctx.SetParamValues(replayProgress.ID.String())
+
+	// Act
+	err := GetReplay(j)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, mustEncodeJSON(replayProgress), rec.Body.String())
+}
+
+func TestGetReplayNotFound(t *testing.T) {
+	// Arrange
+	j := new(replay.MockJobs)
+	j.On("Get", mockContext, replayProgress.ID).Return(replay.Progress{}, replay.ErrJobNotFound)
+	ctx, _ := setup(http.MethodGet, nil)
+	ctx.SetParamNames("jobId")
+	ctx.SetParamValues(replayProgress.ID.String())
+
+	// Act
+	err := GetReplay(j)(ctx)
+
+	// Assert
+	require.Error(t, err)
+	requireProblem(t, err, http.StatusNotFound, replayNotFoundCode)
+}
+
+func TestCancelReplay(t *testing.T) {
+	// Arrange
+	j := new(replay.MockJobs)
+	j.On("Cancel", mockContext, replayProgress.ID).Return(nil)
+	ctx, rec := setup(http.MethodDelete, nil)
+	ctx.SetParamNames("jobId")
+	ctx.SetParamValues(replayProgress.ID.String())
+
+	// Act
+	err := CancelReplay(j)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	j.AssertExpectations(t)
+}
@@ -2,47 +2,102 @@
 package handler
 
 import (
-	"errors"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 
+	"github.com/evleria/cats-app/internal/money"
 	"github.com/evleria/cats-app/internal/repository"
 	"github.com/evleria/cats-app/internal/repository/entities"
 	"github.com/evleria/cats-app/internal/service"
 )
 
-// GetAllCats fetches all entities from cats collection
-func GetAllCats(catsService service.Cats) echo.HandlerFunc {
+// dateLayout is a format of dates in requests and responses
+const dateLayout = "2006-01-02"
+
+// GetAllCats fetches all entities from cats collection matching query filters, prices are shown in currency query param if given
+func GetAllCats(catsService service.Cats, pricesService service.Prices) echo.HandlerFunc {
 	return func(ctx echo.Context) error {
-		cats, err := catsService.GetAll(ctx.Request().Context())
+		filter, err := parseFilter(ctx)
+		if err != nil {
+			return err
+		}
+		page, err := parsePage(ctx)
+		if err != nil {
+			return err
+		}
+
+		cats, err := catsService.GetAll(ctx.Request().Context(), filter, page)
+		if err != nil {
+			return err
+		}
+		displayed, err := mapDisplayedCats(ctx, pricesService, cats)
 		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			return err
 		}
 
-		response := GetAllCatsResponse(mapCats(cats))
+		response := GetAllCatsResponse(displayed)
 		return ctx.JSON(http.StatusOK, response)
 	}
 }
 
-// GetCat fetches a single cat from cats collection by ID
-func GetCat(catsService service.Cats) echo.HandlerFunc {
+// SearchCats performs full-text search over cats matching query filters, prices are shown in currency query param if given
+func SearchCats(catsService service.Cats, pricesService service.Prices) echo.HandlerFunc {
 	return func(ctx echo.Context) error {
-		idParam := ctx.Param("id")
-		id, err := uuid.Parse(idParam)
+		query := ctx.QueryParam("q")
+		if query == "" {
+			return invalidParam("q", "must not be empty")
+		}
+		filter, err := parseFilter(ctx)
+		if err != nil {
+			return err
+		}
+		page, err := parsePage(ctx)
+		if err != nil {
+			return err
+		}
+
+		results, err := catsService.Search(ctx.Request().Context(), query, filter, page)
+		if err != nil {
+			return err
+		}
+		cats := make([]entities.Cat, 0, len(results))
+		for _, result := range results {
+			cats = append(cats, result.Cat)
+		}
+		displayed, err := mapDisplayedCats(ctx, pricesService, cats)
 		if err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest)
+			return err
+		}
+
+		response := SearchCatsResponse(mapSearchResults(results, displayed))
+		return ctx.JSON(http.StatusOK, response)
+	}
+}
+
+// GetCat fetches a single cat from cats collection by ID, its price is shown in currency query param if given
+func GetCat(catsService service.Cats, pricesService service.Prices) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		id, err := parseID(ctx, "id")
+		if err != nil {
+			return err
 		}
 
 		cat, err := catsService.GetOne(ctx.Request().Context(), id)
-		if errors.Is(err, repository.ErrNotFound) {
-			return echo.NewHTTPError(http.StatusNotFound)
-		} else if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		if err != nil {
+			return err
+		}
+		displayed, err := mapDisplayedCats(ctx, pricesService, []entities.Cat{cat})
+		if err != nil {
+			return err
 		}
 
-		response := GetCatResponse(mapCat(cat))
+		response := GetCatResponse(displayed[0])
 		return ctx.JSON(http.StatusOK, response)
 	}
 }
@@ -53,12 +108,16 @@ func AddNewCat(catsService service.Cats) echo.HandlerFunc {
 		request := new(AddNewCatRequest)
 		err := ctx.Bind(request)
 		if err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			return err
+		}
+		cat, err := mapNewCat(*request, time.Now())
+		if err != nil {
+			return err
 		}
 
-		id, err := catsService.CreateNew(ctx.Request().Context(), request.Name, request.Color, request.Age, request.Price)
+		id, err := catsService.CreateNew(ctx.Request().Context(), cat)
 		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			return err
 		}
 
 		response := AddNewCatResponse{
@@ -71,14 +130,28 @@ func AddNewCat(catsService service.Cats) echo.HandlerFunc {
 // DeleteCat deletes a single cat from cats collection by ID
 func DeleteCat(catsService service.Cats) echo.HandlerFunc {
 	return func(ctx echo.Context) error {
-		idParam := ctx.Param("id")
-		id, err := uuid.Parse(idParam)
+		id, err := parseID(ctx, "id")
 		if err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest)
+			return err
 		}
 		err = catsService.Delete(ctx.Request().Context(), id)
 		if err != nil {
-			return echo.NewHTTPError(http.StatusNotFound)
+			return err
+		}
+		return ctx.NoContent(http.StatusOK)
+	}
+}
+
+// RecordView counts a view of a cat by a client showing it, views are a signal of pricing rules
+func RecordView(catsService service.Cats) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		id, err := parseID(ctx, "id")
+		if err != nil {
+			return err
+		}
+		err = catsService.RecordView(ctx.Request().Context(), id)
+		if err != nil {
+			return err
 		}
 		return ctx.NoContent(http.StatusOK)
 	}
@@ -87,51 +160,303 @@ func DeleteCat(catsService service.Cats) echo.HandlerFunc {
 // UpdatePrice updates price of a cat by id
 func UpdatePrice(catsService service.Cats) echo.HandlerFunc {
 	return func(ctx echo.Context) error {
-		idParam := ctx.Param("id")
-		id, err := uuid.Parse(idParam)
+		id, err := parseID(ctx, "id")
 		if err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest)
+			return err
 		}
 
 		request := new(UpdatePriceRequest)
 		err = ctx.Bind(request)
 		if err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			return err
 		}
-		err = catsService.UpdatePrice(ctx.Request().Context(), id, request.Price)
-		if errors.Is(err, repository.ErrNotFound) {
-			return echo.NewHTTPError(http.StatusNotFound)
-		} else if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		err = catsService.UpdatePrice(ctx.Request().Context(), id, unmapMoney(request.Price))
+		if err != nil {
+			return err
 		}
 		return ctx.NoContent(http.StatusOK)
 	}
 }
 
+// ReserveCat places a temporary hold on a cat by ID
+func ReserveCat(catsService service.Cats) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		id, err := parseID(ctx, "id")
+		if err != nil {
+			return err
+		}
+
+		reservation, err := catsService.Reserve(ctx.Request().Context(), id)
+		if err != nil {
+			return err
+		}
+
+		response := ReserveCatResponse{
+			ID:         reservation.ID.String(),
+			Price:      reservation.Price.Major(),
+			PriceMoney: mapMoney(reservation.Price),
+			ExpiresAt:  reservation.ExpiresAt,
+		}
+		return ctx.JSON(http.StatusCreated, response)
+	}
+}
+
+// CancelReservation releases a hold on a cat by cat ID and reservation ID
+func CancelReservation(catsService service.Cats) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		id, err := parseID(ctx, "id")
+		if err != nil {
+			return err
+		}
+		reservationID, err := parseID(ctx, "reservationId")
+		if err != nil {
+			return err
+		}
+
+		err = catsService.CancelReservation(ctx.Request().Context(), id, reservationID)
+		if err != nil {
+			return err
+		}
+		return ctx.NoContent(http.StatusOK)
+	}
+}
+
+// PurchaseCat sells a reserved cat at the price locked by reservation
+func PurchaseCat(catsService service.Cats) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		id, err := parseID(ctx, "id")
+		if err != nil {
+			return err
+		}
+
+		request := new(PurchaseCatRequest)
+		err = ctx.Bind(request)
+		if err != nil {
+			return err
+		}
+		reservationID, err := uuid.Parse(request.ReservationID)
+		if err != nil {
+			return invalidParam("reservationId", "must be a valid UUID")
+		}
+
+		sale, err := catsService.Purchase(ctx.Request().Context(), id, reservationID)
+		if err != nil {
+			return err
+		}
+
+		response := PurchaseCatResponse{
+			Price:      sale.Price.Major(),
+			PriceMoney: mapMoney(sale.Price),
+			SoldAt:     sale.SoldAt,
+		}
+		return ctx.JSON(http.StatusOK, response)
+	}
+}
+
+// parseID parses UUID path parameter
+func parseID(ctx echo.Context, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(ctx.Param(name))
+	if err != nil {
+		return uuid.Nil, invalidParam(name, "must be a valid UUID")
+	}
+	return id, nil
+}
+
+func parseFilter(ctx echo.Context) (repository.Filter, error) {
+	filter := repository.Filter{
+		Color:       ctx.QueryParam("color"),
+		Breed:       ctx.QueryParam("breed"),
+		Sex:         entities.Sex(ctx.QueryParam("sex")),
+		Status:      entities.Status(ctx.QueryParam("status")),
+		Tags:        ctx.QueryParams()["tag"],
+		Vaccination: ctx.QueryParam("vaccination"),
+	}
+	var err error
+	if filter.MinAge, err = parseOptionalInt(ctx, "minAge"); err != nil {
+		return filter, err
+	}
+	if filter.MaxAge, err = parseOptionalInt(ctx, "maxAge"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func parsePage(ctx echo.Context) (repository.Page, error) {
+	page := repository.Page{}
+	for name, target := range map[string]*int64{"limit": &page.Limit, "offset": &page.Offset} {
+		value, err := parseOptionalInt(ctx, name)
+		if err != nil {
+			return page, err
+		}
+		if value == nil {
+			continue
+		}
+		if *value < 0 {
+			return page, invalidParam(name, "must not be negative")
+		}
+		*target = int64(*value)
+	}
+	return page, nil
+}
+
+func parseOptionalInt(ctx echo.Context, name string) (*int, error) {
+	value := ctx.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, invalidParam(name, "must be an integer")
+	}
+	return &result, nil
+}
+
+func mapNewCat(request AddNewCatRequest, now time.Time) (entities.Cat, error) {
+	cat := entities.Cat{
+		Name:        request.Name,
+		Color:       request.Color,
+		Breed:       request.Breed,
+		Sex:         entities.Sex(request.Sex),
+		Age:         request.Age,
+		Description: request.Description,
+		Tags:        request.Tags,
+		Price:       unmapMoney(request.Price),
+	}
+	if cat.Sex != "" && cat.Sex != entities.SexMale && cat.Sex != entities.SexFemale {
+		return cat, invalidParam("sex", fmt.Sprintf("must be either %q or %q", entities.SexMale, entities.SexFemale))
+	}
+	if request.BirthDate != "" {
+		birthDate, err := time.Parse(dateLayout, request.BirthDate)
+		if err != nil {
+			return cat, invalidParam("birthDate", "must be a date formatted as YYYY-MM-DD")
+		}
+		if birthDate.After(now) {
+			return cat, invalidParam("birthDate", "must not be in the future")
+		}
+		cat.BirthDate = &birthDate
+	}
+	for i, vaccination := range request.Vaccinations {
+		date, err := time.Parse(dateLayout, vaccination.Date)
+		if err != nil {
+			return cat, invalidParam(fmt.Sprintf("vaccinations[%d].date", i), "must be a date formatted as YYYY-MM-DD")
+		}
+		v := entities.Vaccination{Name: vaccination.Name, Date: date}
+		if vaccination.ValidUntil != "" {
+			validUntil, err := time.Parse(dateLayout, vaccination.ValidUntil)
+			if err != nil {
+				return cat, invalidParam(fmt.Sprintf("vaccinations[%d].validUntil", i), "must be a date formatted as YYYY-MM-DD")
+			}
+			v.ValidUntil = &validUntil
+		}
+		cat.Vaccinations = append(cat.Vaccinations, v)
+	}
+	return cat, nil
+}
+
 func mapCat(cat entities.Cat) Cat {
-	return Cat{
-		ID:    cat.ID.String(),
-		Name:  cat.Name,
-		Color: cat.Color,
-		Age:   cat.Age,
-		Price: cat.Price,
+	result := Cat{
+		ID:           cat.ID.String(),
+		Name:         cat.Name,
+		Color:        cat.Color,
+		Breed:        cat.Breed,
+		Sex:          string(cat.Sex),
+		Age:          cat.AgeAt(time.Now()),
+		Description:  cat.Description,
+		Tags:         cat.Tags,
+		Vaccinations: mapVaccinations(cat.Vaccinations),
+		Photos:       mapPhotos(cat.ID, cat.Photos),
+		Price:        cat.Price.Major(),
+		PriceMoney:   mapMoney(cat.Price),
+		Status:       string(cat.Status),
 	}
+	if cat.BirthDate != nil {
+		result.BirthDate = cat.BirthDate.Format(dateLayout)
+	}
+	return result
 }
 
-func mapCats(cats []entities.Cat) []Cat {
-	result := make([]Cat, 0, len(cats))
+// mapDisplayedCats maps cats with prices in currency query param, prices they are sold for are shown as list prices then
+func mapDisplayedCats(ctx echo.Context, pricesService service.Prices, cats []entities.Cat) ([]Cat, error) {
+	prices := make([]money.Money, 0, len(cats))
 	for _, cat := range cats {
-		result = append(result, mapCat(cat))
+		prices = append(prices, cat.Price)
+	}
+	displayed, err := pricesService.Display(ctx.Request().Context(), prices, ctx.QueryParam("currency"))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Cat, 0, len(cats))
+	for i, cat := range cats {
+		c := mapCat(cat)
+		if displayed[i] != cat.Price {
+			listPrice := c.PriceMoney
+			c.ListPrice = &listPrice
+			c.Price = displayed[i].Major()
+			c.PriceMoney = mapMoney(displayed[i])
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+func mapSearchResults(results []service.SearchResult, cats []Cat) []SearchResult {
+	response := make([]SearchResult, 0, len(results))
+	for i, result := range results {
+		response = append(response, SearchResult{
+			Cat:        cats[i],
+			Score:      result.Score,
+			Highlights: result.Highlights,
+		})
+	}
+	return response
+}
+
+func mapVaccinations(vaccinations []entities.Vaccination) []Vaccination {
+	if len(vaccinations) == 0 {
+		return nil
+	}
+	result := make([]Vaccination, 0, len(vaccinations))
+	for _, vaccination := range vaccinations {
+		v := Vaccination{
+			Name: vaccination.Name,
+			Date: vaccination.Date.Format(dateLayout),
+		}
+		if vaccination.ValidUntil != nil {
+			v.ValidUntil = vaccination.ValidUntil.Format(dateLayout)
+		}
+		result = append(result, v)
 	}
 	return result
 }
 
-// AddNewCatRequest represents a request to add new cat
+func mapMoney(m money.Money) Money {
+	return Money{
+		Amount:   m.Amount,
+		Currency: m.Currency,
+	}
+}
+
+func unmapMoney(m Money) money.Money {
+	return money.Money{
+		Amount:   m.Amount,
+		Currency: m.Currency,
+	}
+}
+
+// AddNewCatRequest represents a request to add new cat.
+// Age is only used when birth date is unknown.
 type AddNewCatRequest struct {
-	Name  string  `json:"name"`
-	Color string  `json:"color"`
-	Age   int     `json:"age"`
-	Price float64 `json:"price"`
+	Name         string        `json:"name"`
+	Color        string        `json:"color"`
+	Age          int           `json:"age"`
+	Price        Money         `json:"price"`
+	Breed        string        `json:"breed"`
+	Sex          string        `json:"sex"`
+	BirthDate    string        `json:"birthDate"`
+	Description  string        `json:"description"`
+	Tags         []string      `json:"tags"`
+	Vaccinations []Vaccination `json:"vaccinations"`
 }
 
 // AddNewCatResponse represents a response to add new cat
@@ -142,19 +467,93 @@ type AddNewCatResponse struct {
 // GetAllCatsResponse represents a response to get all cats
 type GetAllCatsResponse []Cat
 
+// SearchCatsResponse represents a response to search cats
+type SearchCatsResponse []SearchResult
+
+// SearchResult represents a found cat with relevance score and highlighted snippets by field name
+type SearchResult struct {
+	Cat        Cat               `json:"cat"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
 // GetCatResponse represents a response to get a cat
 type GetCatResponse Cat
 
 // UpdatePriceRequest represents request to update price
 type UpdatePriceRequest struct {
-	Price float64 `json:"price"`
+	Price Money `json:"price"`
+}
+
+// ReserveCatResponse represents a response to reserve a cat, price is in major units as before multi-currency support
+type ReserveCatResponse struct {
+	ID         string    `json:"id"`
+	Price      float64   `json:"price"`
+	PriceMoney Money     `json:"priceMoney"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// PurchaseCatRequest represents a request to purchase a reserved cat
+type PurchaseCatRequest struct {
+	ReservationID string `json:"reservationId"`
+}
+
+// PurchaseCatResponse represents a response to purchase a cat, price is in major units as before multi-currency support
+type PurchaseCatResponse struct {
+	Price      float64   `json:"price"`
+	PriceMoney Money     `json:"priceMoney"`
+	SoldAt     time.Time `json:"soldAt"`
 }
 
-// Cat represents a cat
+// Cat represents a cat, price is shown in requested display currency, then list price is the one cat is sold for.
+// Price is in major units as before multi-currency support, price money holds the exact amount and its currency.
 type Cat struct {
-	ID    string  `json:"id"`
-	Name  string  `json:"name"`
-	Color string  `json:"color"`
-	Age   int     `json:"age"`
-	Price float64 `json:"price"`
+	ID           string        `json:"id"`
+	Name         string        `json:"name"`
+	Color        string        `json:"color"`
+	Breed        string        `json:"breed,omitempty"`
+	Sex          string        `json:"sex,omitempty"`
+	BirthDate    string        `json:"birthDate,omitempty"`
+	Age          int           `json:"age"`
+	Description  string        `json:"description,omitempty"`
+	Tags         []string      `json:"tags,omitempty"`
+	Vaccinations []Vaccination `json:"vaccinations,omitempty"`
+	Photos       []Photo       `json:"photos"`
+	Price        float64       `json:"price"`
+	PriceMoney   Money         `json:"priceMoney"`
+	ListPrice    *Money        `json:"listPrice,omitempty"`
+	Status       string        `json:"status"`
+}
+
+// Money represents an amount in minor units of an ISO 4217 currency, e.g. 999 USD is $9.99
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// UnmarshalJSON accepts a bare number too, it is a price in major units of default currency sent by legacy clients
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && (data[0] == '-' || (data[0] >= '0' && data[0] <= '9')) {
+		var major float64
+		if err := json.Unmarshal(data, &major); err != nil {
+			return err
+		}
+		legacy, err := money.FromMajor(major, money.DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		*m = mapMoney(legacy)
+		return nil
+	}
+
+	// fields are decoded by a type without this method, so that it does not call itself
+	type fields Money
+	return json.Unmarshal(data, (*fields)(m))
+}
+
+// Vaccination represents a single vaccination of a cat, dates are formatted as YYYY-MM-DD
+type Vaccination struct {
+	Name       string `json:"name"`
+	Date       string `json:"date"`
+	ValidUntil string `json:"validUntil,omitempty"`
 }
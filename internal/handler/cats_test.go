@@ -4,16 +4,19 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/evleria/cats-app/internal/money"
 	"github.com/evleria/cats-app/internal/repository"
 	"github.com/evleria/cats-app/internal/repository/entities"
 	"github.com/evleria/cats-app/internal/service"
@@ -27,45 +30,49 @@ var (
 		Name:  "Ms. Bella",
 		Color: "brown",
 		Age:   4,
-		Price: 9.99,
+		Price: money.Money{Amount: 999, Currency: "USD"},
 	}
 	zorro = entities.Cat{
 		ID:    uuid.New(),
 		Name:  "Mr. Zorro",
 		Color: "black",
 		Age:   8,
-		Price: 10.99,
+		Price: money.Money{Amount: 1099, Currency: "USD"},
 	}
-	cats = []entities.Cat{bella, zorro}
+	cats   = []entities.Cat{bella, zorro}
+	prices = service.NewPricesService(money.NewConverter(money.Rates{
+		Base:  "USD",
+		Rates: map[string]*big.Rat{"EUR": big.NewRat(92, 100)},
+	}))
 )
 
 func TestGetAllCats(t *testing.T) {
 	// Arrange
 	s := new(service.MockCats)
-	s.On("GetAll", mockContext).Return(cats, nil)
+	s.On("GetAll", mockContext, repository.Filter{}, repository.Page{}).Return(cats, nil)
 	ctx, rec := setup(http.MethodGet, nil)
 
 	// Act
-	err := GetAllCats(s)(ctx)
+	err := GetAllCats(s, prices)(ctx)
 
 	// Assert
 	require.NoError(t, err)
 	require.Equal(t, http.StatusOK, rec.Code)
-	require.Equal(t, mustEncodeJSON(mapCats(cats)), rec.Body.String())
+	require.Equal(t, mustEncodeJSON([]Cat{mapCat(bella), mapCat(zorro)}), rec.Body.String())
 }
 
 func TestGetAllCatsRepositoryFailed(t *testing.T) {
 	// Arrange
 	s := new(service.MockCats)
-	s.On("GetAll", mockContext).Return(nil, errSomeError)
+	s.On("GetAll", mockContext, repository.Filter{}, repository.Page{}).Return(nil, errSomeError)
 	ctx, _ := setup(http.MethodGet, nil)
 
 	// Act
-	err := GetAllCats(s)(ctx)
+	err := GetAllCats(s, prices)(ctx)
 
 	// Assert
 	require.Error(t, err)
-	require.Equal(t, echo.NewHTTPError(http.StatusInternalServerError, errSomeError.Error()), err)
+	requireProblem(t, err, http.StatusInternalServerError, internalErrorCode)
 }
 
 func TestGetCat(t *testing.T) {
@@ -78,7 +85,7 @@ func TestGetCat(t *testing.T) {
 	ctx.SetParamValues(id.String())
 
 	// Act
-	err := GetCat(s)(ctx)
+	err := GetCat(s, prices)(ctx)
 
 	// Assert
 	require.NoError(t, err)
@@ -86,6 +93,44 @@ func TestGetCat(t *testing.T) {
 	require.Equal(t, mustEncodeJSON(mapCat(bella)), rec.Body.String())
 }
 
+func TestGetCatInCurrency(t *testing.T) {
+	// Arrange
+	s := new(service.MockCats)
+	s.On("GetOne", mockContext, bella.ID).Return(bella, nil)
+	ctx, rec := setup(http.MethodGet, nil)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(bella.ID.String())
+	ctx.Request().URL.RawQuery = "currency=EUR"
+
+	// Act
+	err := GetCat(s, prices)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	var cat Cat
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &cat))
+	require.Equal(t, 9.19, cat.Price)
+	require.Equal(t, Money{Amount: 919, Currency: "EUR"}, cat.PriceMoney)
+	require.Equal(t, &Money{Amount: 999, Currency: "USD"}, cat.ListPrice)
+}
+
+func TestGetCatInCurrencyWithoutRate(t *testing.T) {
+	// Arrange
+	s := new(service.MockCats)
+	s.On("GetOne", mockContext, bella.ID).Return(bella, nil)
+	ctx, _ := setup(http.MethodGet, nil)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(bella.ID.String())
+	ctx.Request().URL.RawQuery = "currency=GBP"
+
+	// Act
+	err := GetCat(s, prices)(ctx)
+
+	// Assert
+	require.Error(t, err)
+	requireProblem(t, err, http.StatusUnprocessableEntity, service.ErrRateUnavailable.Code)
+}
+
 func TestGetCatMalformedId(t *testing.T) {
 	// Arrange
 	s := new(service.MockCats)
@@ -94,28 +139,28 @@ func TestGetCatMalformedId(t *testing.T) {
 	ctx.SetParamValues("malformed-uuid")
 
 	// Act
-	err := GetCat(s)(ctx)
+	err := GetCat(s, prices)(ctx)
 
 	// Assert
 	require.Error(t, err)
-	require.Equal(t, echo.NewHTTPError(http.StatusBadRequest), err)
+	requireProblem(t, err, http.StatusBadRequest, service.ValidationCode)
 }
 
 func TestGetCatNotFound(t *testing.T) {
 	// Arrange
 	s := new(service.MockCats)
 	id := uuid.New().String()
-	s.On("GetOne", mockContext, mock.AnythingOfType("uuid.UUID")).Return(entities.Cat{}, repository.ErrNotFound)
+	s.On("GetOne", mockContext, mock.AnythingOfType("uuid.UUID")).Return(entities.Cat{}, service.ErrCatNotFound)
 	ctx, _ := setup(http.MethodGet, nil)
 	ctx.SetParamNames("id")
 	ctx.SetParamValues(id)
 
 	// Act
-	err := GetCat(s)(ctx)
+	err := GetCat(s, prices)(ctx)
 
 	// Assert
 	require.Error(t, err)
-	require.Equal(t, echo.NewHTTPError(http.StatusNotFound), err)
+	requireProblem(t, err, http.StatusNotFound, service.ErrCatNotFound.Code)
 }
 
 func TestGetCatRepositoryFailed(t *testing.T) {
@@ -128,19 +173,19 @@ func TestGetCatRepositoryFailed(t *testing.T) {
 	ctx.SetParamValues(id)
 
 	// Act
-	err := GetCat(s)(ctx)
+	err := GetCat(s, prices)(ctx)
 
 	// Assert
 	require.Error(t, err)
-	require.Equal(t, echo.NewHTTPError(http.StatusInternalServerError, errSomeError.Error()), err)
+	requireProblem(t, err, http.StatusInternalServerError, internalErrorCode)
 }
 
 func TestAddNewCat(t *testing.T) {
 	// Arrange
 	s := new(service.MockCats)
-	req := AddNewCatRequest{"Mila", "black", 5, 7.99}
+	req := AddNewCatRequest{Name: "Mila", Color: "black", Age: 5, Price: Money{Amount: 799, Currency: "USD"}}
 	id := uuid.New()
-	s.On("CreateNew", mockContext, req.Name, req.Color, req.Age, req.Price).Return(id, nil)
+	s.On("CreateNew", mockContext, entities.Cat{Name: req.Name, Color: req.Color, Age: req.Age, Price: money.Money{Amount: 799, Currency: "USD"}}).Return(id, nil)
 	ctx, rec := setup(http.MethodPost, req)
 
 	// Act
@@ -152,11 +197,140 @@ func TestAddNewCat(t *testing.T) {
 	require.Equal(t, mustEncodeJSON(AddNewCatResponse{id.String()}), rec.Body.String())
 }
 
+func TestAddNewCatLegacyPrice(t *testing.T) {
+	// Arrange
+	s := new(service.MockCats)
+	id := uuid.New()
+	s.On("CreateNew", mockContext, entities.Cat{Name: "Mila", Color: "black", Price: money.Money{Amount: 799, Currency: money.DefaultCurrency}}).Return(id, nil)
+	ctx, rec := setup(http.MethodPost, map[string]interface{}{"name": "Mila", "color": "black", "price": 7.99})
+
+	// Act
+	err := AddNewCat(s)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, rec.Code)
+}
+
 func TestAddNewCatServiceFailed(t *testing.T) {
 	// Arrange
 	s := new(service.MockCats)
-	req := AddNewCatRequest{"Mila", "black", 5, 7.99}
-	s.On("CreateNew", mockContext, req.Name, req.Color, req.Age, req.Price).Return(nil, errSomeError)
+	req := AddNewCatRequest{Name: "Mila", Color: "black", Age: 5, Price: Money{Amount: 799, Currency: "USD"}}
+	s.On("CreateNew", mockContext, mock.AnythingOfType("entities.Cat")).Return(nil, errSomeError)
+	ctx, _ := setup(http.MethodPost, req)
+
+	// Act
+	err := AddNewCat(s)(ctx)
+
+	// Assert
+	require.Error(t, err)
+	requireProblem(t, err, http.StatusInternalServerError, internalErrorCode)
+}
+
+func TestGetAllCatsFiltered(t *testing.T) {
+	// Arrange
+	s := new(service.MockCats)
+	minAge := 2
+	filter := repository.Filter{Breed: "siamese", Sex: entities.SexFemale, Tags: []string{"calm", "indoor"}, MinAge: &minAge}
+	page := repository.Page{Limit: 10, Offset: 20}
+	s.On("GetAll", mockContext, filter, page).Return([]entities.Cat{bella}, nil)
+	ctx, rec := setup(http.MethodGet, nil)
+	ctx.Request().URL.RawQuery = "breed=siamese&sex=female&tag=calm&tag=indoor&minAge=2&limit=10&offset=20"
+
+	// Act
+	err := GetAllCats(s, prices)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, mustEncodeJSON([]Cat{mapCat(bella)}), rec.Body.String())
+}
+
+func TestGetAllCatsMalformedFilter(t *testing.T) {
+	// Arrange
+	s := new(service.MockCats)
+	ctx, _ := setup(http.MethodGet, nil)
+	ctx.Request().URL.RawQuery = "maxAge=old"
+
+	// Act
+	err := GetAllCats(s, prices)(ctx)
+
+	// Assert
+	require.Error(t, err)
+	requireProblem(t, err, http.StatusBadRequest, service.ValidationCode)
+}
+
+func TestSearchCats(t *testing.T) {
+	// Arrange
+	s := new(service.MockCats)
+	results := []service.SearchResult{{Cat: bella, Score: 1.5, Highlights: map[string]string{"name": "Ms. <em>Bella</em>"}}}
+	s.On("Search", mockContext, "bella", repository.Filter{Color: "brown"}, repository.Page{Limit: 5}).Return(results, nil)
+	ctx, rec := setup(http.MethodGet, nil)
+	ctx.Request().URL.RawQuery = "q=bella&color=brown&limit=5"
+
+	// Act
+	err := SearchCats(s, prices)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, mustEncodeJSON(mapSearchResults(results, []Cat{mapCat(bella)})), rec.Body.String())
+}
+
+func TestSearchCatsEmptyQuery(t *testing.T) {
+	// Arrange
+	s := new(service.MockCats)
+	ctx, _ := setup(http.MethodGet, nil)
+
+	// Act
+	err := SearchCats(s, prices)(ctx)
+
+	// Assert
+	require.Error(t, err)
+	requireProblem(t, err, http.StatusBadRequest, service.ValidationCode)
+}
+
+func TestAddNewCatWithProfile(t *testing.T) {
+	// Arrange
+	s := new(service.MockCats)
+	req := AddNewCatRequest{
+		Name:         "Mila",
+		Color:        "black",
+		Price:        Money{Amount: 799, Currency: "USD"},
+		Breed:        "bombay",
+		Sex:          "female",
+		BirthDate:    "2019-03-01",
+		Tags:         []string{"calm"},
+		Vaccinations: []Vaccination{{Name: "rabies", Date: "2020-04-01"}},
+	}
+	birthDate := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	expected := entities.Cat{
+		Name:         req.Name,
+		Color:        req.Color,
+		Price:        money.Money{Amount: 799, Currency: "USD"},
+		Breed:        req.Breed,
+		Sex:          entities.SexFemale,
+		BirthDate:    &birthDate,
+		Tags:         req.Tags,
+		Vaccinations: []entities.Vaccination{{Name: "rabies", Date: time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)}},
+	}
+	id := uuid.New()
+	s.On("CreateNew", mockContext, expected).Return(id, nil)
+	ctx, rec := setup(http.MethodPost, req)
+
+	// Act
+	err := AddNewCat(s)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.Equal(t, mustEncodeJSON(AddNewCatResponse{id.String()}), rec.Body.String())
+}
+
+func TestAddNewCatInvalidSex(t *testing.T) {
+	// Arrange
+	s := new(service.MockCats)
+	req := AddNewCatRequest{Name: "Mila", Sex: "unknown"}
 	ctx, _ := setup(http.MethodPost, req)
 
 	// Act
@@ -164,7 +338,7 @@ func TestAddNewCatServiceFailed(t *testing.T) {
 
 	// Assert
 	require.Error(t, err)
-	require.Equal(t, echo.NewHTTPError(http.StatusInternalServerError, errSomeError.Error()), err)
+	requireProblem(t, err, http.StatusBadRequest, service.ValidationCode)
 }
 
 func TestDeleteCat(t *testing.T) {
@@ -197,14 +371,14 @@ func TestDeleteCatFailed(t *testing.T) {
 
 	// Assert
 	require.Error(t, err)
-	require.Equal(t, echo.NewHTTPError(http.StatusBadRequest), err)
+	requireProblem(t, err, http.StatusBadRequest, service.ValidationCode)
 }
 
 func TestDeleteCatNotFound(t *testing.T) {
 	// Arrange
 	s := new(service.MockCats)
 	id := uuid.New().String()
-	s.On("Delete", mockContext, mock.AnythingOfType("uuid.UUID")).Return(repository.ErrNotFound)
+	s.On("Delete", mockContext, mock.AnythingOfType("uuid.UUID")).Return(service.ErrCatNotFound)
 	ctx, _ := setup(http.MethodDelete, nil)
 	ctx.SetParamNames("id")
 	ctx.SetParamValues(id)
@@ -214,15 +388,47 @@ func TestDeleteCatNotFound(t *testing.T) {
 
 	// Assert
 	require.Error(t, err)
-	require.Equal(t, echo.NewHTTPError(http.StatusNotFound), err)
+	requireProblem(t, err, http.StatusNotFound, service.ErrCatNotFound.Code)
+}
+
+func TestRecordView(t *testing.T) {
+	// Arrange
+	s := new(service.MockCats)
+	s.On("RecordView", mockContext, bella.ID).Return(nil)
+	ctx, rec := setup(http.MethodPost, nil)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(bella.ID.String())
+
+	// Act
+	err := RecordView(s)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, rec.Code)
+	s.AssertNotCalled(t, "GetOne", mock.Anything, mock.Anything)
+}
+
+func TestRecordViewNotFound(t *testing.T) {
+	// Arrange
+	s := new(service.MockCats)
+	s.On("RecordView", mockContext, mock.AnythingOfType("uuid.UUID")).Return(service.ErrCatNotFound)
+	ctx, _ := setup(http.MethodPost, nil)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(uuid.New().String())
+
+	// Act
+	err := RecordView(s)(ctx)
+
+	// Assert
+	requireProblem(t, err, http.StatusNotFound, service.ErrCatNotFound.Code)
 }
 
 func TestUpdatePrice(t *testing.T) {
 	// Arrange
 	s := new(service.MockCats)
 	id := bella.ID
-	req := UpdatePriceRequest{Price: 5.99}
-	s.On("UpdatePrice", mockContext, id, req.Price).Return(nil)
+	req := UpdatePriceRequest{Price: Money{Amount: 599, Currency: "USD"}}
+	s.On("UpdatePrice", mockContext, id, money.Money{Amount: 599, Currency: "USD"}).Return(nil)
 	ctx, rec := setup(http.MethodPut, req)
 	ctx.SetParamNames("id")
 	ctx.SetParamValues(id.String())
@@ -236,10 +442,47 @@ func TestUpdatePrice(t *testing.T) {
 	require.Equal(t, "", rec.Body.String())
 }
 
+func TestUpdatePriceLegacy(t *testing.T) {
+	// Arrange
+	s := new(service.MockCats)
+	id := bella.ID
+	s.On("UpdatePrice", mockContext, id, money.Money{Amount: 599, Currency: money.DefaultCurrency}).Return(nil)
+	ctx, rec := setup(http.MethodPut, map[string]interface{}{"price": 5.99})
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(id.String())
+
+	// Act
+	err := UpdatePrice(s)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestUpdatePriceNegative(t *testing.T) {
+	// Arrange
+	s := new(service.MockCats)
+	id := bella.ID
+	req := UpdatePriceRequest{Price: Money{Amount: -1, Currency: "USD"}}
+	validationErr := service.NewValidationError("price.amount", "must not be negative")
+	s.On("UpdatePrice", mockContext, id, money.Money{Amount: -1, Currency: "USD"}).Return(validationErr)
+	ctx, _ := setup(http.MethodPut, req)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(id.String())
+
+	// Act
+	err := UpdatePrice(s)(ctx)
+
+	// Assert
+	require.Error(t, err)
+	problem := requireProblem(t, err, http.StatusBadRequest, service.ValidationCode)
+	require.Equal(t, []InvalidParam{{Name: "price.amount", Reason: "must not be negative"}}, problem.InvalidParams)
+}
+
 func TestUpdatePriceMalformedId(t *testing.T) {
 	// Arrange
 	s := new(service.MockCats)
-	req := UpdatePriceRequest{Price: 5.99}
+	req := UpdatePriceRequest{Price: Money{Amount: 599, Currency: "USD"}}
 	ctx, _ := setup(http.MethodPut, req)
 	ctx.SetParamNames("id")
 	ctx.SetParamValues("malformed-uuid")
@@ -249,7 +492,111 @@ func TestUpdatePriceMalformedId(t *testing.T) {
 
 	// Assert
 	require.Error(t, err)
-	require.Equal(t, echo.NewHTTPError(http.StatusBadRequest), err)
+	requireProblem(t, err, http.StatusBadRequest, service.ValidationCode)
+}
+
+func TestReserveCat(t *testing.T) {
+	// Arrange
+	s := new(service.MockCats)
+	id := bella.ID
+	reservation := entities.Reservation{ID: uuid.New(), Price: bella.Price, ExpiresAt: time.Now().Add(time.Minute).UTC()}
+	s.On("Reserve", mockContext, id).Return(reservation, nil)
+	ctx, rec := setup(http.MethodPost, nil)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(id.String())
+
+	// Act
+	err := ReserveCat(s)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.Equal(t, mustEncodeJSON(ReserveCatResponse{reservation.ID.String(), 9.99, mapMoney(reservation.Price), reservation.ExpiresAt}), rec.Body.String())
+}
+
+func TestReserveCatNotAvailable(t *testing.T) {
+	// Arrange
+	s := new(service.MockCats)
+	id := bella.ID
+	s.On("Reserve", mockContext, id).Return(entities.Reservation{}, service.ErrCatNotAvailable)
+	ctx, _ := setup(http.MethodPost, nil)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(id.String())
+
+	// Act
+	err := ReserveCat(s)(ctx)
+
+	// Assert
+	require.Error(t, err)
+	requireProblem(t, err, http.StatusConflict, service.ErrCatNotAvailable.Code)
+}
+
+func TestCancelReservation(t *testing.T) {
+	// Arrange
+	s := new(service.MockCats)
+	id, reservationID := bella.ID, uuid.New()
+	s.On("CancelReservation", mockContext, id, reservationID).Return(nil)
+	ctx, rec := setup(http.MethodDelete, nil)
+	ctx.SetParamNames("id", "reservationId")
+	ctx.SetParamValues(id.String(), reservationID.String())
+
+	// Act
+	err := CancelReservation(s)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestPurchaseCat(t *testing.T) {
+	// Arrange
+	s := new(service.MockCats)
+	id, reservationID := bella.ID, uuid.New()
+	sale := entities.Sale{ReservationID: reservationID, Price: money.Money{Amount: 899, Currency: "USD"}, SoldAt: time.Now().UTC()}
+	s.On("Purchase", mockContext, id, reservationID).Return(sale, nil)
+	ctx, rec := setup(http.MethodPost, PurchaseCatRequest{ReservationID: reservationID.String()})
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(id.String())
+
+	// Act
+	err := PurchaseCat(s)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, mustEncodeJSON(PurchaseCatResponse{8.99, mapMoney(sale.Price), sale.SoldAt}), rec.Body.String())
+}
+
+func TestPurchaseCatReservationExpired(t *testing.T) {
+	// Arrange
+	s := new(service.MockCats)
+	id, reservationID := bella.ID, uuid.New()
+	s.On("Purchase", mockContext, id, reservationID).Return(entities.Sale{}, service.ErrReservationNotActive)
+	ctx, _ := setup(http.MethodPost, PurchaseCatRequest{ReservationID: reservationID.String()})
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(id.String())
+
+	// Act
+	err := PurchaseCat(s)(ctx)
+
+	// Assert
+	require.Error(t, err)
+	requireProblem(t, err, http.StatusConflict, service.ErrReservationNotActive.Code)
+}
+
+func TestPurchaseCatMalformedReservationId(t *testing.T) {
+	// Arrange
+	s := new(service.MockCats)
+	ctx, _ := setup(http.MethodPost, PurchaseCatRequest{ReservationID: "malformed-uuid"})
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(bella.ID.String())
+
+	// Act
+	err := PurchaseCat(s)(ctx)
+
+	// Assert
+	require.Error(t, err)
+	requireProblem(t, err, http.StatusBadRequest, service.ValidationCode)
 }
 
 func setup(method string, body interface{}) (echo.Context, *httptest.ResponseRecorder) {
@@ -263,6 +610,7 @@ func setup(method string, body interface{}) (echo.Context, *httptest.ResponseRec
 	}
 	recorder := httptest.NewRecorder()
 	e := echo.New()
+	e.HTTPErrorHandler = ErrorHandler
 	c := e.NewContext(request, recorder)
 	return c, recorder
 }
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/service"
+)
+
+const (
+	// MIMEApplicationProblemJSON is a content type of RFC 7807 problem details
+	MIMEApplicationProblemJSON = "application/problem+json"
+
+	// problemTypePrefix makes problem type URI out of error code
+	problemTypePrefix = "urn:cats-app:problem:"
+	// internalErrorCode is a code of all unexpected errors, their details are only logged
+	internalErrorCode = "internal_error"
+)
+
+// Problem is an RFC 7807 problem details body, Code is stable and meant to be matched by clients
+type Problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance,omitempty"`
+	Code          string         `json:"code"`
+	TraceID       string         `json:"traceId,omitempty"`
+	InvalidParams []InvalidParam `json:"invalidParams,omitempty"`
+}
+
+// InvalidParam describes why a request parameter is invalid
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// NewProblem creates a problem with given status and code, handlers may return it as an error
+func NewProblem(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   problemTypePrefix + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+	return p.Detail
+}
+
+// ErrorHandler writes any error returned by handlers or middlewares as problem details.
+// Unexpected errors are logged with trace ID and reported without details.
+func ErrorHandler(err error, ctx echo.Context) {
+	if ctx.Response().Committed {
+		return
+	}
+	ServeProblem(ctx.Response(), ctx.Request(), err)
+}
+
+// ServeProblem writes an error as problem details the same way ErrorHandler does,
+// handlers served outside of Echo routing, like REST gateway, report their errors with it.
+func ServeProblem(w http.ResponseWriter, r *http.Request, err error) {
+	problem := mapProblem(err)
+	problem.Instance = r.URL.Path
+	problem.TraceID = w.Header().Get(echo.HeaderXRequestID)
+	if problem.Status >= http.StatusInternalServerError {
+		logging.Errorf("%s %s failed, trace ID %q: %v\n", r.Method, problem.Instance, problem.TraceID, err)
+	}
+
+	if r.Method == http.MethodHead {
+		w.WriteHeader(problem.Status)
+		return
+	}
+	if err = writeProblem(w, problem); err != nil {
+		logging.Warnf("cannot write error response: %v\n", err)
+	}
+}
+
+func writeProblem(w http.ResponseWriter, problem *Problem) error {
+	body, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+	w.Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+	w.WriteHeader(problem.Status)
+	_, err = w.Write(body)
+	return err
+}
+
+func mapProblem(err error) *Problem {
+	var (
+		problem       *Problem
+		domainErr     *service.Error
+		validationErr *service.ValidationError
+		httpErr       *echo.HTTPError
+	)
+	switch {
+	case errors.As(err, &problem):
+		copied := *problem
+		return &copied
+	case errors.As(err, &domainErr):
+		return NewProblem(domainStatus(domainErr.Kind), domainErr.Code, domainErr.Message)
+	case errors.As(err, &validationErr):
+		problem = NewProblem(http.StatusBadRequest, service.ValidationCode, validationErr.Error())
+		problem.InvalidParams = []InvalidParam{{Name: validationErr.Field, Reason: validationErr.Message}}
+		return problem
+	case errors.As(err, &httpErr) && httpErr.Code < http.StatusInternalServerError:
+		detail, _ := httpErr.Message.(string)
+		if detail == http.StatusText(httpErr.Code) {
+			detail = ""
+		}
+		return NewProblem(httpErr.Code, statusCode(httpErr.Code), detail)
+	default:
+		return NewProblem(http.StatusInternalServerError, internalErrorCode, "")
+	}
+}
+
+func domainStatus(kind service.Kind) int {
+	switch kind {
+	case service.KindNotFound:
+		return http.StatusNotFound
+	case service.KindConflict:
+		return http.StatusConflict
+	case service.KindTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case service.KindUnsupported:
+		return http.StatusUnsupportedMediaType
+	case service.KindUnprocessable:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// statusCode makes an error code out of HTTP status, e.g. "method_not_allowed"
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
+
+// invalidParam reports malformed request parameter the same way as validation errors of usecases
+func invalidParam(name, reason string) error {
+	return service.NewValidationError(name, reason)
+}
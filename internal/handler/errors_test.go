@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/service"
+)
+
+func TestErrorHandlerDomainError(t *testing.T) {
+	// Arrange
+	ctx, rec := setup(http.MethodGet, nil)
+	ctx.Request().URL.Path = "/api/cats/42"
+	ctx.Response().Header().Set(echo.HeaderXRequestID, "trace-1")
+
+	// Act
+	ErrorHandler(service.ErrCatNotFound, ctx)
+
+	// Assert
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Equal(t, MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+	require.Equal(t, Problem{
+		Type:     "urn:cats-app:problem:cat_not_found",
+		Title:    "Not Found",
+		Status:   http.StatusNotFound,
+		Detail:   "cat is not found",
+		Instance: "/api/cats/42",
+		Code:     "cat_not_found",
+		TraceID:  "trace-1",
+	}, decodeProblem(t, rec))
+}
+
+func TestErrorHandlerHidesInternalError(t *testing.T) {
+	// Arrange
+	ctx, rec := setup(http.MethodGet, nil)
+
+	// Act
+	ErrorHandler(errors.New("connection(localhost:27017) incomplete read of message header"), ctx)
+
+	// Assert
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	problem := decodeProblem(t, rec)
+	require.Equal(t, internalErrorCode, problem.Code)
+	require.Empty(t, problem.Detail)
+	require.NotContains(t, rec.Body.String(), "27017")
+}
+
+func TestErrorHandlerHidesInternalHTTPError(t *testing.T) {
+	// Arrange
+	ctx, rec := setup(http.MethodGet, nil)
+
+	// Act
+	ErrorHandler(echo.NewHTTPError(http.StatusBadGateway, "upstream 10.0.0.7 refused"), ctx)
+
+	// Assert
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.NotContains(t, rec.Body.String(), "10.0.0.7")
+}
+
+func TestErrorHandlerValidationError(t *testing.T) {
+	// Arrange
+	ctx, rec := setup(http.MethodPost, nil)
+
+	// Act
+	ErrorHandler(service.NewValidationError("price", "must not be negative"), ctx)
+
+	// Assert
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	problem := decodeProblem(t, rec)
+	require.Equal(t, service.ValidationCode, problem.Code)
+	require.Equal(t, "price must not be negative", problem.Detail)
+	require.Equal(t, []InvalidParam{{Name: "price", Reason: "must not be negative"}}, problem.InvalidParams)
+}
+
+func TestErrorHandlerEchoError(t *testing.T) {
+	// Arrange
+	ctx, rec := setup(http.MethodPatch, nil)
+
+	// Act
+	ErrorHandler(echo.ErrMethodNotAllowed, ctx)
+
+	// Assert
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	problem := decodeProblem(t, rec)
+	require.Equal(t, "method_not_allowed", problem.Code)
+	require.Empty(t, problem.Detail)
+}
+
+func TestErrorHandlerHead(t *testing.T) {
+	// Arrange
+	ctx, rec := setup(http.MethodHead, nil)
+
+	// Act
+	ErrorHandler(service.ErrCatNotFound, ctx)
+
+	// Assert
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	require.Empty(t, rec.Body.String())
+}
+
+// requireProblem checks that err is reported to clients as a problem with given status and code
+func requireProblem(t *testing.T, err error, status int, code string) *Problem {
+	t.Helper()
+	require.Error(t, err)
+	problem := mapProblem(err)
+	require.Equal(t, status, problem.Status)
+	require.Equal(t, code, problem.Code)
+	return problem
+}
+
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) Problem {
+	t.Helper()
+	problem := Problem{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	return problem
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/repository/entities"
+)
+
+const (
+	// HeaderIdempotencyKey is a request header that identifies retries of the same request
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed is set on responses replayed from idempotency store
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	idempotencyKeyReusedCode     = "idempotency_key_reused"
+	idempotencyKeyInProgressCode = "idempotency_key_in_progress"
+
+	// idempotentBodyLimit is a size limit of request bodies read into memory to fingerprint them
+	idempotentBodyLimit = 1 << 20
+)
+
+// Idempotency stores the first response of a request with Idempotency-Key header and replays it for repeats.
+// Reusing a key for a different request results in 409 Conflict. Server errors are not stored, so they can be retried.
+// Bodies of such requests are limited to 1 MiB.
+func Idempotency(keys repository.IdempotencyKeys) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			key := ctx.Request().Header.Get(HeaderIdempotencyKey)
+			if key == "" {
+				return next(ctx)
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(ctx.Response(), ctx.Request().Body, idempotentBodyLimit))
+			if err != nil {
+				if len(body) == idempotentBodyLimit {
+					return echo.ErrStatusRequestEntityTooLarge
+				}
+				return err
+			}
+			ctx.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			scopedKey := "http:" + ctx.Request().Method + ":" + ctx.Request().URL.Path + ":" + key
+			record, acquired, err := keys.Acquire(ctx.Request().Context(), scopedKey, fingerprint(body))
+			if err != nil {
+				return err
+			}
+			if !acquired {
+				return replayResponse(ctx, record, fingerprint(body))
+			}
+
+			recorder := &responseRecorder{ResponseWriter: ctx.Response().Writer}
+			ctx.Response().Writer = recorder
+			if err := next(ctx); err != nil {
+				ctx.Error(err)
+			}
+
+			if ctx.Response().Status >= http.StatusInternalServerError {
+				return keys.Release(ctx.Request().Context(), scopedKey)
+			}
+			err = keys.Complete(ctx.Request().Context(), scopedKey, entities.IdempotencyRecord{
+				Fingerprint: record.Fingerprint,
+				StatusCode:  ctx.Response().Status,
+				ContentType: ctx.Response().Header().Get(echo.HeaderContentType),
+				Body:        recorder.body.Bytes(),
+			})
+			if err != nil {
+				// the response has already been sent, so the failure can only be logged
+				logging.Warnf("cannot store idempotent response for %s: %v\n", scopedKey, err)
+			}
+			return nil
+		}
+	}
+}
+
+func replayResponse(ctx echo.Context, record entities.IdempotencyRecord, fingerprint string) error {
+	if record.Fingerprint != fingerprint {
+		return NewProblem(http.StatusConflict, idempotencyKeyReusedCode, "idempotency key is already used for a different request")
+	}
+	if !record.Completed {
+		return NewProblem(http.StatusConflict, idempotencyKeyInProgressCode, "request with this idempotency key is in progress")
+	}
+
+	ctx.Response().Header().Set(HeaderIdempotentReplayed, "true")
+	if len(record.Body) == 0 {
+		return ctx.NoContent(record.StatusCode)
+	}
+	return ctx.Blob(record.StatusCode, record.ContentType, record.Body)
+}
+
+func fingerprint(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// responseRecorder copies written response body
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) Flush() {
+	r.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return r.ResponseWriter.(http.Hijacker).Hijack()
+}
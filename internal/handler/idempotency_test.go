@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/repository/entities"
+)
+
+func TestIdempotencyStoresFirstResponse(t *testing.T) {
+	// Arrange
+	keys := new(repository.MockIdempotencyKeys)
+	req := UpdatePriceRequest{Price: Money{Amount: 599, Currency: "USD"}}
+	hash := fingerprint([]byte(mustEncodeJSON(req)))
+	keys.On("Acquire", mockContext, "http:POST:/:key-1", hash).Return(entities.IdempotencyRecord{Fingerprint: hash}, true, nil)
+	keys.On("Complete", mockContext, "http:POST:/:key-1", entities.IdempotencyRecord{
+		Fingerprint: hash,
+		StatusCode:  http.StatusCreated,
+		ContentType: echo.MIMEApplicationJSONCharsetUTF8,
+		Body:        []byte(mustEncodeJSON(AddNewCatResponse{"created"})),
+	}).Return(nil)
+	ctx, rec := setup(http.MethodPost, req)
+	ctx.Request().Header.Set(HeaderIdempotencyKey, "key-1")
+
+	// Act
+	err := Idempotency(keys)(createdHandler)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	keys.AssertExpectations(t)
+}
+
+func TestIdempotencyRejectsLargeBody(t *testing.T) {
+	// Arrange
+	keys := new(repository.MockIdempotencyKeys)
+	ctx, _ := setup(http.MethodPost, strings.Repeat("a", idempotentBodyLimit))
+	ctx.Request().Header.Set(HeaderIdempotencyKey, "key-1")
+
+	// Act
+	err := Idempotency(keys)(createdHandler)(ctx)
+
+	// Assert
+	requireProblem(t, err, http.StatusRequestEntityTooLarge, "request_entity_too_large")
+	keys.AssertNotCalled(t, "Acquire", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestIdempotencyReplaysStoredResponse(t *testing.T) {
+	// Arrange
+	keys := new(repository.MockIdempotencyKeys)
+	req := UpdatePriceRequest{Price: Money{Amount: 599, Currency: "USD"}}
+	hash := fingerprint([]byte(mustEncodeJSON(req)))
+	stored := entities.IdempotencyRecord{
+		Fingerprint: hash,
+		Completed:   true,
+		StatusCode:  http.StatusCreated,
+		ContentType: echo.MIMEApplicationJSONCharsetUTF8,
+		Body:        []byte(mustEncodeJSON(AddNewCatResponse{"stored"})),
+	}
+	keys.On("Acquire", mockContext, mock.Anything, hash).Return(stored, false, nil)
+	ctx, rec := setup(http.MethodPost, req)
+	ctx.Request().Header.Set(HeaderIdempotencyKey, "key-1")
+
+	// Act
+	err := Idempotency(keys)(createdHandler)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.Equal(t, "true", rec.Header().Get(HeaderIdempotentReplayed))
+	require.Equal(t, string(stored.Body), rec.Body.String())
+}
+
+func TestIdempotencyConflictOnDifferentBody(t *testing.T) {
+	// Arrange
+	keys := new(repository.MockIdempotencyKeys)
+	stored := entities.IdempotencyRecord{Fingerprint: "other", Completed: true, StatusCode: http.StatusCreated}
+	keys.On("Acquire", mockContext, mock.Anything, mock.Anything).Return(stored, false, nil)
+	ctx, _ := setup(http.MethodPost, UpdatePriceRequest{Price: Money{Amount: 599, Currency: "USD"}})
+	ctx.Request().Header.Set(HeaderIdempotencyKey, "key-1")
+
+	// Act
+	err := Idempotency(keys)(createdHandler)(ctx)
+
+	// Assert
+	require.Error(t, err)
+	requireProblem(t, err, http.StatusConflict, idempotencyKeyReusedCode)
+}
+
+func TestIdempotencyReleasesKeyOnServerError(t *testing.T) {
+	// Arrange
+	keys := new(repository.MockIdempotencyKeys)
+	keys.On("Acquire", mockContext, mock.Anything, mock.Anything).Return(entities.IdempotencyRecord{}, true, nil)
+	keys.On("Release", mockContext, "http:POST:/:key-1").Return(nil)
+	ctx, rec := setup(http.MethodPost, UpdatePriceRequest{Price: Money{Amount: 599, Currency: "USD"}})
+	ctx.Request().Header.Set(HeaderIdempotencyKey, "key-1")
+	failing := func(echo.Context) error { return echo.NewHTTPError(http.StatusInternalServerError) }
+
+	// Act
+	err := Idempotency(keys)(failing)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	keys.AssertExpectations(t)
+}
+
+func createdHandler(ctx echo.Context) error {
+	return ctx.JSON(http.StatusCreated, AddNewCatResponse{"created"})
+}
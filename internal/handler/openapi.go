@@ -0,0 +1,48 @@
+package handler
+
+import (
+	_ "embed" // OpenAPI spec is embedded into binary
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GatewayPrefix is a path prefix of REST gateway routes generated from gRPC definitions
+const GatewayPrefix = "/v1/"
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// apiDocsPage renders Swagger UI for the spec, its assets are loaded from CDN
+const apiDocsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Cats API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@3.52.0/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@3.52.0/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = function () {
+      SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`
+
+// OpenAPISpec serves OpenAPI document describing all REST routes
+func OpenAPISpec() echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		return ctx.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, openAPISpec)
+	}
+}
+
+// APIDocs serves interactive documentation page for OpenAPI document
+func APIDocs() echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		return ctx.HTML(http.StatusOK, apiDocsPage)
+	}
+}
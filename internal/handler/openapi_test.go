@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/evleria/cats-app/internal/leader"
+	"github.com/evleria/cats-app/protocol/pb"
+)
+
+type spec struct {
+	Paths map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+var echoParam = regexp.MustCompile(`:(\w+)`)
+
+func TestOpenAPISpecMatchesRoutes(t *testing.T) {
+	// Arrange
+	e := echo.New()
+	RegisterRoutes(e, Dependencies{Electors: map[string]leader.Elector{"scheduler": new(leader.MockElector)}, Gateway: http.NotFoundHandler()})
+	s := spec{}
+	require.NoError(t, json.Unmarshal(openAPISpec, &s))
+
+	// Act
+	var routes []string
+	for _, route := range e.Routes() {
+		if route.Path == GatewayPrefix+"*" {
+			continue
+		}
+		routes = append(routes, route.Method+" "+echoParam.ReplaceAllString(route.Path, "{$1}"))
+	}
+	routes = append(routes, gatewayRoutes()...)
+
+	var documented []string
+	for path, operations := range s.Paths {
+		for method := range operations {
+			if method != "parameters" {
+				documented = append(documented, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+
+	// Assert
+	require.ElementsMatch(t, routes, documented)
+}
+
+func TestOpenAPISpecReferences(t *testing.T) {
+	// Arrange
+	document := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(openAPISpec, &document))
+
+	// Act
+	var refs []string
+	collectRefs(document, &refs)
+
+	// Assert
+	require.NotEmpty(t, refs)
+	for _, ref := range refs {
+		var target interface{} = document
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			node, ok := target.(map[string]interface{})
+			require.True(t, ok, ref)
+			target, ok = node[part]
+			require.True(t, ok, "unresolved reference %s", ref)
+		}
+	}
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	// Arrange
+	ctx, rec := setup(http.MethodGet, nil)
+
+	// Act
+	err := OpenAPISpec()(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, string(openAPISpec), rec.Body.String())
+}
+
+// gatewayRoutes lists routes served by REST gateway, as defined by HTTP annotations of RPCs
+func gatewayRoutes() []string {
+	var routes []string
+	for _, file := range []protoreflect.FileDescriptor{pb.File_cats_service_proto, pb.File_price_schedules_service_proto} {
+		services := file.Services()
+		for i := 0; i < services.Len(); i++ {
+			methods := services.Get(i).Methods()
+			for j := 0; j < methods.Len(); j++ {
+				rule, ok := proto.GetExtension(methods.Get(j).Options(), annotations.E_Http).(*annotations.HttpRule)
+				if !ok || rule == nil {
+					continue
+				}
+				switch pattern := rule.Pattern.(type) {
+				case *annotations.HttpRule_Get:
+					routes = append(routes, http.MethodGet+" "+pattern.Get)
+				case *annotations.HttpRule_Post:
+					routes = append(routes, http.MethodPost+" "+pattern.Post)
+				case *annotations.HttpRule_Put:
+					routes = append(routes, http.MethodPut+" "+pattern.Put)
+				case *annotations.HttpRule_Delete:
+					routes = append(routes, http.MethodDelete+" "+pattern.Delete)
+				case *annotations.HttpRule_Patch:
+					routes = append(routes, http.MethodPatch+" "+pattern.Patch)
+				}
+			}
+		}
+	}
+	return routes
+}
+
+func collectRefs(node interface{}, refs *[]string) {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if ref, ok := child.(string); ok && key == "$ref" {
+				*refs = append(*refs, ref)
+			}
+			collectRefs(child, refs)
+		}
+	case []interface{}:
+		for _, child := range value {
+			collectRefs(child, refs)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/service"
+)
+
+// UploadPhoto stores a photo sent as multipart form field "photo" and attaches it to a cat
+func UploadPhoto(photosService service.Photos, maxSize int64) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		catID, err := parseID(ctx, "id")
+		if err != nil {
+			return err
+		}
+
+		file, err := ctx.FormFile("photo")
+		if err != nil {
+			return invalidParam("photo", "must be a file sent as multipart form field")
+		}
+		if file.Size > maxSize {
+			return service.ErrPhotoTooLarge
+		}
+		src, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer src.Close() //nolint:errcheck,gocritic
+
+		data, err := io.ReadAll(io.LimitReader(src, maxSize+1))
+		if err != nil {
+			return err
+		}
+
+		photo, err := photosService.Upload(ctx.Request().Context(), catID, data)
+		if err != nil {
+			return err
+		}
+
+		return ctx.JSON(http.StatusCreated, mapPhoto(catID, photo))
+	}
+}
+
+// GetPhotos fetches references to all photos of a cat
+func GetPhotos(photosService service.Photos) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		catID, err := parseID(ctx, "id")
+		if err != nil {
+			return err
+		}
+
+		photos, err := photosService.GetAll(ctx.Request().Context(), catID)
+		if err != nil {
+			return err
+		}
+
+		response := GetPhotosResponse(mapPhotos(catID, photos))
+		return ctx.JSON(http.StatusOK, response)
+	}
+}
+
+// GetPhoto serves photo content, supports range requests and "thumbnail" query parameter
+func GetPhoto(photosService service.Photos) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		catID, err := parseID(ctx, "id")
+		if err != nil {
+			return err
+		}
+		photoID, err := parseID(ctx, "photoId")
+		if err != nil {
+			return err
+		}
+		thumbnail := ctx.QueryParam("thumbnail") == "true"
+
+		photo, content, err := photosService.GetOne(ctx.Request().Context(), catID, photoID, thumbnail)
+		if err != nil {
+			return err
+		}
+		defer content.Close() //nolint:errcheck,gocritic
+
+		ctx.Response().Header().Set(echo.HeaderContentType, photo.ContentType)
+		http.ServeContent(ctx.Response(), ctx.Request(), "", photo.UploadedAt, content)
+		return nil
+	}
+}
+
+// DeletePhoto removes a photo and its thumbnail
+func DeletePhoto(photosService service.Photos) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		catID, err := parseID(ctx, "id")
+		if err != nil {
+			return err
+		}
+		photoID, err := parseID(ctx, "photoId")
+		if err != nil {
+			return err
+		}
+
+		err = photosService.Delete(ctx.Request().Context(), catID, photoID)
+		if err != nil {
+			return err
+		}
+		return ctx.NoContent(http.StatusOK)
+	}
+}
+
+func mapPhoto(catID uuid.UUID, photo entities.Photo) Photo {
+	url := fmt.Sprintf("/api/cats/%s/photos/%s", catID, photo.ID)
+	return Photo{
+		ID:           photo.ID.String(),
+		ContentType:  photo.ContentType,
+		Size:         photo.Size,
+		URL:          url,
+		ThumbnailURL: url + "?thumbnail=true",
+		UploadedAt:   photo.UploadedAt,
+	}
+}
+
+func mapPhotos(catID uuid.UUID, photos []entities.Photo) []Photo {
+	result := make([]Photo, 0, len(photos))
+	for _, photo := range photos {
+		result = append(result, mapPhoto(catID, photo))
+	}
+	return result
+}
+
+// GetPhotosResponse represents a response to get all photos of a cat
+type GetPhotosResponse []Photo
+
+// Photo represents a reference to a cat photo
+type Photo struct {
+	ID           string    `json:"id"`
+	ContentType  string    `json:"contentType"`
+	Size         int64     `json:"size"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnailUrl"`
+	UploadedAt   time.Time `json:"uploadedAt"`
+}
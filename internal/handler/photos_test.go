@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/service"
+)
+
+var (
+	photo = entities.Photo{
+		ID:          uuid.New(),
+		ThumbnailID: uuid.New(),
+		ContentType: "image/png",
+		Size:        10,
+		UploadedAt:  time.Date(2021, 8, 1, 12, 0, 0, 0, time.UTC),
+	}
+	photoData = []byte("0123456789")
+)
+
+func TestUploadPhoto(t *testing.T) {
+	// Arrange
+	s := new(service.MockPhotos)
+	s.On("Upload", mockContext, bella.ID, photoData).Return(photo, nil)
+	ctx, rec := setupMultipart(photoData)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(bella.ID.String())
+
+	// Act
+	err := UploadPhoto(s, 1024)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.Equal(t, mustEncodeJSON(mapPhoto(bella.ID, photo)), rec.Body.String())
+}
+
+func TestUploadPhotoTooLarge(t *testing.T) {
+	// Arrange
+	s := new(service.MockPhotos)
+	ctx, _ := setupMultipart(photoData)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(bella.ID.String())
+
+	// Act
+	err := UploadPhoto(s, 5)(ctx)
+
+	// Assert
+	require.Error(t, err)
+	requireProblem(t, err, http.StatusRequestEntityTooLarge, service.ErrPhotoTooLarge.Code)
+}
+
+func TestUploadPhotoUnsupportedType(t *testing.T) {
+	// Arrange
+	s := new(service.MockPhotos)
+	s.On("Upload", mockContext, bella.ID, photoData).Return(entities.Photo{}, service.ErrUnsupportedPhotoType)
+	ctx, _ := setupMultipart(photoData)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(bella.ID.String())
+
+	// Act
+	err := UploadPhoto(s, 1024)(ctx)
+
+	// Assert
+	require.Error(t, err)
+	requireProblem(t, err, http.StatusUnsupportedMediaType, service.ErrUnsupportedPhotoType.Code)
+}
+
+func TestGetPhotoRange(t *testing.T) {
+	// Arrange
+	s := new(service.MockPhotos)
+	s.On("GetOne", mockContext, bella.ID, photo.ID, false).Return(photo, photoContent(), nil)
+	ctx, rec := setup(http.MethodGet, nil)
+	ctx.Request().Header.Set("Range", "bytes=2-5")
+	ctx.SetParamNames("id", "photoId")
+	ctx.SetParamValues(bella.ID.String(), photo.ID.String())
+
+	// Act
+	err := GetPhoto(s)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusPartialContent, rec.Code)
+	require.Equal(t, "image/png", rec.Header().Get(echo.HeaderContentType))
+	require.Equal(t, "2345", rec.Body.String())
+}
+
+func TestGetPhotoThumbnail(t *testing.T) {
+	// Arrange
+	s := new(service.MockPhotos)
+	s.On("GetOne", mockContext, bella.ID, photo.ID, true).Return(photo, photoContent(), nil)
+	ctx, rec := setup(http.MethodGet, nil)
+	ctx.Request().URL.RawQuery = "thumbnail=true"
+	ctx.SetParamNames("id", "photoId")
+	ctx.SetParamValues(bella.ID.String(), photo.ID.String())
+
+	// Act
+	err := GetPhoto(s)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, string(photoData), rec.Body.String())
+}
+
+func TestDeletePhotoNotFound(t *testing.T) {
+	// Arrange
+	s := new(service.MockPhotos)
+	s.On("Delete", mockContext, bella.ID, photo.ID).Return(service.ErrPhotoNotFound)
+	ctx, _ := setup(http.MethodDelete, nil)
+	ctx.SetParamNames("id", "photoId")
+	ctx.SetParamValues(bella.ID.String(), photo.ID.String())
+
+	// Act
+	err := DeletePhoto(s)(ctx)
+
+	// Assert
+	require.Error(t, err)
+	requireProblem(t, err, http.StatusNotFound, service.ErrPhotoNotFound.Code)
+}
+
+func setupMultipart(data []byte) (echo.Context, *httptest.ResponseRecorder) {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("photo", "photo.png")
+	if err != nil {
+		panic(err)
+	}
+	if _, err := part.Write(data); err != nil {
+		panic(err)
+	}
+	if err := writer.Close(); err != nil {
+		panic(err)
+	}
+
+	request := httptest.NewRequest(http.MethodPost, "/", body)
+	request.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+	recorder := httptest.NewRecorder()
+	return echo.New().NewContext(request, recorder), recorder
+}
+
+// photoContent opens photoData the way photos service opens stored files
+func photoContent() io.ReadSeekCloser {
+	return nopSeekCloser{bytes.NewReader(photoData)}
+}
+
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error {
+	return nil
+}
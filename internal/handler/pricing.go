@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/evleria/cats-app/internal/service"
+)
+
+// PreviewPricing shows prices pricing rules would give to cats matching filter query params and why, nothing is changed
+func PreviewPricing(pricingService service.Pricing) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		filter, err := parseFilter(ctx)
+		if err != nil {
+			return err
+		}
+
+		changes, err := pricingService.Preview(ctx.Request().Context(), filter)
+		if err != nil {
+			return err
+		}
+
+		response := make(PreviewPricingResponse, 0, len(changes))
+		for _, change := range changes {
+			response = append(response, mapPriceChange(change))
+		}
+		return ctx.JSON(http.StatusOK, response)
+	}
+}
+
+func mapPriceChange(change service.PriceChange) PriceChange {
+	return PriceChange{
+		CatID:        change.Cat.ID.String(),
+		Name:         change.Cat.Name,
+		Age:          change.Facts.Age,
+		DaysListed:   change.Facts.DaysListed,
+		Views:        change.Facts.Views,
+		Tags:         change.Facts.Tags,
+		CurrentPrice: mapMoney(change.Cat.Price),
+		BasePrice:    mapMoney(change.BasePrice),
+		Price:        mapMoney(change.Price),
+		Rule:         change.Rule,
+		Automatic:    change.Automatic,
+		Reasons:      change.Reasons,
+	}
+}
+
+// PreviewPricingResponse represents a response to preview pricing rules
+type PreviewPricingResponse []PriceChange
+
+// PriceChange represents a price pricing rules would give a cat, facts rules are matched against and reasons of the price.
+// Automatic prices are set on the next run of rules, others are only suggested.
+type PriceChange struct {
+	CatID        string   `json:"catId"`
+	Name         string   `json:"name"`
+	Age          int      `json:"age"`
+	DaysListed   int      `json:"daysListed"`
+	Views        int64    `json:"views"`
+	Tags         []string `json:"tags,omitempty"`
+	CurrentPrice Money    `json:"currentPrice"`
+	BasePrice    Money    `json:"basePrice"`
+	Price        Money    `json:"price"`
+	Rule         string   `json:"rule,omitempty"`
+	Automatic    bool     `json:"automatic"`
+	Reasons      []string `json:"reasons"`
+}
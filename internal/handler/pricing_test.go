@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/internal/pricing"
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/service"
+)
+
+func TestPreviewPricing(t *testing.T) {
+	// Arrange
+	s := new(service.MockPricing)
+	change := service.PriceChange{
+		Cat:       bella,
+		Facts:     pricing.Facts{Age: 3, DaysListed: 45, Views: 12},
+		BasePrice: bella.Price,
+		Price:     money.Money{Amount: 799, Currency: "USD"},
+		Rule:      "stale",
+		Automatic: true,
+		Reasons:   []string{"listed for 45 days, at least 30", "rule stale adjusts base price by -20%"},
+	}
+	s.On("Preview", mockContext, repository.Filter{Color: "black"}).Return([]service.PriceChange{change}, nil)
+	ctx, rec := setup(http.MethodGet, nil)
+	ctx.QueryParams().Set("color", "black")
+
+	// Act
+	err := PreviewPricing(s)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, mustEncodeJSON(PreviewPricingResponse{mapPriceChange(change)}), rec.Body.String())
+}
+
+func TestPreviewPricingMalformedFilter(t *testing.T) {
+	// Arrange
+	s := new(service.MockPricing)
+	ctx, _ := setup(http.MethodGet, nil)
+	ctx.QueryParams().Set("minAge", "old")
+
+	// Act
+	err := PreviewPricing(s)(ctx)
+
+	// Assert
+	requireProblem(t, err, http.StatusBadRequest, service.ValidationCode)
+}
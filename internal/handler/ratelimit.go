@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/ratelimit"
+)
+
+const (
+	// HeaderAPIKey identifies a client for rate limiting, clients without a configured key are identified by IP address
+	HeaderAPIKey = "X-API-Key"
+	// HeaderRateLimitLimit is a size of client's bucket
+	HeaderRateLimitLimit = "RateLimit-Limit"
+	// HeaderRateLimitRemaining is a number of requests client may send right away
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	// HeaderRateLimitReset is a number of seconds until client's bucket is full again
+	HeaderRateLimitReset = "RateLimit-Reset"
+	// HeaderRetryAfter is a number of seconds client should wait before retrying rejected request
+	HeaderRetryAfter = "Retry-After"
+
+	rateLimitedCode = "rate_limited"
+)
+
+// RateLimit takes a token from client's bucket of a route class on every request and rejects requests with 429
+// when the bucket is empty. Gateway routes are skipped, as they are limited by gRPC server they proxy to.
+// Requests are let through if limiter fails, so rate limiting never takes the API down.
+func RateLimit(limiter ratelimit.Limiter, rules *ratelimit.Rules) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if strings.HasPrefix(ctx.Path(), GatewayPrefix) {
+				return next(ctx)
+			}
+			class := ratelimit.ClassOfMethod(ctx.Request().Method)
+			limit, ok := rules.Get(class)
+			if !ok {
+				return next(ctx)
+			}
+
+			client := rules.ClientKey(ctx.Request().Header.Get(HeaderAPIKey), ctx.RealIP())
+			result, err := limiter.Allow(ctx.Request().Context(), ratelimit.BucketKey(class, client), limit)
+			if err != nil {
+				logging.Warnf("cannot check rate limit of %s: %v\n", client, err)
+				return next(ctx)
+			}
+
+			header := ctx.Response().Header()
+			header.Set(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+			header.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+			header.Set(HeaderRateLimitReset, strconv.FormatInt(ratelimit.Seconds(result.Reset), 10))
+			if !result.Allowed {
+				header.Set(HeaderRetryAfter, strconv.FormatInt(ratelimit.Seconds(result.RetryAfter), 10))
+				return NewProblem(http.StatusTooManyRequests, rateLimitedCode, "rate limit of "+string(class)+" requests is exceeded")
+			}
+			return next(ctx)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/ratelimit"
+)
+
+var writeLimit = ratelimit.Limit{Rate: 1, Burst: 10}
+
+func TestRateLimitAllowed(t *testing.T) {
+	// Arrange
+	l := new(ratelimit.MockLimiter)
+	l.On("Allow", mockContext, "write:ip:192.0.2.1", writeLimit).
+		Return(ratelimit.Result{Allowed: true, Limit: 10, Remaining: 9, Reset: 1500 * time.Millisecond}, nil)
+	ctx, rec := setup(http.MethodPost, nil)
+
+	// Act
+	err := RateLimit(l, rateLimits())(createdHandler)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.Equal(t, "10", rec.Header().Get(HeaderRateLimitLimit))
+	require.Equal(t, "9", rec.Header().Get(HeaderRateLimitRemaining))
+	require.Equal(t, "2", rec.Header().Get(HeaderRateLimitReset))
+}
+
+func TestRateLimitExceeded(t *testing.T) {
+	// Arrange
+	l := new(ratelimit.MockLimiter)
+	l.On("Allow", mockContext, mock.MatchedBy(func(key string) bool { return key != "write:ip:192.0.2.1" }), writeLimit).
+		Return(ratelimit.Result{Limit: 10, Reset: 10 * time.Second, RetryAfter: 300 * time.Millisecond}, nil)
+	ctx, rec := setup(http.MethodPost, nil)
+	ctx.Request().Header.Set(HeaderAPIKey, "client-key")
+
+	// Act
+	err := RateLimit(l, rateLimits())(createdHandler)(ctx)
+
+	// Assert
+	requireProblem(t, err, http.StatusTooManyRequests, rateLimitedCode)
+	require.Equal(t, "0", rec.Header().Get(HeaderRateLimitRemaining))
+	require.Equal(t, "1", rec.Header().Get(HeaderRetryAfter))
+}
+
+func TestRateLimitUnknownAPIKey(t *testing.T) {
+	// Arrange
+	l := new(ratelimit.MockLimiter)
+	l.On("Allow", mockContext, "write:ip:192.0.2.1", writeLimit).Return(ratelimit.Result{Allowed: true, Limit: 10}, nil)
+	ctx, rec := setup(http.MethodPost, nil)
+	ctx.Request().Header.Set(HeaderAPIKey, "made-up-key")
+
+	// Act
+	err := RateLimit(l, rateLimits())(createdHandler)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestRateLimitUnlimitedClass(t *testing.T) {
+	// Arrange
+	l := new(ratelimit.MockLimiter)
+	ctx, rec := setup(http.MethodGet, nil)
+
+	// Act
+	err := RateLimit(l, rateLimits())(createdHandler)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	l.AssertNotCalled(t, "Allow", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRateLimitSkipsGateway(t *testing.T) {
+	// Arrange
+	l := new(ratelimit.MockLimiter)
+	ctx, _ := setup(http.MethodPost, nil)
+	ctx.SetPath(GatewayPrefix + "*")
+
+	// Act
+	err := RateLimit(l, rateLimits())(createdHandler)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	l.AssertNotCalled(t, "Allow", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRateLimitFailsOpen(t *testing.T) {
+	// Arrange
+	l := new(ratelimit.MockLimiter)
+	l.On("Allow", mockContext, mock.Anything, writeLimit).Return(ratelimit.Result{}, errors.New("redis is down"))
+	ctx, rec := setup(http.MethodPost, nil)
+
+	// Act
+	err := RateLimit(l, rateLimits())(func(ctx echo.Context) error { return ctx.NoContent(http.StatusOK) })(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func rateLimits() *ratelimit.Rules {
+	rules := ratelimit.NewRules(map[ratelimit.Class]ratelimit.Limit{ratelimit.ClassWrite: writeLimit})
+	rules.SetAPIKeys([]string{"client-key"})
+	return rules
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+
+	"github.com/evleria/cats-app/internal/leader"
+	"github.com/evleria/cats-app/internal/ratelimit"
+	"github.com/evleria/cats-app/internal/replay"
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/service"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+// multipartOverhead is a room for multipart headers and boundaries above photo size limit
+const multipartOverhead = 1 << 20
+
+// Dependencies are services routes are served by, Electors, Gateway and RateLimiter are optional.
+// Tenants resolve tenant of cats and replay routes, gateway routes are resolved by gRPC server they proxy to.
+// AdminToken authenticates admin routes, all of them are rejected without it.
+type Dependencies struct {
+	Tenants         *tenant.Resolver
+	Cats            service.Cats
+	Prices          service.Prices
+	Photos          service.Photos
+	Schedules       service.Schedules
+	Pricing         service.Pricing
+	IdempotencyKeys repository.IdempotencyKeys
+	ReplayJobs      replay.Jobs
+	Electors        map[string]leader.Elector
+	Gateway         http.Handler
+	RateLimiter     ratelimit.Limiter
+	RateLimits      *ratelimit.Rules
+	PhotoMaxSize    int64
+	AdminToken      string
+}
+
+// RegisterRoutes registers all REST routes, each of them must be described in OpenAPI spec
+func RegisterRoutes(e *echo.Echo, deps Dependencies) {
+	if deps.RateLimiter != nil {
+		e.Use(RateLimit(deps.RateLimiter, deps.RateLimits))
+	}
+	idempotency := Idempotency(deps.IdempotencyKeys)
+	// tenant is resolved first on every route, so idempotency keys are scoped by it too.
+	// It is not a group middleware, as those make echo register catch-all routes of the group.
+	tenantScoped := Tenant(deps.Tenants)
+
+	catsGroup := e.Group("/api/cats")
+	catsGroup.GET("", GetAllCats(deps.Cats, deps.Prices), tenantScoped)
+	catsGroup.GET("/search", SearchCats(deps.Cats, deps.Prices), tenantScoped)
+	catsGroup.GET("/:id", GetCat(deps.Cats, deps.Prices), tenantScoped)
+	catsGroup.POST("", AddNewCat(deps.Cats), tenantScoped, idempotency)
+	catsGroup.PUT("/:id/price", UpdatePrice(deps.Cats), tenantScoped, idempotency)
+	catsGroup.DELETE("/:id", DeleteCat(deps.Cats), tenantScoped)
+	catsGroup.POST("/:id/reservation", ReserveCat(deps.Cats), tenantScoped, idempotency)
+	catsGroup.DELETE("/:id/reservation/:reservationId", CancelReservation(deps.Cats), tenantScoped)
+	catsGroup.POST("/:id/purchase", PurchaseCat(deps.Cats), tenantScoped, idempotency)
+	catsGroup.POST("/:id/views", RecordView(deps.Cats), tenantScoped)
+	photoBodyLimit := middleware.BodyLimit(strconv.FormatInt(deps.PhotoMaxSize+multipartOverhead, 10))
+	catsGroup.POST("/:id/photos", UploadPhoto(deps.Photos, deps.PhotoMaxSize), tenantScoped, photoBodyLimit)
+	catsGroup.GET("/:id/photos", GetPhotos(deps.Photos), tenantScoped)
+	catsGroup.GET("/:id/photos/:photoId", GetPhoto(deps.Photos), tenantScoped)
+	catsGroup.DELETE("/:id/photos/:photoId", DeletePhoto(deps.Photos), tenantScoped)
+
+	schedulesGroup := e.Group("/api/schedules")
+	schedulesGroup.GET("", GetAllSchedules(deps.Schedules), tenantScoped)
+	schedulesGroup.GET("/:id", GetSchedule(deps.Schedules), tenantScoped)
+	schedulesGroup.POST("", AddSchedule(deps.Schedules), tenantScoped, idempotency)
+	schedulesGroup.PUT("/:id", UpdateSchedule(deps.Schedules), tenantScoped)
+	schedulesGroup.DELETE("/:id", DeleteSchedule(deps.Schedules), tenantScoped)
+
+	pricingGroup := e.Group("/api/pricing")
+	pricingGroup.GET("/preview", PreviewPricing(deps.Pricing), tenantScoped)
+
+	adminGroup := e.Group("/admin")
+	adminAuth := AdminAuth(deps.AdminToken)
+	adminGroup.POST("/replay", StartReplay(deps.ReplayJobs), adminAuth, tenantScoped)
+	adminGroup.GET("/replay/:jobId", GetReplay(deps.ReplayJobs), adminAuth, tenantScoped)
+	adminGroup.DELETE("/replay/:jobId", CancelReplay(deps.ReplayJobs), adminAuth, tenantScoped)
+	if len(deps.Electors) > 0 {
+		adminGroup.GET("/leader", GetLeader(deps.Electors), adminAuth)
+	}
+
+	e.GET("/openapi.json", OpenAPISpec())
+	e.GET("/docs", APIDocs())
+
+	// REST gateway generated from gRPC definitions, legacy /api/cats routes are kept for compatibility
+	if deps.Gateway != nil {
+		e.Any(GatewayPrefix+"*", echo.WrapHandler(deps.Gateway))
+	}
+}
@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/service"
+)
+
+// GetAllSchedules fetches price schedules ordered by start
+func GetAllSchedules(schedulesService service.Schedules) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		page, err := parsePage(ctx)
+		if err != nil {
+			return err
+		}
+
+		schedules, err := schedulesService.GetAll(ctx.Request().Context(), page)
+		if err != nil {
+			return err
+		}
+
+		response := make(GetAllSchedulesResponse, 0, len(schedules))
+		for _, schedule := range schedules {
+			response = append(response, mapSchedule(schedule))
+		}
+		return ctx.JSON(http.StatusOK, response)
+	}
+}
+
+// GetSchedule fetches a single price schedule by ID along with prices it has changed
+func GetSchedule(schedulesService service.Schedules) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		id, err := parseID(ctx, "id")
+		if err != nil {
+			return err
+		}
+
+		schedule, err := schedulesService.GetOne(ctx.Request().Context(), id)
+		if err != nil {
+			return err
+		}
+		return ctx.JSON(http.StatusOK, mapSchedule(schedule))
+	}
+}
+
+// AddSchedule schedules a price change, or a promotion when end is given
+func AddSchedule(schedulesService service.Schedules) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		request := new(ScheduleRequest)
+		err := ctx.Bind(request)
+		if err != nil {
+			return err
+		}
+		schedule, err := unmapSchedule(*request)
+		if err != nil {
+			return err
+		}
+
+		id, err := schedulesService.Create(ctx.Request().Context(), schedule)
+		if err != nil {
+			return err
+		}
+
+		response := AddScheduleResponse{
+			ID: id.String(),
+		}
+		return ctx.JSON(http.StatusCreated, response)
+	}
+}
+
+// UpdateSchedule replaces a pending schedule, only end of an active promotion is taken from the request
+func UpdateSchedule(schedulesService service.Schedules) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		id, err := parseID(ctx, "id")
+		if err != nil {
+			return err
+		}
+
+		request := new(ScheduleRequest)
+		err = ctx.Bind(request)
+		if err != nil {
+			return err
+		}
+		schedule, err := unmapSchedule(*request)
+		if err != nil {
+			return err
+		}
+		schedule.ID = id
+
+		err = schedulesService.Update(ctx.Request().Context(), schedule)
+		if err != nil {
+			return err
+		}
+		return ctx.NoContent(http.StatusOK)
+	}
+}
+
+// DeleteSchedule deletes a pending or completed schedule by ID
+func DeleteSchedule(schedulesService service.Schedules) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		id, err := parseID(ctx, "id")
+		if err != nil {
+			return err
+		}
+		err = schedulesService.Delete(ctx.Request().Context(), id)
+		if err != nil {
+			return err
+		}
+		return ctx.NoContent(http.StatusOK)
+	}
+}
+
+func unmapSchedule(request ScheduleRequest) (entities.PriceSchedule, error) {
+	schedule := entities.PriceSchedule{
+		DiscountPercent: request.DiscountPercent,
+		StartsAt:        request.StartsAt,
+		EndsAt:          request.EndsAt,
+	}
+	if request.CatID != "" {
+		catID, err := uuid.Parse(request.CatID)
+		if err != nil {
+			return schedule, invalidParam("catId", "must be a valid UUID")
+		}
+		schedule.CatID = &catID
+	}
+	if request.Filter != nil {
+		schedule.Filter = &entities.ScheduleFilter{
+			Color:  request.Filter.Color,
+			Breed:  request.Filter.Breed,
+			Tags:   request.Filter.Tags,
+			MinAge: request.Filter.MinAge,
+			MaxAge: request.Filter.MaxAge,
+		}
+	}
+	if request.Price != nil {
+		price := unmapMoney(*request.Price)
+		schedule.Price = &price
+	}
+	return schedule, nil
+}
+
+func mapSchedule(schedule entities.PriceSchedule) Schedule {
+	result := Schedule{
+		ID:              schedule.ID.String(),
+		DiscountPercent: schedule.DiscountPercent,
+		StartsAt:        schedule.StartsAt,
+		EndsAt:          schedule.EndsAt,
+		State:           string(schedule.State),
+		LastError:       schedule.LastError,
+		CreatedAt:       schedule.CreatedAt,
+		UpdatedAt:       schedule.UpdatedAt,
+	}
+	if schedule.CatID != nil {
+		result.CatID = schedule.CatID.String()
+	}
+	if schedule.Filter != nil {
+		result.Filter = &ScheduleFilter{
+			Color:  schedule.Filter.Color,
+			Breed:  schedule.Filter.Breed,
+			Tags:   schedule.Filter.Tags,
+			MinAge: schedule.Filter.MinAge,
+			MaxAge: schedule.Filter.MaxAge,
+		}
+	}
+	if schedule.Price != nil {
+		price := mapMoney(*schedule.Price)
+		result.Price = &price
+	}
+	for _, applied := range schedule.Applied {
+		result.Applied = append(result.Applied, AppliedPrice{
+			CatID:         applied.CatID.String(),
+			OriginalPrice: mapMoney(applied.OriginalPrice),
+			Price:         mapMoney(applied.Price),
+			Reverted:      applied.Reverted,
+		})
+	}
+	return result
+}
+
+// ScheduleRequest represents a request to add or update a price schedule.
+// Exactly one of catId and filter, and one of price and discountPercent must be set,
+// a schedule with endsAt is a promotion and prices are restored when it ends.
+type ScheduleRequest struct {
+	CatID           string          `json:"catId"`
+	Filter          *ScheduleFilter `json:"filter"`
+	Price           *Money          `json:"price"`
+	DiscountPercent int             `json:"discountPercent"`
+	StartsAt        time.Time       `json:"startsAt"`
+	EndsAt          *time.Time      `json:"endsAt"`
+}
+
+// AddScheduleResponse represents a response to add a price schedule
+type AddScheduleResponse struct {
+	ID string `json:"id"`
+}
+
+// GetAllSchedulesResponse represents a response to get all price schedules
+type GetAllSchedulesResponse []Schedule
+
+// Schedule represents a price schedule with prices it has changed
+type Schedule struct {
+	ID              string          `json:"id"`
+	CatID           string          `json:"catId,omitempty"`
+	Filter          *ScheduleFilter `json:"filter,omitempty"`
+	Price           *Money          `json:"price,omitempty"`
+	DiscountPercent int             `json:"discountPercent,omitempty"`
+	StartsAt        time.Time       `json:"startsAt"`
+	EndsAt          *time.Time      `json:"endsAt,omitempty"`
+	State           string          `json:"state"`
+	Applied         []AppliedPrice  `json:"applied,omitempty"`
+	LastError       string          `json:"lastError,omitempty"`
+	CreatedAt       time.Time       `json:"createdAt"`
+	UpdatedAt       time.Time       `json:"updatedAt"`
+}
+
+// ScheduleFilter represents conditions on cats a schedule applies to
+type ScheduleFilter struct {
+	Color  string   `json:"color,omitempty"`
+	Breed  string   `json:"breed,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+	MinAge *int     `json:"minAge,omitempty"`
+	MaxAge *int     `json:"maxAge,omitempty"`
+}
+
+// AppliedPrice represents a price a schedule has set to a cat along with the price it replaced
+type AppliedPrice struct {
+	CatID         string `json:"catId"`
+	OriginalPrice Money  `json:"originalPrice"`
+	Price         Money  `json:"price"`
+	Reverted      bool   `json:"reverted,omitempty"`
+}
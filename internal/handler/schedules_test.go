@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/service"
+)
+
+func TestGetAllSchedules(t *testing.T) {
+	// Arrange
+	s := new(service.MockSchedules)
+	catID := uuid.New()
+	schedule := entities.PriceSchedule{
+		ID:              uuid.New(),
+		CatID:           &catID,
+		DiscountPercent: 20,
+		StartsAt:        time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC),
+		State:           entities.ScheduleCompleted,
+		Applied:         []entities.AppliedPrice{{CatID: catID, OriginalPrice: bella.Price, Price: money.Money{Amount: 799, Currency: "USD"}}},
+	}
+	s.On("GetAll", mockContext, repository.Page{Limit: 10}).Return([]entities.PriceSchedule{schedule}, nil)
+	ctx, rec := setup(http.MethodGet, nil)
+	ctx.QueryParams().Set("limit", "10")
+
+	// Act
+	err := GetAllSchedules(s)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, mustEncodeJSON(GetAllSchedulesResponse{mapSchedule(schedule)}), rec.Body.String())
+}
+
+func TestGetScheduleNotFound(t *testing.T) {
+	// Arrange
+	s := new(service.MockSchedules)
+	id := uuid.New()
+	s.On("GetOne", mockContext, id).Return(entities.PriceSchedule{}, service.ErrScheduleNotFound)
+	ctx, _ := setup(http.MethodGet, nil)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(id.String())
+
+	// Act
+	err := GetSchedule(s)(ctx)
+
+	// Assert
+	requireProblem(t, err, http.StatusNotFound, service.ErrScheduleNotFound.Code)
+}
+
+func TestAddSchedule(t *testing.T) {
+	// Arrange
+	s := new(service.MockSchedules)
+	startsAt := time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC)
+	endsAt := startsAt.Add(48 * time.Hour)
+	req := ScheduleRequest{Filter: &ScheduleFilter{Breed: "Siamese"}, DiscountPercent: 15, StartsAt: startsAt, EndsAt: &endsAt}
+	id := uuid.New()
+	s.On("Create", mockContext, entities.PriceSchedule{
+		Filter:          &entities.ScheduleFilter{Breed: "Siamese"},
+		DiscountPercent: 15,
+		StartsAt:        startsAt,
+		EndsAt:          &endsAt,
+	}).Return(id, nil)
+	ctx, rec := setup(http.MethodPost, req)
+
+	// Act
+	err := AddSchedule(s)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.Equal(t, mustEncodeJSON(AddScheduleResponse{id.String()}), rec.Body.String())
+}
+
+func TestAddScheduleMalformedCatId(t *testing.T) {
+	// Arrange
+	s := new(service.MockSchedules)
+	ctx, _ := setup(http.MethodPost, ScheduleRequest{CatID: "bella", DiscountPercent: 15, StartsAt: time.Now()})
+
+	// Act
+	err := AddSchedule(s)(ctx)
+
+	// Assert
+	requireProblem(t, err, http.StatusBadRequest, "validation_failed")
+	s.AssertNotCalled(t, "Create")
+}
+
+func TestUpdateScheduleNotEditable(t *testing.T) {
+	// Arrange
+	s := new(service.MockSchedules)
+	id, catID := uuid.New(), uuid.New()
+	startsAt := time.Date(2021, 8, 1, 9, 0, 0, 0, time.UTC)
+	price := Money{Amount: 599, Currency: "USD"}
+	s.On("Update", mockContext, entities.PriceSchedule{
+		ID:       id,
+		CatID:    &catID,
+		Price:    &money.Money{Amount: 599, Currency: "USD"},
+		StartsAt: startsAt,
+	}).Return(service.ErrScheduleNotEditable)
+	ctx, _ := setup(http.MethodPut, ScheduleRequest{CatID: catID.String(), Price: &price, StartsAt: startsAt})
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(id.String())
+
+	// Act
+	err := UpdateSchedule(s)(ctx)
+
+	// Assert
+	requireProblem(t, err, http.StatusConflict, service.ErrScheduleNotEditable.Code)
+}
+
+func TestDeleteSchedule(t *testing.T) {
+	// Arrange
+	s := new(service.MockSchedules)
+	id := uuid.New()
+	s.On("Delete", mockContext, id).Return(nil)
+	ctx, rec := setup(http.MethodDelete, nil)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(id.String())
+
+	// Act
+	err := DeleteSchedule(s)(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+const (
+	tenantUnauthenticatedCode = "tenant_unauthenticated"
+	tenantForbiddenCode       = "tenant_forbidden"
+)
+
+// Tenant resolves tenant of a request from bearer token or tenant header and puts it into request context.
+// Requests without valid tenant credentials are rejected with 401, requests of tenants not served here with 403.
+func Tenant(resolver *tenant.Resolver) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			request := ctx.Request()
+			tenantID, err := resolver.Resolve(request.Header.Get(tenant.AuthorizationHeader), request.Header.Get(tenant.Header))
+			if tenant.IsUnauthenticated(err) {
+				return NewProblem(http.StatusUnauthorized, tenantUnauthenticatedCode, err.Error())
+			} else if err != nil {
+				return NewProblem(http.StatusForbidden, tenantForbiddenCode, err.Error())
+			}
+
+			ctx.SetRequest(request.WithContext(tenant.WithID(request.Context(), tenantID)))
+			return next(ctx)
+		}
+	}
+}
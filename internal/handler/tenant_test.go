@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+func TestTenantPutsTenantIntoContext(t *testing.T) {
+	// Arrange
+	resolver := tenant.NewResolver([]string{tenant.Default, "shelter-1"}, "", "", false)
+	ctx, _ := setup(http.MethodGet, nil)
+	ctx.Request().Header.Set(tenant.Header, "shelter-1")
+	var tenantID string
+
+	// Act
+	err := Tenant(resolver)(func(ctx echo.Context) error {
+		var err error
+		tenantID, err = tenant.FromContext(ctx.Request().Context())
+		return err
+	})(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, "shelter-1", tenantID)
+}
+
+func TestTenantRejectsRequest(t *testing.T) {
+	resolver := tenant.NewResolver([]string{tenant.Default}, "", "", true)
+	for header, status := range map[string]int{
+		"":          http.StatusUnauthorized,
+		"Shelter":   http.StatusUnauthorized,
+		"shelter-1": http.StatusForbidden,
+	} {
+		// Arrange
+		ctx, _ := setup(http.MethodGet, nil)
+		ctx.Request().Header.Set(tenant.Header, header)
+
+		// Act
+		err := Tenant(resolver)(createdHandler)(ctx)
+
+		// Assert
+		var problem *Problem
+		require.ErrorAs(t, err, &problem, header)
+		require.Equal(t, status, problem.Status, header)
+	}
+}
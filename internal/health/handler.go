@@ -0,0 +1,70 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// checkTimeout limits time of dependency checks, so probes get an answer before they time out themselves
+const checkTimeout = 2 * time.Second
+
+// Summary is a state of all components, it is up only when all of them are up
+type Summary struct {
+	Status     Status            `json:"status"`
+	Components map[string]Report `json:"components"`
+}
+
+// Handler serves health of components:
+//
+//	GET /health/live      200 while process is running
+//	GET /health           200 if all components are up, 503 otherwise
+//	GET /health/{name}    200 if the component is up, 503 otherwise, 404 if it is not run by this process
+func Handler(r *Registry) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health/live", func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]Status{"status": StatusUp})
+	})
+	mux.HandleFunc("/health", func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), checkTimeout)
+		defer cancel()
+
+		summary := Summary{Status: StatusUp, Components: make(map[string]Report)}
+		for _, c := range r.Components() {
+			report := c.Report(ctx)
+			summary.Components[c.Name()] = report
+			if report.Status != StatusUp {
+				summary.Status = StatusDown
+			}
+		}
+		writeJSON(w, statusCode(summary.Status), summary)
+	})
+	mux.HandleFunc("/health/", func(w http.ResponseWriter, req *http.Request) {
+		c, ok := r.Component(strings.TrimPrefix(req.URL.Path, "/health/"))
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		ctx, cancel := context.WithTimeout(req.Context(), checkTimeout)
+		defer cancel()
+
+		report := c.Report(ctx)
+		writeJSON(w, statusCode(report.Status), report)
+	})
+	return mux
+}
+
+func statusCode(status Status) int {
+	if status == StatusUp {
+		return http.StatusOK
+	}
+	return http.StatusServiceUnavailable
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body) //nolint:errcheck,gosec
+}
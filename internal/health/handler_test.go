@@ -0,0 +1,58 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	// Arrange
+	registry := NewRegistry()
+	registry.Register("http", nil).Set(StatusUp, ":5000")
+	registry.Register("bridge", nil)
+	h := Handler(registry)
+
+	testCases := []struct {
+		name     string
+		path     string
+		wantCode int
+	}{
+		{name: "live while starting", path: "/health/live", wantCode: http.StatusOK},
+		{name: "summary with starting component", path: "/health", wantCode: http.StatusServiceUnavailable},
+		{name: "up component", path: "/health/http", wantCode: http.StatusOK},
+		{name: "starting component", path: "/health/bridge", wantCode: http.StatusServiceUnavailable},
+		{name: "role not run", path: "/health/grpc", wantCode: http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			// Assert
+			require.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
+
+func TestHandlerSummary(t *testing.T) {
+	// Arrange
+	registry := NewRegistry()
+	registry.Register("http", nil).Set(StatusUp, ":5000")
+	registry.Register("grpc", nil).Set(StatusUp, ":6000")
+	rec := httptest.NewRecorder()
+
+	// Act
+	Handler(registry).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	// Assert
+	require.Equal(t, http.StatusOK, rec.Code)
+	var summary Summary
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &summary))
+	require.Equal(t, StatusUp, summary.Status)
+	require.Equal(t, Report{Status: StatusUp, Detail: ":6000"}, summary.Components["grpc"])
+}
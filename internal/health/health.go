@@ -0,0 +1,146 @@
+// Package health reports state of app roles, so orchestrators can route traffic and restart failed processes
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Status is a state of a component
+type Status string
+
+const (
+	// StatusStarting means a component has not finished starting yet
+	StatusStarting Status = "starting"
+	// StatusUp means a component is working
+	StatusUp Status = "up"
+	// StatusDown means a component has failed or one of its dependencies is unavailable
+	StatusDown Status = "down"
+	// StatusStopping means a component is shutting down and does not take new work
+	StatusStopping Status = "stopping"
+)
+
+// Check reports whether a dependency of a component is available
+type Check func(ctx context.Context) error
+
+// Report is a state of a component at the moment of request
+type Report struct {
+	Status Status            `json:"status"`
+	Detail string            `json:"detail,omitempty"`
+	Error  string            `json:"error,omitempty"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Component is a part of app that reports its own state, e.g. a role
+type Component struct {
+	name   string
+	checks map[string]Check
+
+	mu     sync.RWMutex
+	status Status
+	detail string
+	err    error
+}
+
+// Name returns name of the component
+func (c *Component) Name() string {
+	return c.name
+}
+
+// Set changes status of the component, detail is an optional human readable state, e.g. listening address
+func (c *Component) Set(status Status, detail string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status, c.detail, c.err = status, detail, nil
+}
+
+// SetDetail changes detail keeping status of the component
+func (c *Component) SetDetail(detail string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.detail = detail
+}
+
+// Fail marks the component down because of err
+func (c *Component) Fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status, c.err = StatusDown, err
+}
+
+// Report returns state of the component, a working component is reported down if any of its checks fails
+func (c *Component) Report(ctx context.Context) Report {
+	c.mu.RLock()
+	report := Report{Status: c.status, Detail: c.detail}
+	if c.err != nil {
+		report.Error = c.err.Error()
+	}
+	c.mu.RUnlock()
+
+	if len(c.checks) == 0 {
+		return report
+	}
+	report.Checks = make(map[string]string, len(c.checks))
+	for name, check := range c.checks {
+		if err := check(ctx); err != nil {
+			report.Checks[name] = err.Error()
+			if report.Status == StatusUp {
+				report.Status = StatusDown
+			}
+			continue
+		}
+		report.Checks[name] = string(StatusUp)
+	}
+	return report
+}
+
+// Registry holds components of app
+type Registry struct {
+	mu         sync.RWMutex
+	components map[string]*Component
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{components: make(map[string]*Component)}
+}
+
+// Register adds a starting component with checks of its dependencies
+func (r *Registry) Register(name string, checks map[string]Check) *Component {
+	c := &Component{name: name, checks: checks, status: StatusStarting}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.components[name] = c
+	return c
+}
+
+// Component returns a registered component by name
+func (r *Registry) Component(name string) (*Component, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.components[name]
+	return c, ok
+}
+
+// Components returns all registered components sorted by name
+func (r *Registry) Components() []*Component {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make([]*Component, 0, len(r.components))
+	for _, c := range r.components {
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+	return result
+}
+
+// SetAll changes status of all components, e.g. to stopping when shutdown begins
+func (r *Registry) SetAll(status Status) {
+	for _, c := range r.Components() {
+		c.mu.Lock()
+		c.status = status
+		c.mu.Unlock()
+	}
+}
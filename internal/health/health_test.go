@@ -0,0 +1,61 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComponentReport(t *testing.T) {
+	// Arrange
+	registry := NewRegistry()
+	c := registry.Register("http", map[string]Check{
+		"mongo": func(ctx context.Context) error { return nil },
+		"redis": func(ctx context.Context) error { return errors.New("connection refused") },
+	})
+	starting := c.Report(context.Background())
+	c.Set(StatusUp, ":5000")
+
+	// Act
+	report := c.Report(context.Background())
+
+	// Assert
+	require.Equal(t, StatusStarting, starting.Status)
+	require.Equal(t, StatusDown, report.Status)
+	require.Equal(t, ":5000", report.Detail)
+	require.Equal(t, map[string]string{"mongo": "up", "redis": "connection refused"}, report.Checks)
+}
+
+func TestComponentFail(t *testing.T) {
+	// Arrange
+	registry := NewRegistry()
+	c := registry.Register("fanout-consumer", nil)
+	c.Set(StatusUp, "redis consumer 0")
+
+	// Act
+	c.Fail(errors.New("price consumer has stopped"))
+	report := c.Report(context.Background())
+
+	// Assert
+	require.Equal(t, Report{Status: StatusDown, Detail: "redis consumer 0", Error: "price consumer has stopped"}, report)
+}
+
+func TestRegistrySetAll(t *testing.T) {
+	// Arrange
+	registry := NewRegistry()
+	registry.Register("grpc", nil).Set(StatusUp, "")
+	registry.Register("bridge", nil).Set(StatusUp, "leader")
+
+	// Act
+	registry.SetAll(StatusStopping)
+
+	// Assert
+	components := registry.Components()
+	require.Len(t, components, 2)
+	require.Equal(t, "bridge", components[0].Name())
+	require.Equal(t, StatusStopping, components[0].Report(context.Background()).Status)
+	require.Equal(t, "leader", components[0].Report(context.Background()).Detail)
+	require.Equal(t, StatusStopping, components[1].Report(context.Background()).Status)
+}
@@ -0,0 +1,163 @@
+// Package leader provides election of a single active instance among many
+package leader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/evleria/cats-app/internal/logging"
+)
+
+// ErrNotLeader means the instance does not hold leadership with given fencing token anymore
+var ErrNotLeader = errors.New("not a leader")
+
+// Elector elects a single leader and runs a task on it
+type Elector interface {
+	// Run blocks until ctx is done. Whenever leadership is acquired, lead is called with a fencing token,
+	// its context is cancelled as soon as leadership is lost.
+	Run(ctx context.Context, lead func(ctx context.Context, token int64) error)
+	// Validate checks that the instance still holds leadership with given fencing token
+	Validate(ctx context.Context, token int64) error
+	// Leader returns information about current leader
+	Leader(ctx context.Context) (Info, error)
+}
+
+// Info describes current leader
+type Info struct {
+	Name       string `json:"name"`
+	InstanceID string `json:"instanceId"`
+	Token      int64  `json:"token"`
+	Elected    bool   `json:"elected"`
+	Self       bool   `json:"self"`
+}
+
+// lock is a lease of leadership kept in shared store, it expires unless renewed
+type lock interface {
+	// acquire takes the lock if it is free and returns a fencing token growing on every acquisition, or 0 if it is held
+	acquire(ctx context.Context) (int64, error)
+	// renew prolongs the lock only if it is still held with given token
+	renew(ctx context.Context, token int64) (bool, error)
+	// release frees the lock only if it is still held with given token
+	release(ctx context.Context, token int64) error
+	// holder returns instance holding the lock and its token, held is false when the lock is free
+	holder(ctx context.Context) (instanceID string, token int64, held bool, err error)
+}
+
+type elector struct {
+	lock       lock
+	name       string
+	instanceID string
+	ttl        time.Duration
+
+	mu    sync.RWMutex
+	token int64
+}
+
+func newElector(l lock, name, instanceID string, ttl time.Duration) Elector {
+	return &elector{
+		lock:       l,
+		name:       name,
+		instanceID: instanceID,
+		ttl:        ttl,
+	}
+}
+
+func (e *elector) Run(ctx context.Context, lead func(ctx context.Context, token int64) error) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		token, err := e.lock.acquire(ctx)
+		if err != nil && ctx.Err() == nil {
+			logging.Warnf("leader election %s failed: %v\n", e.name, err)
+		}
+		if token > 0 {
+			e.setToken(token)
+			logging.Infof("instance %s became leader of %s with token %d\n", e.instanceID, e.name, token)
+			e.holdLeadership(ctx, token, ticker, lead)
+			e.setToken(0)
+			logging.Infof("instance %s lost leadership of %s\n", e.instanceID, e.name)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// holdLeadership runs lead while renewing the lock, and releases the lock when lead returns
+func (e *elector) holdLeadership(ctx context.Context, token int64, ticker *time.Ticker, lead func(ctx context.Context, token int64) error) {
+	leadCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := lead(leadCtx, token); err != nil && leadCtx.Err() == nil {
+			logging.Errorf("leader task %s failed: %v\n", e.name, err)
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			e.releaseLock(token)
+			return
+		case <-ticker.C:
+			renewed, err := e.lock.renew(ctx, token)
+			if err != nil || !renewed {
+				cancel()
+				<-done
+				return
+			}
+		}
+	}
+}
+
+func (e *elector) releaseLock(token int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.ttl)
+	defer cancel()
+	if err := e.lock.release(ctx, token); err != nil {
+		logging.Warnf("cannot release leadership of %s: %v\n", e.name, err)
+	}
+}
+
+func (e *elector) Validate(ctx context.Context, token int64) error {
+	instanceID, heldToken, held, err := e.lock.holder(ctx)
+	if err != nil {
+		return err
+	}
+	if !held || instanceID != e.instanceID || heldToken != token {
+		return ErrNotLeader
+	}
+	return nil
+}
+
+func (e *elector) Leader(ctx context.Context) (Info, error) {
+	info := Info{Name: e.name}
+	instanceID, token, held, err := e.lock.holder(ctx)
+	if err != nil || !held {
+		return info, err
+	}
+	info.InstanceID = instanceID
+	info.Token = token
+	info.Elected = true
+	info.Self = info.Token == e.currentToken() && info.InstanceID == e.instanceID
+	return info, nil
+}
+
+func (e *elector) setToken(token int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.token = token
+}
+
+func (e *elector) currentToken() int64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.token
+}
@@ -0,0 +1,54 @@
+// Code generated by mockery v0.0.0-dev. DO NOT EDIT.
+
+package leader
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockElector is an autogenerated mock type for the Elector type
+type MockElector struct {
+	mock.Mock
+}
+
+// Leader provides a mock function with given fields: ctx
+func (_m *MockElector) Leader(ctx context.Context) (Info, error) {
+	ret := _m.Called(ctx)
+
+	var r0 Info
+	if rf, ok := ret.Get(0).(func(context.Context) Info); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(Info)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Run provides a mock function with given fields: ctx, lead
+func (_m *MockElector) Run(ctx context.Context, lead func(context.Context, int64) error) {
+	_m.Called(ctx, lead)
+}
+
+// Validate provides a mock function with given fields: ctx, token
+func (_m *MockElector) Validate(ctx context.Context, token int64) error {
+	ret := _m.Called(ctx, token)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,97 @@
+package leader
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// lockDocument is a leader lock in leaders collection, expired document is a free lock keeping the last token
+type lockDocument struct {
+	Name       string    `bson:"_id"`
+	InstanceID string    `bson:"instanceId"`
+	Token      int64     `bson:"token"`
+	ExpiresAt  time.Time `bson:"expiresAt"`
+}
+
+// mongoLock compares expiration with $$NOW of the server, so clocks of instances do not matter
+type mongoLock struct {
+	collection *mongo.Collection
+	name       string
+	instanceID string
+	ttl        time.Duration
+}
+
+// NewMongoElector creates an elector based on mongo lock with fencing tokens.
+// A leader that stops renewing the lock loses leadership after ttl.
+func NewMongoElector(mongoDB *mongo.Database, name, instanceID string, ttl time.Duration) Elector {
+	l := &mongoLock{
+		collection: mongoDB.Collection("leaders"),
+		name:       name,
+		instanceID: instanceID,
+		ttl:        ttl,
+	}
+	return newElector(l, name, instanceID, ttl)
+}
+
+func (l *mongoLock) acquire(ctx context.Context) (int64, error) {
+	// upsert of a lock that is held fails on duplicate _id
+	filter := bson.M{"_id": l.name, "$expr": bson.M{"$lt": bson.A{"$expiresAt", "$$NOW"}}}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"instanceId": l.instanceID,
+		"token":      bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$token", 0}}, 1}},
+		"expiresAt":  l.expiresAt(),
+	}}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var doc lockDocument
+	err := l.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+	if mongo.IsDuplicateKeyError(err) {
+		return 0, nil
+	}
+	return doc.Token, err
+}
+
+func (l *mongoLock) renew(ctx context.Context, token int64) (bool, error) {
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{"expiresAt": l.expiresAt()}}}}
+	result, err := l.collection.UpdateOne(ctx, l.heldFilter(token), update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (l *mongoLock) release(ctx context.Context, token int64) error {
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{"expiresAt": "$$NOW"}}}}
+	_, err := l.collection.UpdateOne(ctx, l.heldFilter(token), update)
+	return err
+}
+
+func (l *mongoLock) holder(ctx context.Context) (string, int64, bool, error) {
+	filter := bson.M{"_id": l.name, "$expr": bson.M{"$gt": bson.A{"$expiresAt", "$$NOW"}}}
+	var doc lockDocument
+	err := l.collection.FindOne(ctx, filter).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return "", 0, false, nil
+	} else if err != nil {
+		return "", 0, false, err
+	}
+	return doc.InstanceID, doc.Token, true, nil
+}
+
+// heldFilter matches the lock while it is held by this instance with given token
+func (l *mongoLock) heldFilter(token int64) bson.M {
+	return bson.M{
+		"_id":        l.name,
+		"instanceId": l.instanceID,
+		"token":      token,
+		"$expr":      bson.M{"$gt": bson.A{"$expiresAt", "$$NOW"}},
+	}
+}
+
+func (l *mongoLock) expiresAt() bson.M {
+	return bson.M{"$add": bson.A{"$$NOW", l.ttl.Milliseconds()}}
+}
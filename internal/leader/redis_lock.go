@@ -0,0 +1,99 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// acquireScript sets the lock if it is free, fencing token grows on every successful acquisition
+const acquireScript = `
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return 0
+end
+local token = redis.call("INCR", KEYS[2])
+redis.call("SET", KEYS[1], ARGV[1] .. ":" .. token, "PX", ARGV[2])
+return token
+`
+
+// renewScript prolongs the lock only if it is still held with the same value
+const renewScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`
+
+// releaseScript removes the lock only if it is still held with the same value
+const releaseScript = `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`
+
+type redisLock struct {
+	redis         *redis.Client
+	name          string
+	instanceID    string
+	ttl           time.Duration
+	acquireScript *redis.Script
+	renewScript   *redis.Script
+	releaseScript *redis.Script
+}
+
+// NewRedisElector creates an elector based on redis lock with fencing tokens.
+// A leader that stops renewing the lock loses leadership after ttl.
+func NewRedisElector(redisClient *redis.Client, name, instanceID string, ttl time.Duration) Elector {
+	l := &redisLock{
+		redis:         redisClient,
+		name:          name,
+		instanceID:    instanceID,
+		ttl:           ttl,
+		acquireScript: redis.NewScript(acquireScript),
+		renewScript:   redis.NewScript(renewScript),
+		releaseScript: redis.NewScript(releaseScript),
+	}
+	return newElector(l, name, instanceID, ttl)
+}
+
+func (l *redisLock) acquire(ctx context.Context) (int64, error) {
+	return l.acquireScript.Run(ctx, l.redis, l.keys(), l.instanceID, l.ttl.Milliseconds()).Int64()
+}
+
+func (l *redisLock) renew(ctx context.Context, token int64) (bool, error) {
+	renewed, err := l.renewScript.Run(ctx, l.redis, l.keys()[:1], l.value(token), l.ttl.Milliseconds()).Int64()
+	return renewed == 1, err
+}
+
+func (l *redisLock) release(ctx context.Context, token int64) error {
+	return l.releaseScript.Run(ctx, l.redis, l.keys()[:1], l.value(token)).Err()
+}
+
+func (l *redisLock) holder(ctx context.Context) (string, int64, bool, error) {
+	value, err := l.redis.Get(ctx, l.keys()[0]).Result()
+	if err == redis.Nil {
+		return "", 0, false, nil
+	} else if err != nil {
+		return "", 0, false, err
+	}
+
+	separator := strings.LastIndex(value, ":")
+	if separator < 0 {
+		return "", 0, false, fmt.Errorf("malformed leader lock value %q", value)
+	}
+	token, err := strconv.ParseInt(value[separator+1:], 10, 64)
+	return value[:separator], token, err == nil, err
+}
+
+func (l *redisLock) keys() []string {
+	return []string{"leader:" + l.name, "leader:" + l.name + ":token"}
+}
+
+func (l *redisLock) value(token int64) string {
+	return fmt.Sprintf("%s:%d", l.instanceID, token)
+}
@@ -0,0 +1,92 @@
+// Package logging filters standard log output by a level that can be changed while app is running
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is a severity of a log message, messages below the current level are dropped
+type Level int32
+
+const (
+	// LevelDebug is for messages useful only while troubleshooting
+	LevelDebug Level = iota
+	// LevelInfo is for messages about regular operation, e.g. served calls
+	LevelInfo
+	// LevelWarn is for failures app recovers from, e.g. failing open on unavailable rate limiter
+	LevelWarn
+	// LevelError is for failures that need attention
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+var current = int32(LevelInfo)
+
+// ParseLevel parses level by its name
+func ParseLevel(name string) (Level, error) {
+	for i, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return Level(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q, must be one of %s", name, strings.Join(levelNames, ", "))
+}
+
+// String returns name of the level
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return fmt.Sprintf("Level(%d)", int32(l))
+	}
+	return levelNames[l]
+}
+
+// UnmarshalText parses level by its name, so it can be read from config
+func (l *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// SetLevel changes minimal level of messages that are logged
+func SetLevel(l Level) {
+	atomic.StoreInt32(&current, int32(l))
+}
+
+// Enabled reports whether messages of the level are logged
+func Enabled(l Level) bool {
+	return l >= Level(atomic.LoadInt32(&current))
+}
+
+// Debugf logs a message with LevelDebug
+func Debugf(format string, args ...interface{}) {
+	logf(LevelDebug, format, args...)
+}
+
+// Infof logs a message with LevelInfo
+func Infof(format string, args ...interface{}) {
+	logf(LevelInfo, format, args...)
+}
+
+// Warnf logs a message with LevelWarn
+func Warnf(format string, args ...interface{}) {
+	logf(LevelWarn, format, args...)
+}
+
+// Errorf logs a message with LevelError
+func Errorf(format string, args ...interface{}) {
+	logf(LevelError, format, args...)
+}
+
+func logf(l Level, format string, args ...interface{}) {
+	if Enabled(l) {
+		// calldepth skips logf and the exported helper, so file flags point at the caller
+		log.Output(3, fmt.Sprintf(format, args...)) //nolint:errcheck,gosec
+	}
+}
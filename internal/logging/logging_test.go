@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelFiltering(t *testing.T) {
+	// Arrange
+	var out bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&out)
+	defer SetLevel(LevelInfo)
+	SetLevel(LevelWarn)
+
+	// Act
+	Infof("served call")
+	Warnf("failing open")
+	Errorf("internal error")
+
+	// Assert
+	require.NotContains(t, out.String(), "served call")
+	require.Contains(t, out.String(), "failing open")
+	require.Contains(t, out.String(), "internal error")
+}
+
+func TestParseLevel(t *testing.T) {
+	// Arrange
+	var level Level
+
+	// Act
+	err := level.UnmarshalText([]byte("DEBUG"))
+	_, unknownErr := ParseLevel("verbose")
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, LevelDebug, level)
+	require.Equal(t, "debug", level.String())
+	require.Error(t, unknownErr)
+}
@@ -0,0 +1,193 @@
+// Package migration applies versioned changes of database schema and data exactly once across all instances
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/evleria/cats-app/internal/logging"
+)
+
+// ErrLockLost means another instance has taken the migration lock while migrations were running
+var ErrLockLost = errors.New("migration lock lost")
+
+// Migration is a single change of database, Up must be safe to run again if it fails half way,
+// because it is recorded as applied only after it succeeds
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context) error
+}
+
+// Record is a migration applied to database
+type Record struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"appliedAt"`
+	AppliedBy   string    `bson:"appliedBy"`
+}
+
+// Store keeps applied migrations and a lock that lets only one instance migrate at a time
+type Store interface {
+	// Lock acquires the lock for owner or prolongs it if owner already holds it,
+	// it returns false if the lock is held by another owner
+	Lock(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+	// Unlock releases the lock if it is held by owner
+	Unlock(ctx context.Context, owner string) error
+	Applied(ctx context.Context) ([]Record, error)
+	Record(ctx context.Context, record Record) error
+}
+
+// Migrator applies migrations not recorded in store yet in order of their versions
+type Migrator struct {
+	store      Store
+	owner      string
+	lockTTL    time.Duration
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator, owner identifies the instance holding the lock.
+// The lock is prolonged while migrations run, so lockTTL only limits how long a crashed instance blocks others.
+func NewMigrator(store Store, owner string, lockTTL time.Duration, migrations []Migration) *Migrator {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return &Migrator{
+		store:      store,
+		owner:      owner,
+		lockTTL:    lockTTL,
+		migrations: sorted,
+	}
+}
+
+// Up waits for the lock and applies pending migrations, it returns migrations applied by this call
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := m.store.Unlock(context.Background(), m.owner); err != nil {
+			logging.Warnf("cannot release migration lock: %v\n", err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var lost int32
+	go m.keepLock(ctx, func() {
+		atomic.StoreInt32(&lost, 1)
+		cancel()
+	})
+
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied := make([]Migration, 0, len(pending))
+	for _, migration := range pending {
+		err := migration.Up(ctx)
+		if err == nil {
+			err = m.store.Record(ctx, Record{
+				Version:     migration.Version,
+				Description: migration.Description,
+				AppliedAt:   time.Now().UTC(),
+				AppliedBy:   m.owner,
+			})
+		}
+		if atomic.LoadInt32(&lost) == 1 {
+			err = ErrLockLost
+		}
+		if err != nil {
+			return applied, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// Pending returns migrations not recorded in store yet
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	records, err := m.store.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[int]bool, len(records))
+	for _, r := range records {
+		done[r.Version] = true
+	}
+
+	pending := []Migration{}
+	for _, migration := range m.migrations {
+		if !done[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+func (m *Migrator) validate() error {
+	for i, migration := range m.migrations {
+		if migration.Version <= 0 {
+			return fmt.Errorf("migration version must be positive, got %d", migration.Version)
+		}
+		if i > 0 && m.migrations[i-1].Version == migration.Version {
+			return fmt.Errorf("migration version %d is used twice", migration.Version)
+		}
+	}
+	return nil
+}
+
+// lock polls until the lock is acquired, an instance that crashed while migrating blocks others for lockTTL at most
+func (m *Migrator) lock(ctx context.Context) error {
+	retry := time.NewTicker(m.lockTTL / 10)
+	defer retry.Stop()
+
+	for {
+		acquired, err := m.store.Lock(ctx, m.owner, m.lockTTL)
+		if err != nil {
+			return fmt.Errorf("cannot acquire migration lock: %w", err)
+		}
+		if acquired {
+			return nil
+		}
+		logging.Infof("waiting for migrations run by another instance\n")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-retry.C:
+		}
+	}
+}
+
+// keepLock prolongs the lock until ctx is done, lost is called if another instance has taken it
+func (m *Migrator) keepLock(ctx context.Context, lost func()) {
+	ticker := time.NewTicker(m.lockTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		held, err := m.store.Lock(ctx, m.owner, m.lockTTL)
+		if err != nil {
+			if ctx.Err() == nil {
+				logging.Warnf("cannot prolong migration lock: %v\n", err)
+			}
+			continue
+		}
+		if !held {
+			lost()
+			return
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpAppliesPendingInOrder(t *testing.T) {
+	// Arrange
+	var ran []int
+	up := func(version int) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			ran = append(ran, version)
+			return nil
+		}
+	}
+	store := new(MockStore)
+	store.On("Lock", mock.Anything, "instance-1", time.Minute).Return(true, nil)
+	store.On("Unlock", mock.Anything, "instance-1").Return(nil)
+	store.On("Applied", mock.Anything).Return([]Record{{Version: 1}}, nil)
+	store.On("Record", mock.Anything, mock.MatchedBy(func(r Record) bool {
+		return r.AppliedBy == "instance-1" && !r.AppliedAt.IsZero()
+	})).Return(nil)
+	m := NewMigrator(store, "instance-1", time.Minute, []Migration{
+		{Version: 3, Description: "third", Up: up(3)},
+		{Version: 1, Description: "first", Up: up(1)},
+		{Version: 2, Description: "second", Up: up(2)},
+	})
+
+	// Act
+	applied, err := m.Up(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 3}, ran)
+	require.Len(t, applied, 2)
+	store.AssertNumberOfCalls(t, "Record", 2)
+	store.AssertCalled(t, "Unlock", mock.Anything, "instance-1")
+}
+
+func TestUpStopsOnFailure(t *testing.T) {
+	// Arrange
+	store := new(MockStore)
+	store.On("Lock", mock.Anything, "instance-1", time.Minute).Return(true, nil)
+	store.On("Unlock", mock.Anything, "instance-1").Return(nil)
+	store.On("Applied", mock.Anything).Return([]Record{}, nil)
+	store.On("Record", mock.Anything, mock.Anything).Return(nil)
+	secondRan := false
+	m := NewMigrator(store, "instance-1", time.Minute, []Migration{
+		{Version: 1, Description: "broken", Up: func(ctx context.Context) error { return errors.New("index exists") }},
+		{Version: 2, Description: "next", Up: func(ctx context.Context) error {
+			secondRan = true
+			return nil
+		}},
+	})
+
+	// Act
+	applied, err := m.Up(context.Background())
+
+	// Assert
+	require.EqualError(t, err, "migration 1 (broken): index exists")
+	require.Empty(t, applied)
+	require.False(t, secondRan)
+	store.AssertNotCalled(t, "Record", mock.Anything, mock.Anything)
+	store.AssertCalled(t, "Unlock", mock.Anything, "instance-1")
+}
+
+func TestUpWaitsForLock(t *testing.T) {
+	// Arrange
+	store := new(MockStore)
+	store.On("Lock", mock.Anything, "instance-2", 100*time.Millisecond).Return(false, nil).Once()
+	store.On("Lock", mock.Anything, "instance-2", 100*time.Millisecond).Return(true, nil)
+	store.On("Unlock", mock.Anything, "instance-2").Return(nil)
+	store.On("Applied", mock.Anything).Return([]Record{{Version: 1}}, nil)
+	m := NewMigrator(store, "instance-2", 100*time.Millisecond, []Migration{
+		{Version: 1, Description: "applied by another instance", Up: func(ctx context.Context) error { return nil }},
+	})
+
+	// Act
+	applied, err := m.Up(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	require.Empty(t, applied)
+	store.AssertNumberOfCalls(t, "Lock", 2)
+}
+
+func TestUpLockLost(t *testing.T) {
+	// Arrange
+	store := new(MockStore)
+	store.On("Lock", mock.Anything, "instance-1", 30*time.Millisecond).Return(true, nil).Once()
+	store.On("Lock", mock.Anything, "instance-1", 30*time.Millisecond).Return(false, nil)
+	store.On("Unlock", mock.Anything, "instance-1").Return(nil)
+	store.On("Applied", mock.Anything).Return([]Record{}, nil)
+	m := NewMigrator(store, "instance-1", 30*time.Millisecond, []Migration{
+		{Version: 1, Description: "slow backfill", Up: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	})
+
+	// Act
+	_, err := m.Up(context.Background())
+
+	// Assert
+	require.True(t, errors.Is(err, ErrLockLost))
+	store.AssertNotCalled(t, "Record", mock.Anything, mock.Anything)
+}
+
+func TestUpDuplicateVersion(t *testing.T) {
+	// Arrange
+	noop := func(ctx context.Context) error { return nil }
+	m := NewMigrator(new(MockStore), "instance-1", time.Minute, []Migration{
+		{Version: 1, Up: noop},
+		{Version: 1, Up: noop},
+	})
+
+	// Act
+	_, err := m.Up(context.Background())
+
+	// Assert
+	require.EqualError(t, err, "migration version 1 is used twice")
+}
@@ -0,0 +1,381 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+// Mongo returns migrations of app database. Applied migrations must never change,
+// a change of schema or data is added as a migration with the next version.
+func Mongo(mongoDB *mongo.Database) []Migration {
+	cats := mongoDB.Collection("cats")
+	priceHistory := mongoDB.Collection("price_history")
+	priceSchedules := mongoDB.Collection("price_schedules")
+	idempotencyKeys := mongoDB.Collection("idempotency_keys")
+
+	return []Migration{
+		{
+			Version:     1,
+			Description: "create text index of cats",
+			Up: func(ctx context.Context) error {
+				// name and weights are the same as of the index created before migrations, so it is reused
+				_, err := cats.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys: bson.D{
+						{Key: "name", Value: "text"},
+						{Key: "color", Value: "text"},
+						{Key: "breed", Value: "text"},
+						{Key: "description", Value: "text"},
+					},
+					Options: options.Index().
+						SetName("cats_text").
+						SetWeights(bson.M{"name": 10, "breed": 5, "color": 3, "description": 1}),
+				})
+				return err
+			},
+		},
+		{
+			Version:     2,
+			Description: "create indexes of cats filters and reservations",
+			Up: func(ctx context.Context) error {
+				_, err := cats.Indexes().CreateMany(ctx, []mongo.IndexModel{
+					{Keys: bson.D{{Key: "color", Value: 1}}, Options: options.Index().SetName("cats_color")},
+					{Keys: bson.D{{Key: "breed", Value: 1}}, Options: options.Index().SetName("cats_breed")},
+					{Keys: bson.D{{Key: "tags", Value: 1}}, Options: options.Index().SetName("cats_tags")},
+					{Keys: bson.D{{Key: "vaccinations.name", Value: 1}}, Options: options.Index().SetName("cats_vaccinations")},
+					{Keys: bson.D{{Key: "birthDate", Value: 1}}, Options: options.Index().SetName("cats_birth_date")},
+					// releasing expired reservations scans reserved cats by expiration
+					{
+						Keys:    bson.D{{Key: "status", Value: 1}, {Key: "reservation.expiresAt", Value: 1}},
+						Options: options.Index().SetName("cats_status_reservation"),
+					},
+				})
+				return err
+			},
+		},
+		{
+			Version:     3,
+			Description: "create index of price history by cat",
+			Up: func(ctx context.Context) error {
+				_, err := priceHistory.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "catId", Value: 1}, {Key: "changedAt", Value: -1}},
+					Options: options.Index().SetName("price_history_cat"),
+				})
+				return err
+			},
+		},
+		{
+			Version:     4,
+			Description: "backfill status and price version of legacy cats",
+			Up: func(ctx context.Context) error {
+				// cats created before sale states and price versions were introduced lack both fields
+				_, err := cats.UpdateMany(ctx,
+					bson.M{"status": bson.M{"$exists": false}},
+					bson.M{"$set": bson.M{"status": entities.StatusAvailable}})
+				if err != nil {
+					return err
+				}
+				_, err = cats.UpdateMany(ctx,
+					bson.M{"priceVersion": bson.M{"$exists": false}},
+					bson.M{"$set": bson.M{"priceVersion": 1}})
+				return err
+			},
+		},
+		{
+			Version:     5,
+			Description: "validate cats with JSON schema",
+			Up: func(ctx context.Context) error {
+				return setValidator(ctx, mongoDB, "cats", catsSchema)
+			},
+		},
+		{
+			Version:     6,
+			Description: "backfill tenant of cats and price history",
+			Up: func(ctx context.Context) error {
+				// data created before multi-tenancy belongs to default tenant
+				for _, collection := range []*mongo.Collection{cats, priceHistory} {
+					_, err := collection.UpdateMany(ctx,
+						bson.M{"tenantId": bson.M{"$exists": false}},
+						bson.M{"$set": bson.M{"tenantId": tenant.Default}})
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+		{
+			Version:     7,
+			Description: "prefix indexes of cats and price history with tenant",
+			Up: func(ctx context.Context) error {
+				// a collection has at most one text index, so the old one goes first
+				if err := dropIndex(ctx, cats, "cats_text"); err != nil {
+					return err
+				}
+				_, err := cats.Indexes().CreateMany(ctx, []mongo.IndexModel{
+					{
+						Keys: bson.D{
+							{Key: "tenantId", Value: 1},
+							{Key: "name", Value: "text"},
+							{Key: "color", Value: "text"},
+							{Key: "breed", Value: "text"},
+							{Key: "description", Value: "text"},
+						},
+						Options: options.Index().
+							SetName("cats_tenant_text").
+							SetWeights(bson.M{"name": 10, "breed": 5, "color": 3, "description": 1}),
+					},
+					{Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "color", Value: 1}}, Options: options.Index().SetName("cats_tenant_color")},
+					{Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "breed", Value: 1}}, Options: options.Index().SetName("cats_tenant_breed")},
+					{Keys: bson.D{{Key: "tenantId", Value: 1}, {Key: "tags", Value: 1}}, Options: options.Index().SetName("cats_tenant_tags")},
+					{
+						Keys:    bson.D{{Key: "tenantId", Value: 1}, {Key: "status", Value: 1}, {Key: "reservation.expiresAt", Value: 1}},
+						Options: options.Index().SetName("cats_tenant_status_reservation"),
+					},
+				})
+				if err != nil {
+					return err
+				}
+				_, err = priceHistory.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "tenantId", Value: 1}, {Key: "catId", Value: 1}, {Key: "changedAt", Value: -1}},
+					Options: options.Index().SetName("price_history_tenant_cat"),
+				})
+				if err != nil {
+					return err
+				}
+				// indexes without tenant are covered by prefixed ones
+				for _, name := range []string{"cats_color", "cats_breed", "cats_tags", "cats_status_reservation"} {
+					if err := dropIndex(ctx, cats, name); err != nil {
+						return err
+					}
+				}
+				return dropIndex(ctx, priceHistory, "price_history_cat")
+			},
+		},
+		{
+			Version:     8,
+			Description: "require tenant of cats in JSON schema",
+			Up: func(ctx context.Context) error {
+				return setValidator(ctx, mongoDB, "cats", tenantCatsSchema())
+			},
+		},
+		{
+			Version:     9,
+			Description: "validate prices of cats as money in JSON schema",
+			Up: func(ctx context.Context) error {
+				// schema goes before conversion of prices, an update making a valid document invalid is rejected
+				return setValidator(ctx, mongoDB, "cats", moneyCatsSchema())
+			},
+		},
+		{
+			Version:     10,
+			Description: "convert prices of cats and price history to minor units of currency",
+			Up: func(ctx context.Context) error {
+				// prices stored before multi-currency support are floating point major units of USD,
+				// price history kept their currency in a separate field
+				for _, field := range []string{"price", "reservation.price", "sale.price"} {
+					_, err := cats.UpdateMany(ctx,
+						bson.M{field: bson.M{"$type": "number"}},
+						bson.A{bson.M{"$set": bson.M{field: legacyMoney("$"+field, money.DefaultCurrency)}}})
+					if err != nil {
+						return err
+					}
+				}
+				currency := bson.M{"$ifNull": bson.A{"$currency", money.DefaultCurrency}}
+				for _, field := range []string{"oldPrice", "newPrice"} {
+					_, err := priceHistory.UpdateMany(ctx,
+						bson.M{field: bson.M{"$type": "number"}},
+						bson.A{bson.M{"$set": bson.M{field: legacyMoney("$"+field, currency)}}})
+					if err != nil {
+						return err
+					}
+				}
+				_, err := priceHistory.UpdateMany(ctx,
+					bson.M{"currency": bson.M{"$exists": true}},
+					bson.M{"$unset": bson.M{"currency": ""}})
+				return err
+			},
+		},
+		{
+			Version:     11,
+			Description: "create index of due price schedules",
+			Up: func(ctx context.Context) error {
+				// scheduler looks up started pending schedules and ended promotions of a tenant
+				_, err := priceSchedules.Indexes().CreateMany(ctx, []mongo.IndexModel{
+					{
+						Keys:    bson.D{{Key: "tenantId", Value: 1}, {Key: "state", Value: 1}, {Key: "startsAt", Value: 1}},
+						Options: options.Index().SetName("price_schedules_tenant_state_starts"),
+					},
+					{
+						Keys:    bson.D{{Key: "tenantId", Value: 1}, {Key: "state", Value: 1}, {Key: "endsAt", Value: 1}},
+						Options: options.Index().SetName("price_schedules_tenant_state_ends"),
+					},
+				})
+				return err
+			},
+		},
+		{
+			Version:     12,
+			Description: "backfill listing time of cats",
+			Up: func(ctx context.Context) error {
+				// the first price change of a cat is recorded when it is added, so it is the best guess of its listing time
+				cursor, err := priceHistory.Aggregate(ctx, mongo.Pipeline{
+					{{Key: "$group", Value: bson.M{"_id": "$catId", "listedAt": bson.M{"$min": "$changedAt"}}}},
+					{{Key: "$merge", Value: bson.M{
+						"into":           "cats",
+						"on":             "_id",
+						"whenMatched":    bson.A{bson.M{"$set": bson.M{"listedAt": bson.M{"$ifNull": bson.A{"$listedAt", "$$new.listedAt"}}}}},
+						"whenNotMatched": "discard",
+					}}},
+				})
+				if err != nil {
+					return err
+				}
+				if err := cursor.Close(ctx); err != nil {
+					return err
+				}
+				// days listed of cats without price history count from the migration
+				_, err = cats.UpdateMany(ctx,
+					bson.M{"listedAt": bson.M{"$exists": false}},
+					bson.M{"$set": bson.M{"listedAt": time.Now().UTC()}})
+				return err
+			},
+		},
+		{
+			Version:     13,
+			Description: "expire idempotency keys and index price history by time for stores without redis",
+			Up: func(ctx context.Context) error {
+				_, err := idempotencyKeys.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+					Options: options.Index().SetName("idempotency_keys_expires").SetExpireAfterSeconds(0),
+				})
+				if err != nil {
+					return err
+				}
+				// price history is replayed in order of changes when price events are not kept in redis streams
+				_, err = priceHistory.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "tenantId", Value: 1}, {Key: "changedAt", Value: 1}, {Key: "_id", Value: 1}},
+					Options: options.Index().SetName("price_history_tenant_changed"),
+				})
+				return err
+			},
+		},
+		{
+			Version:     14,
+			Description: "index price schedules by state to find tenants with schedules to run",
+			Up: func(ctx context.Context) error {
+				_, err := priceSchedules.Indexes().CreateOne(ctx, mongo.IndexModel{
+					Keys:    bson.D{{Key: "state", Value: 1}, {Key: "tenantId", Value: 1}},
+					Options: options.Index().SetName("price_schedules_state_tenant"),
+				})
+				return err
+			},
+		},
+	}
+}
+
+// catsSchema requires fields every cat has since backfill, optional fields are checked only when present
+var catsSchema = bson.M{
+	"bsonType": "object",
+	"required": bson.A{"name", "color", "price", "status"},
+	"properties": bson.M{
+		"name":         bson.M{"bsonType": "string", "minLength": 1},
+		"color":        bson.M{"bsonType": "string", "minLength": 1},
+		"breed":        bson.M{"bsonType": "string"},
+		"sex":          bson.M{"enum": bson.A{entities.SexMale, entities.SexFemale}},
+		"birthDate":    bson.M{"bsonType": "date"},
+		"age":          bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0},
+		"description":  bson.M{"bsonType": "string"},
+		"tags":         bson.M{"bsonType": "array", "items": bson.M{"bsonType": "string"}},
+		"price":        bson.M{"bsonType": bson.A{"double", "int", "long"}, "minimum": 0},
+		"priceVersion": bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 1},
+		"status": bson.M{"enum": bson.A{
+			entities.StatusAvailable, entities.StatusReserved, entities.StatusSold,
+		}},
+		"vaccinations": bson.M{"bsonType": "array", "items": bson.M{
+			"bsonType": "object",
+			"required": bson.A{"name", "date"},
+		}},
+		"photos": bson.M{"bsonType": "array", "items": bson.M{"bsonType": "object"}},
+	},
+}
+
+// tenantCatsSchema is catsSchema requiring tenant, catsSchema is kept as it was applied by version 5
+func tenantCatsSchema() bson.M {
+	properties := bson.M{"tenantId": bson.M{"bsonType": "string", "minLength": 1}}
+	for name, property := range catsSchema["properties"].(bson.M) {
+		properties[name] = property
+	}
+	required := append(bson.A{"tenantId"}, catsSchema["required"].(bson.A)...)
+	return bson.M{"bsonType": catsSchema["bsonType"], "required": required, "properties": properties}
+}
+
+// moneyCatsSchema is tenantCatsSchema with prices as money, tenantCatsSchema is kept as it was applied by version 8
+func moneyCatsSchema() bson.M {
+	schema := tenantCatsSchema()
+	schema["properties"].(bson.M)["price"] = bson.M{
+		"bsonType": "object",
+		"required": bson.A{"amount", "currency"},
+		"properties": bson.M{
+			"amount":   bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0},
+			"currency": bson.M{"bsonType": "string", "pattern": "^[A-Z]{3}$"},
+		},
+	}
+	return schema
+}
+
+// legacyMoney is an aggregation expression converting floating point major units to money,
+// exponents of currencies are listed as they were when prices were converted, so the migration never changes
+func legacyMoney(amount, currency interface{}) bson.M {
+	scale := bson.M{"$switch": bson.M{
+		"branches": bson.A{
+			bson.M{"case": bson.M{"$in": bson.A{currency, bson.A{"CLP", "ISK", "JPY", "KRW", "VND"}}}, "then": 1},
+			bson.M{"case": bson.M{"$eq": bson.A{currency, "KWD"}}, "then": 1000},
+		},
+		"default": 100,
+	}}
+	return bson.M{
+		"amount":   bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{amount, scale}}, 0}}},
+		"currency": currency,
+	}
+}
+
+// dropIndex drops an index by name, an index that does not exist is not an error, so a failed migration can be retried
+func dropIndex(ctx context.Context, collection *mongo.Collection, name string) error {
+	_, err := collection.Indexes().DropOne(ctx, name)
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) && commandErr.Code == indexNotFoundCode {
+		return nil
+	}
+	return err
+}
+
+// indexNotFoundCode is a code of mongo error returned when dropped index does not exist
+const indexNotFoundCode = 27
+
+// setValidator sets JSON schema of a collection creating the collection if it does not exist yet.
+// Level is moderate, so documents that were invalid before are not blocked from updates fixing other fields.
+func setValidator(ctx context.Context, mongoDB *mongo.Database, collection string, schema bson.M) error {
+	validator := bson.M{"$jsonSchema": schema}
+	names, err := mongoDB.ListCollectionNames(ctx, bson.M{"name": collection})
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		opts := options.CreateCollection().SetValidator(validator).SetValidationLevel("moderate")
+		return mongoDB.CreateCollection(ctx, collection, opts)
+	}
+	return mongoDB.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: collection},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: "moderate"},
+	}).Err()
+}
@@ -0,0 +1,72 @@
+package migration
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// lockID identifies the only document of locks collection
+const lockID = "migrations"
+
+type mongoStore struct {
+	migrations *mongo.Collection
+	locks      *mongo.Collection
+}
+
+// NewMongoStore creates a store keeping applied migrations in migrations collection
+// and the lock in migration_locks collection
+func NewMongoStore(mongoDB *mongo.Database) Store {
+	return &mongoStore{
+		migrations: mongoDB.Collection("migrations"),
+		locks:      mongoDB.Collection("migration_locks"),
+	}
+}
+
+// Lock upserts the lock document when it is free, expired or already held by owner,
+// otherwise upsert conflicts with the existing document on _id
+func (s *mongoStore) Lock(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now().UTC()
+	filter := bson.M{
+		"_id": lockID,
+		"$or": bson.A{
+			bson.M{"owner": owner},
+			bson.M{"expiresAt": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"owner": owner, "expiresAt": now.Add(ttl)}}
+
+	_, err := s.locks.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *mongoStore) Unlock(ctx context.Context, owner string) error {
+	_, err := s.locks.DeleteOne(ctx, bson.M{"_id": lockID, "owner": owner})
+	return err
+}
+
+func (s *mongoStore) Applied(ctx context.Context) ([]Record, error) {
+	cursor, err := s.migrations.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	records := []Record{}
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (s *mongoStore) Record(ctx context.Context, record Record) error {
+	_, err := s.migrations.InsertOne(ctx, record)
+	return err
+}
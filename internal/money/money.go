@@ -0,0 +1,125 @@
+// Package money describes prices as integer amounts of minor units of ISO 4217 currencies
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is a currency of prices stored before multi-currency support
+const DefaultCurrency = "USD"
+
+// ErrUnknownCurrency means a currency code is not a supported ISO 4217 code
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// Money is an amount of minor units of a currency, e.g. 999 USD stands for $9.99.
+// Integer amounts keep sums and comparisons exact, unlike floating point major units.
+type Money struct {
+	Amount   int64  `bson:"amount"`
+	Currency string `bson:"currency"`
+}
+
+// exponents are numbers of minor unit digits of supported ISO 4217 currencies
+var exponents = map[string]int{
+	"AED": 2, "AUD": 2, "BGN": 2, "BRL": 2, "BYN": 2, "CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2, "CZK": 2,
+	"DKK": 2, "EUR": 2, "GBP": 2, "GEL": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2, "ISK": 0,
+	"JPY": 0, "KRW": 0, "KWD": 3, "KZT": 2, "MXN": 2, "NOK": 2, "NZD": 2, "PLN": 2, "RON": 2, "RUB": 2,
+	"SEK": 2, "SGD": 2, "THB": 2, "TRY": 2, "UAH": 2, "USD": 2, "VND": 0, "ZAR": 2,
+}
+
+// Exponent returns number of minor unit digits of a currency, e.g. 2 for USD and 0 for JPY
+func Exponent(currency string) (int, error) {
+	exponent, ok := exponents[currency]
+	if !ok {
+		return 0, fmt.Errorf("%w %q: must be an ISO 4217 code, e.g. %s", ErrUnknownCurrency, currency, DefaultCurrency)
+	}
+	return exponent, nil
+}
+
+// ValidateCurrency checks that currency is a supported ISO 4217 code
+func ValidateCurrency(currency string) error {
+	_, err := Exponent(currency)
+	return err
+}
+
+// FromMajor converts a floating point amount of major units, rounding it to the nearest minor unit.
+// It is meant for legacy values only, new amounts are parsed exactly by Parse.
+func FromMajor(amount float64, currency string) (Money, error) {
+	exponent, err := Exponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+	minor := math.Round(amount * math.Pow10(exponent))
+	if minor > math.MaxInt64 || minor < math.MinInt64 {
+		return Money{}, fmt.Errorf("amount %v %s is out of range", amount, currency)
+	}
+	return Money{Amount: int64(minor), Currency: currency}, nil
+}
+
+// Parse parses a decimal amount of major units exactly, e.g. "9.99" USD is 999 minor units.
+// Amounts with more fractional digits than the currency has are rejected rather than rounded.
+func Parse(amount, currency string) (Money, error) {
+	exponent, err := Exponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	unsigned := strings.TrimPrefix(amount, "-")
+	whole, fraction, hasFraction := unsigned, "", false
+	if dot := strings.IndexByte(unsigned, '.'); dot >= 0 {
+		whole, fraction, hasFraction = unsigned[:dot], unsigned[dot+1:], true
+	}
+	if !isDigits(whole) || (hasFraction && !isDigits(fraction)) {
+		return Money{}, fmt.Errorf("invalid amount %q", amount)
+	}
+	if len(fraction) > exponent {
+		return Money{}, fmt.Errorf("amount %q has more than %d fractional digits of %s", amount, exponent, currency)
+	}
+	minor, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", exponent-len(fraction)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("amount %q is out of range", amount)
+	}
+	if unsigned != amount {
+		minor = -minor
+	}
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Major returns the amount in major units. It is approximate and meant only for consumers of legacy formats.
+func (m Money) Major() float64 {
+	exponent, err := Exponent(m.Currency)
+	if err != nil {
+		return float64(m.Amount)
+	}
+	return float64(m.Amount) / math.Pow10(exponent)
+}
+
+// String formats money as a decimal amount of major units followed by currency, e.g. "9.99 USD"
+func (m Money) String() string {
+	exponent, err := Exponent(m.Currency)
+	if err != nil || exponent == 0 {
+		return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+	}
+
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	scale := int64(math.Pow10(exponent))
+	return fmt.Sprintf("%s%d.%0*d %s", sign, amount/scale, exponent, amount%scale, m.Currency)
+}
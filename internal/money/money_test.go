@@ -0,0 +1,123 @@
+package money
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for amount, expected := range map[string]Money{
+		"9.99": {Amount: 999, Currency: "USD"},
+		"9.9":  {Amount: 990, Currency: "USD"},
+		"9":    {Amount: 900, Currency: "USD"},
+		"0.01": {Amount: 1, Currency: "USD"},
+		"-1.5": {Amount: -150, Currency: "USD"},
+	} {
+		// Act
+		actual, err := Parse(amount, "USD")
+
+		// Assert
+		require.NoError(t, err, amount)
+		require.Equal(t, expected, actual, amount)
+	}
+}
+
+func TestParseRejectsInexactAmounts(t *testing.T) {
+	for _, amount := range []string{"", ".5", "9.", "9.999", "1e3", "+1", "9,99", "--1", "99999999999999999999"} {
+		// Act
+		_, err := Parse(amount, "USD")
+
+		// Assert
+		require.Error(t, err, amount)
+	}
+}
+
+func TestParseUnknownCurrency(t *testing.T) {
+	// Act
+	_, err := Parse("1", "usd")
+
+	// Assert
+	require.ErrorIs(t, err, ErrUnknownCurrency)
+}
+
+func TestFromMajor(t *testing.T) {
+	// Act
+	usd, err := FromMajor(120.5, "USD")
+	require.NoError(t, err)
+	rounded, err := FromMajor(0.1+0.2, "USD")
+	require.NoError(t, err)
+	yen, err := FromMajor(1500, "JPY")
+	require.NoError(t, err)
+
+	// Assert
+	require.Equal(t, Money{Amount: 12050, Currency: "USD"}, usd)
+	require.Equal(t, Money{Amount: 30, Currency: "USD"}, rounded)
+	require.Equal(t, Money{Amount: 1500, Currency: "JPY"}, yen)
+}
+
+func TestString(t *testing.T) {
+	require.Equal(t, "9.99 USD", Money{Amount: 999, Currency: "USD"}.String())
+	require.Equal(t, "-0.05 EUR", Money{Amount: -5, Currency: "EUR"}.String())
+	require.Equal(t, "1500 JPY", Money{Amount: 1500, Currency: "JPY"}.String())
+	require.Equal(t, "1.234 KWD", Money{Amount: 1234, Currency: "KWD"}.String())
+}
+
+func TestConvert(t *testing.T) {
+	// Arrange
+	rates, err := ParseRates([]byte(`{"base": "USD", "rates": {"EUR": 0.92, "JPY": 146.5, "KWD": "0.307"}}`))
+	require.NoError(t, err)
+	c := NewConverter(rates)
+
+	for _, test := range []struct {
+		from     Money
+		currency string
+		expected Money
+	}{
+		{Money{Amount: 999, Currency: "USD"}, "USD", Money{Amount: 999, Currency: "USD"}},
+		{Money{Amount: 999, Currency: "USD"}, "EUR", Money{Amount: 919, Currency: "EUR"}},
+		{Money{Amount: 1000, Currency: "USD"}, "JPY", Money{Amount: 1465, Currency: "JPY"}},
+		{Money{Amount: 1465, Currency: "JPY"}, "USD", Money{Amount: 1000, Currency: "USD"}},
+		{Money{Amount: 1000, Currency: "EUR"}, "KWD", Money{Amount: 3337, Currency: "KWD"}},
+		{Money{Amount: 50, Currency: "USD"}, "JPY", Money{Amount: 73, Currency: "JPY"}},
+	} {
+		// Act
+		actual, err := c.Convert(context.Background(), test.from, test.currency)
+
+		// Assert
+		require.NoError(t, err)
+		require.Equal(t, test.expected, actual, "%s to %s", test.from, test.currency)
+	}
+}
+
+func TestConvertWithoutRate(t *testing.T) {
+	// Arrange
+	rates := Rates{Base: "USD", Rates: map[string]*big.Rat{"EUR": big.NewRat(92, 100)}}
+
+	// Act
+	_, noRateErr := NewConverter(rates).Convert(context.Background(), Money{Amount: 1, Currency: "USD"}, "GBP")
+	_, noProviderErr := NewConverter(nil).Convert(context.Background(), Money{Amount: 1, Currency: "USD"}, "EUR")
+	_, unknownErr := NewConverter(rates).Convert(context.Background(), Money{Amount: 1, Currency: "USD"}, "XXX")
+
+	// Assert
+	require.ErrorIs(t, noRateErr, ErrNoRate)
+	require.ErrorIs(t, noProviderErr, ErrNoRate)
+	require.ErrorIs(t, unknownErr, ErrUnknownCurrency)
+}
+
+func TestParseRatesRejectsInvalidRate(t *testing.T) {
+	for _, data := range []string{
+		`{"base": "usd", "rates": {}}`,
+		`{"base": "USD", "rates": {"EUR": 0}}`,
+		`{"base": "USD", "rates": {"XXX": 1}}`,
+		`{"base": "USD", "rates": {"EUR": "abc"}}`,
+	} {
+		// Act
+		_, err := ParseRates([]byte(data))
+
+		// Assert
+		require.Error(t, err, data)
+	}
+}
@@ -0,0 +1,175 @@
+package money
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+)
+
+// ErrNoRate means there is no exchange rate between two currencies
+var ErrNoRate = errors.New("no exchange rate")
+
+// RateProvider provides exchange rates, e.g. from a file or an external service
+type RateProvider interface {
+	// Rate returns how many major units of currency to one major unit of currency from is worth
+	Rate(ctx context.Context, from, to string) (*big.Rat, error)
+}
+
+// Rates are exchange rates of currencies to a base currency
+type Rates struct {
+	Base  string
+	Rates map[string]*big.Rat
+}
+
+// Rate returns a cross rate through the base currency
+func (r Rates) Rate(_ context.Context, from, to string) (*big.Rat, error) {
+	fromRate, ok := r.baseRate(from)
+	if !ok {
+		return nil, fmt.Errorf("%w from %s to %s", ErrNoRate, from, to)
+	}
+	toRate, ok := r.baseRate(to)
+	if !ok {
+		return nil, fmt.Errorf("%w from %s to %s", ErrNoRate, from, to)
+	}
+	return new(big.Rat).Quo(toRate, fromRate), nil
+}
+
+func (r Rates) baseRate(currency string) (*big.Rat, bool) {
+	if currency == r.Base {
+		return big.NewRat(1, 1), true
+	}
+	rate, ok := r.Rates[currency]
+	return rate, ok
+}
+
+// ParseRates parses rates file, e.g. {"base": "USD", "rates": {"EUR": 0.92, "JPY": 146.5}}.
+// Rates are parsed as exact decimals, they tell how much of a currency one unit of base is worth.
+func ParseRates(data []byte) (Rates, error) {
+	var file struct {
+		Base  string                 `json:"base"`
+		Rates map[string]json.Number `json:"rates"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return Rates{}, fmt.Errorf("cannot parse rates: %w", err)
+	}
+	if err := ValidateCurrency(file.Base); err != nil {
+		return Rates{}, fmt.Errorf("base of rates: %w", err)
+	}
+
+	rates := Rates{Base: file.Base, Rates: make(map[string]*big.Rat, len(file.Rates))}
+	for currency, value := range file.Rates {
+		if err := ValidateCurrency(currency); err != nil {
+			return Rates{}, err
+		}
+		rate, ok := new(big.Rat).SetString(value.String())
+		if !ok || rate.Sign() <= 0 {
+			return Rates{}, fmt.Errorf("rate of %s must be a positive number, got %s", currency, value)
+		}
+		rates.Rates[currency] = rate
+	}
+	return rates, nil
+}
+
+// FileRates provides rates read from a file, so prices can be converted offline.
+// The file is read once and again on Reload, e.g. on SIGHUP.
+type FileRates struct {
+	path string
+
+	mu    sync.RWMutex
+	rates Rates
+}
+
+// NewFileRates reads rates from a file, see ParseRates for its format
+func NewFileRates(path string) (*FileRates, error) {
+	r := &FileRates{path: path}
+	return r, r.Reload()
+}
+
+// Reload reads the file again, current rates are kept if it is invalid
+func (r *FileRates) Reload() error {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return err
+	}
+	rates, err := ParseRates(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", r.path, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rates = rates
+	return nil
+}
+
+// Rate returns a cross rate of the last read rates
+func (r *FileRates) Rate(ctx context.Context, from, to string) (*big.Rat, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.rates.Rate(ctx, from, to)
+}
+
+// Converter converts money between currencies with rates of a provider
+type Converter struct {
+	rates RateProvider
+}
+
+// NewConverter creates a converter, nil provider means only conversion to the same currency is possible
+func NewConverter(rates RateProvider) *Converter {
+	return &Converter{rates: rates}
+}
+
+// Convert converts money to currency rounding half away from zero to the nearest minor unit of currency
+func (c *Converter) Convert(ctx context.Context, m Money, currency string) (Money, error) {
+	toExponent, err := Exponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+	if m.Currency == currency {
+		return m, nil
+	}
+	fromExponent, err := Exponent(m.Currency)
+	if err != nil {
+		return Money{}, err
+	}
+	if c.rates == nil {
+		return Money{}, fmt.Errorf("%w from %s to %s", ErrNoRate, m.Currency, currency)
+	}
+	rate, err := c.rates.Rate(ctx, m.Currency, currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	amount := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), rate)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(toExponent-fromExponent))), nil))
+	if toExponent > fromExponent {
+		amount.Mul(amount, scale)
+	} else {
+		amount.Quo(amount, scale)
+	}
+	minor := roundHalfAwayFromZero(amount)
+	if !minor.IsInt64() {
+		return Money{}, fmt.Errorf("%s converted to %s is out of range", m, currency)
+	}
+	return Money{Amount: minor.Int64(), Currency: currency}, nil
+}
+
+func roundHalfAwayFromZero(r *big.Rat) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	// remainder has sign of numerator, rounding goes away from zero when it is at least half of denominator
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(r.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(r.Sign())))
+	}
+	return quotient
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
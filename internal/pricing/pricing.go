@@ -0,0 +1,253 @@
+// Package pricing evaluates pricing rules configured as data, e.g. a JSON or YAML file
+package pricing
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/evleria/cats-app/internal/money"
+)
+
+// Mode tells whether a price of a rule is set automatically or only suggested
+type Mode string
+
+const (
+	// ModeSuggest rules only show up in previews, prices are changed by hand
+	ModeSuggest Mode = "suggest"
+	// ModeAuto rules change prices on their own
+	ModeAuto Mode = "auto"
+)
+
+// Facts are properties of a cat rules are matched against
+type Facts struct {
+	Age        int
+	DaysListed int
+	Views      int64
+	Tags       []string
+}
+
+// Conditions must all hold for a rule to match, nil and empty conditions always hold
+type Conditions struct {
+	MinAge        *int     `yaml:"minAge"`
+	MaxAge        *int     `yaml:"maxAge"`
+	MinDaysListed *int     `yaml:"minDaysListed"`
+	MaxDaysListed *int     `yaml:"maxDaysListed"`
+	MinViews      *int64   `yaml:"minViews"`
+	MaxViews      *int64   `yaml:"maxViews"`
+	Tags          []string `yaml:"tags"`
+}
+
+// Rule computes a price of cats matching its conditions, either by adjusting a base price by a percent or setting a fixed price
+type Rule struct {
+	Name          string       `yaml:"name"`
+	Priority      int          `yaml:"priority"`
+	Mode          Mode         `yaml:"mode"`
+	When          Conditions   `yaml:"when"`
+	AdjustPercent int          `yaml:"adjustPercent"`
+	Price         *money.Money `yaml:"price"`
+}
+
+// Match tells whether facts meet conditions of the rule and describes every condition that holds
+func (r Rule) Match(facts Facts) ([]string, bool) {
+	var reasons []string
+	check := func(holds bool, reason string) bool {
+		if holds {
+			reasons = append(reasons, reason)
+		}
+		return holds
+	}
+	w := r.When
+	ok := (w.MinAge == nil || check(facts.Age >= *w.MinAge, fmt.Sprintf("age %d is at least %d", facts.Age, *w.MinAge))) &&
+		(w.MaxAge == nil || check(facts.Age <= *w.MaxAge, fmt.Sprintf("age %d is at most %d", facts.Age, *w.MaxAge))) &&
+		(w.MinDaysListed == nil || check(facts.DaysListed >= *w.MinDaysListed,
+			fmt.Sprintf("listed for %d days, at least %d", facts.DaysListed, *w.MinDaysListed))) &&
+		(w.MaxDaysListed == nil || check(facts.DaysListed <= *w.MaxDaysListed,
+			fmt.Sprintf("listed for %d days, at most %d", facts.DaysListed, *w.MaxDaysListed))) &&
+		(w.MinViews == nil || check(facts.Views >= *w.MinViews, fmt.Sprintf("viewed %d times, at least %d", facts.Views, *w.MinViews))) &&
+		(w.MaxViews == nil || check(facts.Views <= *w.MaxViews, fmt.Sprintf("viewed %d times, at most %d", facts.Views, *w.MaxViews)))
+	if !ok {
+		return nil, false
+	}
+	for _, tag := range w.Tags {
+		if !hasTag(facts.Tags, tag) {
+			return nil, false
+		}
+		reasons = append(reasons, fmt.Sprintf("tagged %s", tag))
+	}
+	return reasons, true
+}
+
+// Apply returns a price of the rule for a base price, adjustments round half away from zero to a minor unit.
+// A fixed price in another currency than the base price is not applicable.
+func (r Rule) Apply(base money.Money) (money.Money, bool) {
+	if r.Price != nil {
+		return *r.Price, r.Price.Currency == base.Currency
+	}
+	amount := base.Amount * int64(100+r.AdjustPercent)
+	return money.Money{Amount: (amount + 50) / 100, Currency: base.Currency}, true
+}
+
+// Describe describes a price change made by the rule
+func (r Rule) Describe() string {
+	if r.Price != nil {
+		return fmt.Sprintf("rule %s sets price to %s", r.Name, r.Price)
+	}
+	return fmt.Sprintf("rule %s adjusts base price by %+d%%", r.Name, r.AdjustPercent)
+}
+
+// Provider provides current pricing rules, e.g. read from a file
+type Provider interface {
+	Rules() Rules
+}
+
+// Rules are pricing rules ordered by priority, the first matching rule wins
+type Rules []Rule
+
+// Rules returns rules themselves, so fixed rules are a Provider
+func (r Rules) Rules() Rules {
+	return r
+}
+
+// OfMode returns rules of given mode keeping their priority order
+func (r Rules) OfMode(mode Mode) Rules {
+	rules := make(Rules, 0, len(r))
+	for _, rule := range r {
+		if rule.Mode == mode {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// Evaluate returns the first rule matching facts along with conditions that made it match
+func (r Rules) Evaluate(facts Facts) (Rule, []string, bool) {
+	for _, rule := range r {
+		if reasons, ok := rule.Match(facts); ok {
+			return rule, reasons, true
+		}
+	}
+	return Rule{}, nil, false
+}
+
+// ParseRules parses rules file, e.g. {"rules": [{"name": "senior", "priority": 10, "mode": "auto",
+// "when": {"minAge": 8}, "adjustPercent": -20}]}. YAML is accepted as well as JSON, which is a subset of it.
+// Prices are minor units of currency as in API, mode defaults to suggest, rules of equal priority keep file order.
+func ParseRules(data []byte) (Rules, error) {
+	var file struct {
+		Rules Rules `yaml:"rules"`
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("cannot parse pricing rules: %w", err)
+	}
+
+	names := make(map[string]bool, len(file.Rules))
+	for i := range file.Rules {
+		rule := &file.Rules[i]
+		if rule.Mode == "" {
+			rule.Mode = ModeSuggest
+		}
+		if err := validateRule(*rule); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("rule %d: name %q is not unique", i+1, rule.Name)
+		}
+		names[rule.Name] = true
+	}
+	sort.SliceStable(file.Rules, func(i, j int) bool {
+		return file.Rules[i].Priority > file.Rules[j].Priority
+	})
+	return file.Rules, nil
+}
+
+func validateRule(rule Rule) error {
+	if strings.TrimSpace(rule.Name) == "" {
+		return errors.New("name must not be empty")
+	}
+	if rule.Mode != ModeSuggest && rule.Mode != ModeAuto {
+		return fmt.Errorf("mode must be %s or %s, got %q", ModeSuggest, ModeAuto, rule.Mode)
+	}
+	if (rule.Price == nil) == (rule.AdjustPercent == 0) {
+		return errors.New("exactly one of adjustPercent and price must be given")
+	}
+	if rule.AdjustPercent <= -100 {
+		return errors.New("adjustPercent must be greater than -100")
+	}
+	if rule.Price != nil {
+		if rule.Price.Amount < 0 {
+			return errors.New("price must not be negative")
+		}
+		if err := money.ValidateCurrency(rule.Price.Currency); err != nil {
+			return fmt.Errorf("price: %w", err)
+		}
+	}
+	w := rule.When
+	if w.MinAge != nil && w.MaxAge != nil && *w.MinAge > *w.MaxAge {
+		return errors.New("minAge must not be greater than maxAge")
+	}
+	if w.MinDaysListed != nil && w.MaxDaysListed != nil && *w.MinDaysListed > *w.MaxDaysListed {
+		return errors.New("minDaysListed must not be greater than maxDaysListed")
+	}
+	if w.MinViews != nil && w.MaxViews != nil && *w.MinViews > *w.MaxViews {
+		return errors.New("minViews must not be greater than maxViews")
+	}
+	return nil
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// FileRules provides rules read from a file, so prices can be tuned without a deploy.
+// The file is read once and again on Reload, e.g. on SIGHUP.
+type FileRules struct {
+	path string
+
+	mu    sync.RWMutex
+	rules Rules
+}
+
+// NewFileRules reads rules from a file, see ParseRules for its format
+func NewFileRules(path string) (*FileRules, error) {
+	r := &FileRules{path: path}
+	return r, r.Reload()
+}
+
+// Reload reads the file again, current rules are kept if it is invalid
+func (r *FileRules) Reload() error {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return err
+	}
+	rules, err := ParseRules(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", r.path, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = rules
+	return nil
+}
+
+// Rules returns the last read rules
+func (r *FileRules) Rules() Rules {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.rules
+}
@@ -0,0 +1,132 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/money"
+)
+
+func TestParseRulesJSON(t *testing.T) {
+	// Arrange
+	data := []byte(`{"rules": [
+		{"name": "stale", "priority": 1, "mode": "auto", "when": {"minDaysListed": 60}, "adjustPercent": -10},
+		{"name": "senior", "priority": 10, "when": {"minAge": 8, "tags": ["calm"]}, "price": {"amount": 4999, "currency": "USD"}}
+	]}`)
+
+	// Act
+	rules, err := ParseRules(data)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	require.Equal(t, "senior", rules[0].Name)
+	require.Equal(t, ModeSuggest, rules[0].Mode)
+	require.Equal(t, &money.Money{Amount: 4999, Currency: "USD"}, rules[0].Price)
+	require.Equal(t, "stale", rules[1].Name)
+	require.Equal(t, ModeAuto, rules[1].Mode)
+}
+
+func TestParseRulesYAML(t *testing.T) {
+	// Arrange
+	data := []byte(`
+rules:
+  - name: popular
+    mode: auto
+    when:
+      minViews: 100
+      maxDaysListed: 7
+    adjustPercent: 15
+`)
+
+	// Act
+	rules, err := ParseRules(data)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, int64(100), *rules[0].When.MinViews)
+	require.Equal(t, 7, *rules[0].When.MaxDaysListed)
+	require.Equal(t, 15, rules[0].AdjustPercent)
+}
+
+func TestParseRulesRejectsInvalidRules(t *testing.T) {
+	for _, data := range []string{
+		`{"rules": [{"name": "", "adjustPercent": 5}]}`,
+		`{"rules": [{"name": "a", "mode": "sometimes", "adjustPercent": 5}]}`,
+		`{"rules": [{"name": "a"}]}`,
+		`{"rules": [{"name": "a", "adjustPercent": 5, "price": {"amount": 100, "currency": "USD"}}]}`,
+		`{"rules": [{"name": "a", "adjustPercent": -100}]}`,
+		`{"rules": [{"name": "a", "price": {"amount": 100, "currency": "usd"}}]}`,
+		`{"rules": [{"name": "a", "when": {"minAge": 5, "maxAge": 3}, "adjustPercent": 5}]}`,
+		`{"rules": [{"name": "a", "adjustPercent": 5}, {"name": "a", "adjustPercent": 6}]}`,
+		`{"rules": [{"name": "a", "when": {"minAgee": 5}, "adjustPercent": 5}]}`,
+	} {
+		// Act
+		_, err := ParseRules([]byte(data))
+
+		// Assert
+		require.Error(t, err, data)
+	}
+}
+
+func TestEvaluateFirstMatchingRule(t *testing.T) {
+	// Arrange
+	minAge, minViews := 8, int64(50)
+	rules := Rules{
+		{Name: "senior", When: Conditions{MinAge: &minAge, Tags: []string{"calm"}}, AdjustPercent: -20},
+		{Name: "popular", When: Conditions{MinViews: &minViews}, AdjustPercent: 10},
+	}
+	facts := Facts{Age: 9, Views: 70, Tags: []string{"Calm"}}
+
+	// Act
+	rule, reasons, ok := rules.Evaluate(facts)
+
+	// Assert
+	require.True(t, ok)
+	require.Equal(t, "senior", rule.Name)
+	require.Equal(t, []string{"age 9 is at least 8", "tagged calm"}, reasons)
+}
+
+func TestEvaluateNoMatchingRule(t *testing.T) {
+	// Arrange
+	maxDays := 7
+	rules := Rules{{Name: "new", When: Conditions{MaxDaysListed: &maxDays}, AdjustPercent: 10}}
+
+	// Act
+	_, _, ok := rules.Evaluate(Facts{DaysListed: 8})
+
+	// Assert
+	require.False(t, ok)
+}
+
+func TestOfMode(t *testing.T) {
+	// Arrange
+	rules := Rules{
+		{Name: "calm", Mode: ModeSuggest, AdjustPercent: 10},
+		{Name: "senior", Mode: ModeAuto, AdjustPercent: -20},
+		{Name: "stale", Mode: ModeAuto, AdjustPercent: -10},
+	}
+
+	// Act
+	auto := rules.OfMode(ModeAuto)
+
+	// Assert
+	require.Equal(t, Rules{rules[1], rules[2]}, auto)
+}
+
+func TestApply(t *testing.T) {
+	// Arrange
+	base := money.Money{Amount: 999, Currency: "USD"}
+
+	// Act
+	discounted, discountOK := Rule{AdjustPercent: -15}.Apply(base)
+	fixed, fixedOK := Rule{Price: &money.Money{Amount: 500, Currency: "EUR"}}.Apply(base)
+
+	// Assert
+	require.True(t, discountOK)
+	require.Equal(t, money.Money{Amount: 849, Currency: "USD"}, discounted)
+	require.False(t, fixedOK)
+	require.Equal(t, money.Money{Amount: 500, Currency: "EUR"}, fixed)
+}
@@ -0,0 +1,48 @@
+package producer
+
+import (
+	"fmt"
+
+	"github.com/evleria/cats-app/internal/broker"
+	"github.com/evleria/cats-app/internal/event"
+)
+
+const (
+	// PriceTopic is a name of stream, exchange or subject price events of default tenant are produced to,
+	// events of other tenants go to topics named by tenant.Topic
+	PriceTopic = "price"
+	// StatusTopic is a name of stream, exchange or subject status messages of default tenant are produced to
+	StatusTopic = "status"
+)
+
+// NewPriceProducer creates price producer for given broker backend, retentions apply to redis streams only and are optional
+func NewPriceProducer(kind broker.Kind, conns broker.Connections, codec *event.Codec, retentions Retentions) (Price, error) {
+	switch kind {
+	case broker.RedisRabbit, broker.Redis:
+		return NewRedisPriceProducer(conns.Redis, codec, retentions), nil
+	case broker.Rabbit:
+		return NewRabbitPriceProducer(conns.Rabbit, PriceTopic, codec)
+	case broker.NATS:
+		return NewNATSPriceProducer(conns.NATS, PriceTopic, codec), nil
+	case broker.InProcess:
+		return NewMemoryPriceProducer(conns.InProcess, PriceTopic, codec), nil
+	default:
+		return nil, fmt.Errorf("unknown broker %q", kind)
+	}
+}
+
+// NewStatusProducer creates status producer for given broker backend
+func NewStatusProducer(kind broker.Kind, conns broker.Connections) (Status, error) {
+	switch kind {
+	case broker.RedisRabbit, broker.Redis:
+		return NewRedisStatusProducer(conns.Redis), nil
+	case broker.Rabbit:
+		return NewRabbitStatusProducer(conns.Rabbit), nil
+	case broker.NATS:
+		return NewNATSStatusProducer(conns.NATS), nil
+	case broker.InProcess:
+		return NewMemoryStatusProducer(conns.InProcess), nil
+	default:
+		return nil, fmt.Errorf("unknown broker %q", kind)
+	}
+}
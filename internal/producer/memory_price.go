@@ -0,0 +1,36 @@
+package producer
+
+import (
+	"context"
+
+	"github.com/evleria/cats-app/internal/broker"
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+type memoryPrice struct {
+	memory  *broker.Memory
+	subject string
+	codec   *event.Codec
+}
+
+// NewMemoryPriceProducer creates a new producer delivering events within the process
+func NewMemoryPriceProducer(memory *broker.Memory, subject string, codec *event.Codec) Price {
+	return &memoryPrice{
+		memory:  memory,
+		subject: subject,
+		codec:   codec,
+	}
+}
+
+func (p *memoryPrice) Produce(_ context.Context, e event.PriceChanged) error {
+	bytes, err := p.codec.EncodePriceChanged(e)
+	if err != nil {
+		return err
+	}
+
+	logging.Debugf("producing message in process: {%v, %v, %v}\n", e.ID, e.CatID, e.NewPrice)
+	p.memory.Publish(tenant.Topic(p.subject, e.TenantID), bytes)
+	return nil
+}
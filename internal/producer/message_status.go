@@ -0,0 +1,86 @@
+package producer
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/streadway/amqp"
+
+	"github.com/evleria/cats-app/internal/broker"
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+// statusMessage is a body of status messages of brokers without redis streams, it has the fields of redis status entries
+type statusMessage struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// messageStatus produces status messages as JSON bodies published to topic of a tenant
+type messageStatus struct {
+	backend string
+	publish func(topic string, body []byte) error
+}
+
+// NewMemoryStatusProducer creates a new producer delivering status messages within the process
+func NewMemoryStatusProducer(memory *broker.Memory) Status {
+	return &messageStatus{
+		backend: "in process",
+		publish: func(topic string, body []byte) error {
+			memory.Publish(topic, body)
+			return nil
+		},
+	}
+}
+
+// NewNATSStatusProducer creates a new producer publishing status messages to NATS subject of tenant
+func NewNATSStatusProducer(conn *nats.Conn) Status {
+	return &messageStatus{
+		backend: "to nats",
+		publish: conn.Publish,
+	}
+}
+
+// NewRabbitStatusProducer creates a new producer publishing status messages to fanout exchange of tenant,
+// exchanges are declared on their first message
+func NewRabbitStatusProducer(channel *amqp.Channel) Status {
+	var (
+		mu       sync.Mutex
+		declared = make(map[string]bool)
+	)
+	return &messageStatus{
+		backend: "to rabbit",
+		publish: func(exchange string, body []byte) error {
+			mu.Lock()
+			if !declared[exchange] {
+				err := channel.ExchangeDeclare(exchange, amqp.ExchangeFanout, true, false, false, false, nil)
+				if err != nil {
+					mu.Unlock()
+					return err
+				}
+				declared[exchange] = true
+			}
+			mu.Unlock()
+			return channel.Publish(exchange, "", false, false, amqp.Publishing{ContentType: "application/json", Body: body})
+		},
+	}
+}
+
+// Produce publishes status message to status topic of tenant carried by ctx
+func (p *messageStatus) Produce(ctx context.Context, id uuid.UUID, status string) error {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(statusMessage{ID: id.String(), Status: status})
+	if err != nil {
+		return err
+	}
+
+	logging.Debugf("producing status message %s: {%v, %s}\n", p.backend, id, status)
+	return p.publish(tenant.Topic(StatusTopic, tenantID), body)
+}
@@ -5,8 +5,9 @@ package producer
 import (
 	context "context"
 
-	uuid "github.com/google/uuid"
 	mock "github.com/stretchr/testify/mock"
+
+	event "github.com/evleria/cats-app/internal/event"
 )
 
 // MockPrice is an autogenerated mock type for the Price type
@@ -14,13 +15,13 @@ type MockPrice struct {
 	mock.Mock
 }
 
-// Produce provides a mock function with given fields: ctx, id, price
-func (_m *MockPrice) Produce(ctx context.Context, id uuid.UUID, price float64) error {
-	ret := _m.Called(ctx, id, price)
+// Produce provides a mock function with given fields: ctx, e
+func (_m *MockPrice) Produce(ctx context.Context, e event.PriceChanged) error {
+	ret := _m.Called(ctx, e)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, float64) error); ok {
-		r0 = rf(ctx, id, price)
+	if rf, ok := ret.Get(0).(func(context.Context, event.PriceChanged) error); ok {
+		r0 = rf(ctx, e)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -0,0 +1,29 @@
+// Code generated by mockery v0.0.0-dev. DO NOT EDIT.
+
+package producer
+
+import (
+	context "context"
+
+	uuid "github.com/google/uuid"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockStatus is an autogenerated mock type for the Status type
+type MockStatus struct {
+	mock.Mock
+}
+
+// Produce provides a mock function with given fields: ctx, id, status
+func (_m *MockStatus) Produce(ctx context.Context, id uuid.UUID, status string) error {
+	ret := _m.Called(ctx, id, status)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) error); ok {
+		r0 = rf(ctx, id, status)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,36 @@
+package producer
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+type natsPrice struct {
+	conn    *nats.Conn
+	subject string
+	codec   *event.Codec
+}
+
+// NewNATSPriceProducer creates a new producer publishing to NATS subject of event tenant
+func NewNATSPriceProducer(conn *nats.Conn, subject string, codec *event.Codec) Price {
+	return &natsPrice{
+		conn:    conn,
+		subject: subject,
+		codec:   codec,
+	}
+}
+
+func (p *natsPrice) Produce(_ context.Context, e event.PriceChanged) error {
+	bytes, err := p.codec.EncodePriceChanged(e)
+	if err != nil {
+		return err
+	}
+
+	logging.Debugf("producing message to nats: {%v, %v, %v}\n", e.ID, e.CatID, e.NewPrice)
+	return p.conn.Publish(tenant.Topic(p.subject, e.TenantID), bytes)
+}
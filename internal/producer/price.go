@@ -4,10 +4,10 @@ package producer
 import (
 	"context"
 
-	"github.com/google/uuid"
+	"github.com/evleria/cats-app/internal/event"
 )
 
 // Price provides producing to price stream
 type Price interface {
-	Produce(ctx context.Context, id uuid.UUID, price float64) error
+	Produce(ctx context.Context, e event.PriceChanged) error
 }
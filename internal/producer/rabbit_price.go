@@ -2,54 +2,73 @@ package producer
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+	"sync"
 
-	"github.com/google/uuid"
 	"github.com/streadway/amqp"
+
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/tenant"
 )
 
 type rabbitPrice struct {
 	channel      *amqp.Channel
 	exchangeName string
-}
+	codec        *event.Codec
 
-// NewRabbitPriceProducer creates a new producer for rabbitMQ
-func NewRabbitPriceProducer(channel *amqp.Channel, exchangeName string) (Price, error) {
-	err := channel.ExchangeDeclare(exchangeName, amqp.ExchangeFanout, true, false, false, false, nil)
-	if err != nil {
-		return nil, err
-	}
+	mu       sync.Mutex
+	declared map[string]bool
+}
 
-	return &rabbitPrice{
+// NewRabbitPriceProducer creates a new producer for rabbitMQ, events are published to fanout exchange of their tenant.
+// Exchange of default tenant is declared right away, exchanges of other tenants on their first event.
+func NewRabbitPriceProducer(channel *amqp.Channel, exchangeName string, codec *event.Codec) (Price, error) {
+	p := &rabbitPrice{
 		channel:      channel,
 		exchangeName: exchangeName,
-	}, nil
+		codec:        codec,
+		declared:     make(map[string]bool),
+	}
+	if err := p.declare(exchangeName); err != nil {
+		return nil, err
+	}
+	return p, nil
 }
 
-func (r *rabbitPrice) Produce(_ context.Context, id uuid.UUID, price float64) error {
-	msg := message{
-		ID:    id.String(),
-		Price: price,
-	}
-	bytes, err := json.Marshal(msg)
+func (r *rabbitPrice) Produce(_ context.Context, e event.PriceChanged) error {
+	bytes, err := r.codec.EncodePriceChanged(e)
 	if err != nil {
 		return err
 	}
+	exchange := tenant.Topic(r.exchangeName, e.TenantID)
+	if err := r.declare(exchange); err != nil {
+		return err
+	}
 
-	fmt.Printf("producing message to rabbit: {%v, %f}\n", id, price)
+	logging.Debugf("producing message to rabbit: {%v, %v, %v}\n", e.ID, e.CatID, e.NewPrice)
 	return r.channel.Publish(
-		r.exchangeName,
+		exchange,
 		"",
 		false,
 		false,
 		amqp.Publishing{
-			ContentType: "application/json",
+			ContentType: event.ContentType,
+			MessageId:   e.ID.String(),
+			Timestamp:   e.OccurredAt,
 			Body:        bytes,
 		})
 }
 
-type message struct {
-	ID    string  `json:"id"`
-	Price float64 `json:"price"`
+func (r *rabbitPrice) declare(exchange string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.declared[exchange] {
+		return nil
+	}
+	err := r.channel.ExchangeDeclare(exchange, amqp.ExchangeFanout, true, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	r.declared[exchange] = true
+	return nil
 }
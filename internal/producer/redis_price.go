@@ -2,31 +2,46 @@ package producer
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/go-redis/redis/v8"
-	"github.com/google/uuid"
+
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/tenant"
 )
 
 type redisPrice struct {
-	redis *redis.Client
+	redis      *redis.Client
+	codec      *event.Codec
+	retentions Retentions
 }
 
-// NewRedisPriceProducer creates new producer to price stream
-func NewRedisPriceProducer(redisClient *redis.Client) Price {
+// NewRedisPriceProducer creates new producer to price streams of tenants, retentions are optional
+func NewRedisPriceProducer(redisClient *redis.Client, codec *event.Codec, retentions Retentions) Price {
 	return &redisPrice{
-		redis: redisClient,
+		redis:      redisClient,
+		codec:      codec,
+		retentions: retentions,
 	}
 }
 
-func (p *redisPrice) Produce(ctx context.Context, id uuid.UUID, price float64) error {
-	fmt.Printf("producing message to redis: {%v, %f}\n", id, price)
+func (p *redisPrice) Produce(ctx context.Context, e event.PriceChanged) error {
+	data, err := p.codec.EncodePriceChanged(e)
+	if err != nil {
+		return err
+	}
+
+	logging.Debugf("producing message to redis: {%v, %v, %v}\n", e.ID, e.CatID, e.NewPrice)
+	stream := tenant.Topic(PriceTopic, e.TenantID)
 	args := &redis.XAddArgs{
-		Stream: "price",
+		Stream: stream,
 		Values: map[string]interface{}{
-			"id":    id.String(),
-			"price": price,
+			"event": data,
 		},
 	}
+	if retention := p.retentions[stream]; retention != nil {
+		args.MinID = retention.MinID()
+		args.Approx = true
+	}
 	return p.redis.XAdd(ctx, args).Err()
 }
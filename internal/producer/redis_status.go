@@ -0,0 +1,40 @@
+package producer
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+type redisStatus struct {
+	redis *redis.Client
+}
+
+// NewRedisStatusProducer creates new producer to status stream
+func NewRedisStatusProducer(redisClient *redis.Client) Status {
+	return &redisStatus{
+		redis: redisClient,
+	}
+}
+
+// Produce adds status message to status stream of tenant carried by ctx
+func (p *redisStatus) Produce(ctx context.Context, id uuid.UUID, status string) error {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	logging.Debugf("producing status message to redis: {%v, %s}\n", id, status)
+	args := &redis.XAddArgs{
+		Stream: tenant.Topic(StatusTopic, tenantID),
+		Values: map[string]interface{}{
+			"id":     id.String(),
+			"status": status,
+		},
+	}
+	return p.redis.XAdd(ctx, args).Err()
+}
@@ -0,0 +1,12 @@
+package producer
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Status provides producing to status stream
+type Status interface {
+	Produce(ctx context.Context, id uuid.UUID, status string) error
+}
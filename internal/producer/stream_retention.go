@@ -0,0 +1,217 @@
+package producer
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/evleria/cats-app/internal/broker"
+	"github.com/evleria/cats-app/internal/logging"
+)
+
+// maxTrimBatch limits number of entries read to find retention bound by count, the rest is trimmed on next runs
+const maxTrimBatch = 10000
+
+// StreamRetention limits redis stream by number of entries and by their age.
+// Entries not yet delivered to a consumer group, or delivered but not acknowledged, are never trimmed.
+type StreamRetention struct {
+	redis  *redis.Client
+	stream string
+	maxLen int64
+	maxAge time.Duration
+
+	mu    sync.RWMutex
+	minID string
+}
+
+// Retentions are retentions of streams by stream name
+type Retentions map[string]*StreamRetention
+
+// NewStreamRetentions creates retentions of streams with the same limits
+func NewStreamRetentions(redisClient *redis.Client, streams []string, maxLen int64, maxAge time.Duration) Retentions {
+	retentions := make(Retentions, len(streams))
+	for _, stream := range streams {
+		retentions[stream] = NewStreamRetention(redisClient, stream, maxLen, maxAge)
+	}
+	return retentions
+}
+
+// NewStreamRetention creates retention of a stream, zero maxLen or maxAge means no limit
+func NewStreamRetention(redisClient *redis.Client, stream string, maxLen int64, maxAge time.Duration) *StreamRetention {
+	return &StreamRetention{
+		redis:  redisClient,
+		stream: stream,
+		maxLen: maxLen,
+		maxAge: maxAge,
+	}
+}
+
+// Enabled reports whether any limit is set
+func (r *StreamRetention) Enabled() bool {
+	return r.maxLen > 0 || r.maxAge > 0
+}
+
+// MinID returns the lowest ID to keep found by the last trim or refresh, it is empty before the first of them.
+// Producers use it for approximate trimming, it only lags behind the actual bound, so it never trims more.
+func (r *StreamRetention) MinID() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.minID
+}
+
+// Refresh finds the lowest ID to keep and returns it as MinID from then on, it is nil if nothing has to be trimmed
+func (r *StreamRetention) Refresh(ctx context.Context) (*broker.StreamID, error) {
+	minID, err := r.safeMinID(ctx)
+	if err != nil || minID == nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.minID = minID.String()
+	r.mu.Unlock()
+	return minID, nil
+}
+
+// Trim removes entries beyond retention, returns number of removed entries
+func (r *StreamRetention) Trim(ctx context.Context) (int64, error) {
+	minID, err := r.Refresh(ctx)
+	if err != nil || minID == nil {
+		return 0, err
+	}
+	return r.redis.XTrimMinID(ctx, r.stream, minID.String()).Result()
+}
+
+// Run trims the stream periodically until ctx is done
+func (r *StreamRetention) Run(ctx context.Context, interval time.Duration) {
+	r.repeat(ctx, interval, func() {
+		trimmed, err := r.Trim(ctx)
+		if err != nil && ctx.Err() == nil {
+			logging.Warnf("cannot trim stream %s: %v\n", r.stream, err)
+		} else if trimmed > 0 {
+			logging.Debugf("trimmed %d entries of stream %s\n", trimmed, r.stream)
+		}
+	})
+}
+
+// Watch refreshes MinID periodically until ctx is done without trimming the stream. It is run by processes
+// that produce to the stream but do not run the trimming janitor, so that their producers trim approximately too.
+func (r *StreamRetention) Watch(ctx context.Context, interval time.Duration) {
+	r.repeat(ctx, interval, func() {
+		if _, err := r.Refresh(ctx); err != nil && ctx.Err() == nil {
+			logging.Warnf("cannot refresh retention bound of stream %s: %v\n", r.stream, err)
+		}
+	})
+}
+
+// repeat calls fn right away and then every interval until ctx is done
+func (r *StreamRetention) repeat(ctx context.Context, interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		fn()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// safeMinID returns the lowest ID to keep, or nil if nothing has to be trimmed
+func (r *StreamRetention) safeMinID(ctx context.Context) (*broker.StreamID, error) {
+	var minID *broker.StreamID
+	if r.maxAge > 0 {
+		byAge := broker.StreamIDAt(time.Now().Add(-r.maxAge))
+		minID = &byAge
+	}
+	if r.maxLen > 0 {
+		byCount, err := r.minIDByCount(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if byCount != nil && (minID == nil || minID.Less(*byCount)) {
+			minID = byCount
+		}
+	}
+	if minID == nil {
+		return nil, nil
+	}
+
+	floor, err := r.groupsFloor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if floor != nil && floor.Less(*minID) {
+		minID = floor
+	}
+	return minID, nil
+}
+
+// minIDByCount returns ID of the oldest entry kept by count limit
+func (r *StreamRetention) minIDByCount(ctx context.Context) (*broker.StreamID, error) {
+	length, err := r.redis.XLen(ctx, r.stream).Result()
+	if err != nil {
+		return nil, err
+	}
+	excess := length - r.maxLen
+	if excess <= 0 {
+		return nil, nil
+	}
+	if excess > maxTrimBatch {
+		excess = maxTrimBatch
+	}
+
+	entries, err := r.redis.XRangeN(ctx, r.stream, "-", "+", excess+1).Result()
+	if err != nil || int64(len(entries)) <= excess {
+		return nil, err
+	}
+	id, err := broker.ParseStreamID(entries[excess].ID)
+	return &id, err
+}
+
+// groupsFloor returns the lowest ID some consumer group still needs, or nil if there are no groups
+func (r *StreamRetention) groupsFloor(ctx context.Context) (*broker.StreamID, error) {
+	groups, err := r.redis.XInfoGroups(ctx, r.stream).Result()
+	if err != nil {
+		if strings.Contains(err.Error(), "no such key") {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var floor *broker.StreamID
+	for _, group := range groups {
+		needed, err := r.groupFloor(ctx, group)
+		if err != nil {
+			return nil, err
+		}
+		if floor == nil || needed.Less(*floor) {
+			floor = &needed
+		}
+	}
+	return floor, nil
+}
+
+// groupFloor returns the oldest pending entry of a group, or the next entry to deliver if nothing is pending
+func (r *StreamRetention) groupFloor(ctx context.Context, group redis.XInfoGroup) (broker.StreamID, error) {
+	if group.Pending > 0 {
+		pending, err := r.redis.XPending(ctx, r.stream, group.Name).Result()
+		if err != nil {
+			return broker.StreamID{}, err
+		}
+		if pending.Count > 0 {
+			return broker.ParseStreamID(pending.Lower)
+		}
+	}
+
+	lastDelivered, err := broker.ParseStreamID(group.LastDeliveredID)
+	if err != nil {
+		return broker.StreamID{}, err
+	}
+	return lastDelivered.Next(), nil
+}
@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+type memoryLimiter struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	buckets   map[string]bucket
+	nextSweep time.Time
+}
+
+// NewMemoryLimiter creates limiter keeping buckets in process memory, so limits are not shared between instances
+func NewMemoryLimiter() Limiter {
+	return &memoryLimiter{
+		now:     time.Now,
+		buckets: map[string]bucket{},
+	}
+}
+
+func (m *memoryLimiter) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	b, ok := m.buckets[key]
+	if !ok {
+		b = bucket{tokens: float64(limit.Burst), updatedAt: now}
+	}
+	tokens, result := take(b.tokens, now.Sub(b.updatedAt), limit)
+	m.buckets[key] = bucket{tokens: tokens, updatedAt: now}
+
+	// full buckets carry no state, so they are dropped from time to time
+	if now.After(m.nextSweep) {
+		for k, b := range m.buckets {
+			if now.Sub(b.updatedAt) > time.Hour {
+				delete(m.buckets, k)
+			}
+		}
+		m.nextSweep = now.Add(time.Hour)
+	}
+	return result, nil
+}
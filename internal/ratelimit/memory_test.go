@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLimiterBurst(t *testing.T) {
+	// Arrange
+	now := time.Date(2021, 8, 1, 12, 0, 0, 0, time.UTC)
+	l := &memoryLimiter{now: func() time.Time { return now }, buckets: map[string]bucket{}}
+	limit := Limit{Rate: 1, Burst: 3}
+
+	// Act
+	var results []Result
+	for i := 0; i < 4; i++ {
+		result, err := l.Allow(context.Background(), "ip:10.0.0.1", limit)
+		require.NoError(t, err)
+		results = append(results, result)
+	}
+
+	// Assert
+	for i, remaining := range []int{2, 1, 0} {
+		require.True(t, results[i].Allowed)
+		require.Equal(t, remaining, results[i].Remaining)
+		require.Equal(t, 3, results[i].Limit)
+	}
+	require.False(t, results[3].Allowed)
+	require.Equal(t, time.Second, results[3].RetryAfter)
+	require.Equal(t, 3*time.Second, results[3].Reset)
+}
+
+func TestMemoryLimiterRefill(t *testing.T) {
+	// Arrange
+	now := time.Date(2021, 8, 1, 12, 0, 0, 0, time.UTC)
+	l := &memoryLimiter{now: func() time.Time { return now }, buckets: map[string]bucket{}}
+	limit := Limit{Rate: 2, Burst: 2}
+	for i := 0; i < 2; i++ {
+		_, _ = l.Allow(context.Background(), "ip:10.0.0.1", limit)
+	}
+
+	// Act
+	now = now.Add(500 * time.Millisecond)
+	refilled, _ := l.Allow(context.Background(), "ip:10.0.0.1", limit)
+	exhausted, _ := l.Allow(context.Background(), "ip:10.0.0.1", limit)
+	other, _ := l.Allow(context.Background(), "ip:10.0.0.2", limit)
+
+	// Assert
+	require.True(t, refilled.Allowed)
+	require.False(t, exhausted.Allowed)
+	require.Equal(t, 500*time.Millisecond, exhausted.RetryAfter)
+	require.True(t, other.Allowed)
+}
+
+func TestRules(t *testing.T) {
+	// Arrange
+	rules := NewRules(map[Class]Limit{ClassRead: {Rate: 10, Burst: 20}, ClassWrite: {}})
+
+	// Act
+	read, readLimited := rules.Get(ClassRead)
+	_, writeLimited := rules.Get(ClassWrite)
+	rules.Set(map[Class]Limit{ClassWrite: {Rate: 1, Burst: 1}})
+	_, readLimitedAfterSet := rules.Get(ClassRead)
+	_, writeLimitedAfterSet := rules.Get(ClassWrite)
+
+	// Assert
+	require.True(t, readLimited)
+	require.Equal(t, Limit{Rate: 10, Burst: 20}, read)
+	require.False(t, writeLimited)
+	require.False(t, readLimitedAfterSet)
+	require.True(t, writeLimitedAfterSet)
+}
+
+func TestClientKey(t *testing.T) {
+	rules := NewRules(nil)
+	rules.SetAPIKeys([]string{"secret"})
+	require.Equal(t, "ip:10.0.0.1", rules.ClientKey("", "10.0.0.1"))
+	require.Equal(t, "ip:10.0.0.1", rules.ClientKey("made-up", "10.0.0.1"))
+	require.NotContains(t, rules.ClientKey("secret", "10.0.0.1"), "secret")
+	require.Equal(t, rules.ClientKey("secret", "10.0.0.1"), rules.ClientKey("secret", "10.0.0.2"))
+	require.Equal(t, ClassRead, ClassOfMethod(http.MethodGet))
+	require.Equal(t, ClassWrite, ClassOfMethod(http.MethodPut))
+}
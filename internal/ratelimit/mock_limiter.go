@@ -0,0 +1,35 @@
+// Code generated by mockery v0.0.0-dev. DO NOT EDIT.
+
+package ratelimit
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockLimiter is an autogenerated mock type for the Limiter type
+type MockLimiter struct {
+	mock.Mock
+}
+
+// Allow provides a mock function with given fields: ctx, key, limit
+func (_m *MockLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	ret := _m.Called(ctx, key, limit)
+
+	var r0 Result
+	if rf, ok := ret.Get(0).(func(context.Context, string, Limit) Result); ok {
+		r0 = rf(ctx, key, limit)
+	} else {
+		r0 = ret.Get(0).(Result)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, Limit) error); ok {
+		r1 = rf(ctx, key, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
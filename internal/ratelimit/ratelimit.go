@@ -0,0 +1,155 @@
+// Package ratelimit limits request rates of clients with token buckets
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Class is a class of routes sharing a limit, e.g. reads or writes
+type Class string
+
+const (
+	// ClassRead contains routes that do not change state
+	ClassRead Class = "read"
+	// ClassWrite contains routes that change state
+	ClassWrite Class = "write"
+)
+
+// Limit is a token bucket refilled with Rate tokens per second up to Burst tokens, each request takes a token
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled reports whether the limit restricts anything
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Result describes a state of a bucket after a request
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Limiter takes tokens from buckets of clients
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Rules are limits by route class and API keys of clients limited on their own, they may be replaced at runtime
+type Rules struct {
+	mu      sync.RWMutex
+	limits  map[Class]Limit
+	apiKeys map[string]bool
+}
+
+// NewRules creates rules with given limits, classes without a limit are not restricted
+func NewRules(limits map[Class]Limit) *Rules {
+	r := &Rules{}
+	r.Set(limits)
+	return r
+}
+
+// Get returns limit of a class, false means the class is not restricted
+func (r *Rules) Get(class Class) (Limit, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	limit, ok := r.limits[class]
+	return limit, ok && limit.Enabled()
+}
+
+// Set replaces all limits
+func (r *Rules) Set(limits map[Class]Limit) {
+	copied := make(map[Class]Limit, len(limits))
+	for class, limit := range limits {
+		copied[class] = limit
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limits = copied
+}
+
+// SetAPIKeys replaces API keys of clients having own buckets, keys are kept hashed
+func (r *Rules) SetAPIKeys(keys []string) {
+	hashed := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key != "" {
+			hashed[hashKey(key)] = true
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.apiKeys = hashed
+}
+
+// ClientKey identifies a client by API key if it is one of configured keys, otherwise by IP address,
+// so made up keys cannot give a client fresh buckets. API keys are hashed, so they are not stored in plain text.
+func (r *Rules) ClientKey(apiKey, ip string) string {
+	if apiKey != "" {
+		hashed := hashKey(apiKey)
+		r.mu.RLock()
+		known := r.apiKeys[hashed]
+		r.mu.RUnlock()
+		if known {
+			return "key:" + hashed
+		}
+	}
+	return "ip:" + ip
+}
+
+func hashKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:16])
+}
+
+// ClassOfMethod classifies HTTP request by its method
+func ClassOfMethod(method string) Class {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ClassRead
+	default:
+		return ClassWrite
+	}
+}
+
+// BucketKey is a key of a bucket of a client for a class of routes
+func BucketKey(class Class, client string) string {
+	return string(class) + ":" + client
+}
+
+// Seconds rounds duration up to whole seconds, as rate limit headers carry seconds
+func Seconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+// take refills bucket holding tokens since elapsed time and takes a token if there is one
+func take(tokens float64, elapsed time.Duration, limit Limit) (float64, Result) {
+	tokens = math.Min(float64(limit.Burst), tokens+elapsed.Seconds()*limit.Rate)
+	result := Result{Limit: limit.Burst}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - tokens) / limit.Rate)
+	}
+	result.Remaining = int(tokens)
+	result.Reset = secondsToDuration((float64(limit.Burst) - tokens) / limit.Rate)
+	return tokens, result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
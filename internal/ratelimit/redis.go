@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// allowScript refills a bucket by time elapsed since its last update and takes a token from it.
+// Redis clock is used, so instances with skewed clocks share buckets consistently.
+const allowScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local clock = redis.call("TIME")
+local now = tonumber(clock[1]) * 1000 + math.floor(tonumber(clock[2]) / 1000)
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local allowed, retry = 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) * 1000 / rate)
+end
+local reset = math.ceil((burst - tokens) * 1000 / rate)
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.max(reset, 1000))
+return {allowed, math.floor(tokens), reset, retry}
+`
+
+type redisLimiter struct {
+	redis *redis.Client
+	allow *redis.Script
+}
+
+// NewRedisLimiter creates limiter keeping buckets in redis, so limits are shared by all instances
+func NewRedisLimiter(redisClient *redis.Client) Limiter {
+	return &redisLimiter{
+		redis: redisClient,
+		allow: redis.NewScript(allowScript),
+	}
+}
+
+func (r *redisLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	reply, err := r.allow.Run(ctx, r.redis, []string{"ratelimit:" + key}, limit.Rate, limit.Burst).Result()
+	if err != nil {
+		return Result{}, err
+	}
+	items, ok := reply.([]interface{})
+	if !ok || len(items) != 4 {
+		return Result{}, fmt.Errorf("unexpected rate limit reply: %v", reply)
+	}
+	values := make([]int64, len(items))
+	for i, item := range items {
+		values[i], _ = item.(int64)
+	}
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      limit.Burst,
+		Remaining:  int(values[1]),
+		Reset:      time.Duration(values[2]) * time.Millisecond,
+		RetryAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,45 @@
+package replay
+
+import (
+	"context"
+
+	"github.com/evleria/cats-app/internal/consumer"
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/producer"
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/repository/entities"
+)
+
+// PriceHistory rebuilds price history projection, entries of already recorded events are replaced
+func PriceHistory(repo repository.PriceHistory) Handler {
+	return func(ctx context.Context, e event.PriceChanged) error {
+		return repo.Record(ctx, MapPriceChange(e))
+	}
+}
+
+// CacheWarmup fills dedup cache of a consumer, so that events already in history are not processed again.
+// In-memory store can be warmed up only in the process running that consumer.
+func CacheWarmup(store consumer.DedupStore) Handler {
+	return func(ctx context.Context, e event.PriceChanged) error {
+		return store.MarkProcessed(ctx, e)
+	}
+}
+
+// Republish produces events again, e.g. to the fanout exchange for consumers that missed them
+func Republish(p producer.Price) Handler {
+	return func(ctx context.Context, e event.PriceChanged) error {
+		return p.Produce(ctx, e)
+	}
+}
+
+// MapPriceChange maps price event to an entry of price history
+func MapPriceChange(e event.PriceChanged) entities.PriceChange {
+	return entities.PriceChange{
+		ID:        e.ID,
+		CatID:     e.CatID,
+		Sequence:  e.Sequence,
+		OldPrice:  e.OldPrice,
+		NewPrice:  e.NewPrice,
+		ChangedAt: e.OccurredAt,
+	}
+}
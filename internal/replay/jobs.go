@@ -0,0 +1,168 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+var (
+	// ErrUnknownHandler means replay is requested into a handler that is not registered
+	ErrUnknownHandler = errors.New("unknown replay handler")
+	// ErrJobNotFound means there is no replay job with given ID
+	ErrJobNotFound = errors.New("replay job not found")
+	// ErrJobRunning means replay into the same handler is already in progress
+	ErrJobRunning = errors.New("replay into the handler is already running")
+)
+
+// finishedJobTTL is how long progress of a finished job can be read, older jobs are forgotten when another one starts
+const finishedJobTTL = time.Hour
+
+// Request describes a replay to start
+type Request struct {
+	Handler string
+	From    string
+	To      string
+	Rate    float64
+}
+
+// Jobs runs replays of price streams of tenants in background and tracks their progress.
+// Jobs belong to tenant carried by context, jobs of other tenants are never found.
+type Jobs interface {
+	Start(ctx context.Context, request Request) (Progress, error)
+	Get(ctx context.Context, id uuid.UUID) (Progress, error)
+	Cancel(ctx context.Context, id uuid.UUID) error
+}
+
+type job struct {
+	tenantID string
+	progress Progress
+	cancel   context.CancelFunc
+}
+
+type jobs struct {
+	sources  func(tenantID string) Source
+	handlers map[string]Handler
+
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*job
+}
+
+// NewJobs creates registry of replay jobs reading from source of a tenant into one of named handlers
+func NewJobs(sources func(tenantID string) Source, handlers map[string]Handler) Jobs {
+	return &jobs{
+		sources:  sources,
+		handlers: handlers,
+		jobs:     make(map[uuid.UUID]*job),
+	}
+}
+
+func (j *jobs) Start(ctx context.Context, request Request) (Progress, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return Progress{}, err
+	}
+	handler, ok := j.handlers[request.Handler]
+	if !ok {
+		return Progress{}, fmt.Errorf("%w: %q", ErrUnknownHandler, request.Handler)
+	}
+	from, err := ParseBound(defaultBound(request.From, StreamStart))
+	if err != nil {
+		return Progress{}, err
+	}
+	to, err := ParseBound(defaultBound(request.To, StreamEnd))
+	if err != nil {
+		return Progress{}, err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.prune(time.Now())
+	for _, running := range j.jobs {
+		if running.tenantID == tenantID && running.progress.Handler == request.Handler && running.progress.State == StateRunning {
+			return Progress{}, ErrJobRunning
+		}
+	}
+
+	// job outlives the request, it keeps only tenant of the request context
+	jobCtx, cancel := context.WithCancel(tenant.WithID(context.Background(), tenantID))
+	progress := Progress{
+		ID:        uuid.New(),
+		Handler:   request.Handler,
+		From:      from,
+		To:        to,
+		State:     StateRunning,
+		StartedAt: time.Now().UTC(),
+	}
+	j.jobs[progress.ID] = &job{tenantID: tenantID, progress: progress, cancel: cancel}
+
+	go func() {
+		defer cancel()
+		opts := Options{From: from, To: to, Rate: request.Rate}
+		Run(jobCtx, j.sources(tenantID), handler, opts, progress, func(p Progress) {
+			j.mu.Lock()
+			defer j.mu.Unlock()
+			j.jobs[p.ID].progress = p
+		})
+	}()
+
+	return progress, nil
+}
+
+func (j *jobs) Get(ctx context.Context, id uuid.UUID) (Progress, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	running, err := j.find(ctx, id)
+	if err != nil {
+		return Progress{}, err
+	}
+	return running.progress, nil
+}
+
+func (j *jobs) Cancel(ctx context.Context, id uuid.UUID) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	running, err := j.find(ctx, id)
+	if err != nil {
+		return err
+	}
+	running.cancel()
+	return nil
+}
+
+// prune forgets jobs finished more than finishedJobTTL ago, j.mu must be held
+func (j *jobs) prune(now time.Time) {
+	for id, finished := range j.jobs {
+		if finishedAt := finished.progress.FinishedAt; finishedAt != nil && now.Sub(*finishedAt) > finishedJobTTL {
+			delete(j.jobs, id)
+		}
+	}
+}
+
+// find returns a job of tenant carried by ctx, j.mu must be held
+func (j *jobs) find(ctx context.Context, id uuid.UUID) (*job, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	running, ok := j.jobs[id]
+	if !ok || running.tenantID != tenantID {
+		return nil, ErrJobNotFound
+	}
+	return running, nil
+}
+
+func defaultBound(bound, fallback string) string {
+	if bound == "" {
+		return fallback
+	}
+	return bound
+}
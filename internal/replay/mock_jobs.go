@@ -0,0 +1,71 @@
+// Code generated by mockery v0.0.0-dev. DO NOT EDIT.
+
+package replay
+
+import (
+	context "context"
+
+	uuid "github.com/google/uuid"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockJobs is an autogenerated mock type for the Jobs type
+type MockJobs struct {
+	mock.Mock
+}
+
+// Cancel provides a mock function with given fields: ctx, id
+func (_m *MockJobs) Cancel(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Get provides a mock function with given fields: ctx, id
+func (_m *MockJobs) Get(ctx context.Context, id uuid.UUID) (Progress, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 Progress
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) Progress); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(Progress)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Start provides a mock function with given fields: ctx, request
+func (_m *MockJobs) Start(ctx context.Context, request Request) (Progress, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 Progress
+	if rf, ok := ret.Get(0).(func(context.Context, Request) Progress); ok {
+		r0 = rf(ctx, request)
+	} else {
+		r0 = ret.Get(0).(Progress)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, Request) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
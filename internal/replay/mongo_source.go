@@ -0,0 +1,129 @@
+package replay
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/evleria/cats-app/internal/broker"
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/repository/entities"
+)
+
+// historyOrder orders price history as mongo source reads it, changes of the same millisecond by their IDs
+var historyOrder = bson.D{{Key: "changedAt", Value: 1}, {Key: "_id", Value: 1}}
+
+type mongoSource struct {
+	collection *mongo.Collection
+	tenantID   string
+}
+
+// NewMongoSource creates source reading price history of a tenant for brokers without redis streams.
+// Entry IDs look like stream IDs, they are milliseconds of a change and its index among changes of the same millisecond.
+func NewMongoSource(mongoDB *mongo.Database, tenantID string) Source {
+	return &mongoSource{
+		collection: mongoDB.Collection("price_history"),
+		tenantID:   tenantID,
+	}
+}
+
+func (s *mongoSource) Range(ctx context.Context, from, to string, count int64) ([]Entry, error) {
+	changedAt := bson.M{}
+	start := broker.StreamID{}
+	if from != StreamStart {
+		id, err := broker.ParseStreamID(from)
+		if err != nil {
+			return nil, err
+		}
+		start = id
+		changedAt["$gte"] = start.Time()
+	}
+	end := broker.StreamID{Millis: math.MaxUint64, Sequence: math.MaxUint64}
+	if to != StreamEnd {
+		id, err := broker.ParseStreamID(to)
+		if err != nil {
+			return nil, err
+		}
+		// upper bound without index includes all changes of its millisecond as in redis streams
+		if !strings.Contains(to, "-") {
+			id.Sequence = math.MaxUint64
+		}
+		end = id
+		changedAt["$lte"] = end.Time()
+	}
+	filter := bson.M{"tenantId": s.tenantID}
+	if len(changedAt) > 0 {
+		filter["changedAt"] = changedAt
+	}
+
+	cursor, err := s.collection.Find(ctx, filter, options.Find().SetSort(historyOrder))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx) //nolint:errcheck
+
+	var (
+		entries []Entry
+		id      broker.StreamID
+		first   = true
+	)
+	for int64(len(entries)) < count && cursor.Next(ctx) {
+		var change entities.PriceChange
+		if err := cursor.Decode(&change); err != nil {
+			return nil, err
+		}
+		// cursor starts at the first change of a millisecond, so indexes are counted from it
+		millis := broker.StreamIDAt(change.ChangedAt).Millis
+		if !first && id.Millis == millis {
+			id = id.Next()
+		} else {
+			id = broker.StreamID{Millis: millis}
+		}
+		first = false
+
+		if id.Less(start) {
+			continue
+		}
+		if end.Less(id) {
+			break
+		}
+		entries = append(entries, Entry{ID: id.String(), Event: changeEvent(change)})
+	}
+	return entries, cursor.Err()
+}
+
+func (s *mongoSource) LastID(ctx context.Context) (string, error) {
+	var last entities.PriceChange
+	opts := options.FindOne().SetSort(bson.D{{Key: "changedAt", Value: -1}, {Key: "_id", Value: -1}})
+	err := s.collection.FindOne(ctx, bson.M{"tenantId": s.tenantID}, opts).Decode(&last)
+	if err == mongo.ErrNoDocuments {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	sameMillis, err := s.collection.CountDocuments(ctx, bson.M{"tenantId": s.tenantID, "changedAt": last.ChangedAt})
+	if err != nil {
+		return "", err
+	}
+	id := broker.StreamID{Millis: broker.StreamIDAt(last.ChangedAt).Millis, Sequence: uint64(sameMillis - 1)}
+	return id.String(), nil
+}
+
+// changeEvent rebuilds price event from the price change recorded from it
+func changeEvent(change entities.PriceChange) event.PriceChanged {
+	return event.PriceChanged{
+		ID:            change.ID,
+		TenantID:      change.TenantID,
+		SchemaVersion: event.PriceChangedSchemaVersion,
+		OccurredAt:    change.ChangedAt,
+		CatID:         change.CatID,
+		Sequence:      change.Sequence,
+		OldPrice:      change.OldPrice,
+		NewPrice:      change.NewPrice,
+	}
+}
@@ -0,0 +1,62 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/evleria/cats-app/internal/consumer"
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/producer"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+type redisSource struct {
+	redis    *redis.Client
+	stream   string
+	tenantID string
+	codec    *event.Codec
+}
+
+// NewRedisSource creates source reading redis price stream of a tenant
+func NewRedisSource(redisClient *redis.Client, tenantID string, codec *event.Codec) Source {
+	return &redisSource{
+		redis:    redisClient,
+		stream:   tenant.Topic(producer.PriceTopic, tenantID),
+		tenantID: tenantID,
+		codec:    codec,
+	}
+}
+
+func (s *redisSource) Range(ctx context.Context, from, to string, count int64) ([]Entry, error) {
+	messages, err := s.redis.XRangeN(ctx, s.stream, from, to, count).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(messages))
+	for _, message := range messages {
+		e, err := consumer.DecodeRedisMessage(s.codec, message)
+		if err != nil {
+			return nil, err
+		}
+		// entries without tenant were produced before multi-tenancy to the stream of default tenant
+		if e.TenantID == "" {
+			e.TenantID = tenant.Default
+		}
+		if e.TenantID != s.tenantID {
+			return nil, fmt.Errorf("entry %s belongs to tenant %q, not %q", message.ID, e.TenantID, s.tenantID)
+		}
+		entries = append(entries, Entry{ID: message.ID, Event: e})
+	}
+	return entries, nil
+}
+
+func (s *redisSource) LastID(ctx context.Context) (string, error) {
+	messages, err := s.redis.XRevRangeN(ctx, s.stream, "+", "-", 1).Result()
+	if err != nil || len(messages) == 0 {
+		return "", err
+	}
+	return messages[0].ID, nil
+}
@@ -0,0 +1,198 @@
+// Package replay provides reprocessing of price stream history
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/evleria/cats-app/internal/broker"
+	"github.com/evleria/cats-app/internal/event"
+)
+
+const (
+	// StreamStart is a bound meaning the first entry of the stream
+	StreamStart = "-"
+	// StreamEnd is a bound meaning the last entry of the stream at the moment replay starts
+	StreamEnd = "+"
+	// defaultBatchSize is a number of entries read from the stream at once
+	defaultBatchSize = 100
+)
+
+// State is a state of replay
+type State string
+
+const (
+	// StateRunning means replay is in progress
+	StateRunning State = "running"
+	// StateCompleted means all entries of the range are handled
+	StateCompleted State = "completed"
+	// StateFailed means replay stopped on an error
+	StateFailed State = "failed"
+	// StateCancelled means replay is stopped on request
+	StateCancelled State = "cancelled"
+)
+
+// ErrInvalidBound means bound of a range is neither a stream ID nor a timestamp
+var ErrInvalidBound = errors.New("invalid range bound")
+
+var streamIDPattern = regexp.MustCompile(`^\d+(-\d+)?$`)
+
+// Handler processes a replayed event
+type Handler func(ctx context.Context, e event.PriceChanged) error
+
+// Entry is a price event read from the stream together with its stream ID
+type Entry struct {
+	ID    string
+	Event event.PriceChanged
+}
+
+// Source reads entries of price stream
+type Source interface {
+	// Range returns up to count entries with IDs between from and to, both inclusive
+	Range(ctx context.Context, from, to string, count int64) ([]Entry, error)
+	// LastID returns ID of the last entry of the stream, it is empty for an empty stream
+	LastID(ctx context.Context) (string, error)
+}
+
+// Options limits replay to a range of the stream and a rate of handled events
+type Options struct {
+	From string
+	To   string
+	// Rate is a maximal number of events handled per second, zero means no limit
+	Rate      float64
+	BatchSize int64
+}
+
+// Progress describes state of a replay
+type Progress struct {
+	ID         uuid.UUID  `json:"id"`
+	Handler    string     `json:"handler"`
+	From       string     `json:"from"`
+	To         string     `json:"to"`
+	State      State      `json:"state"`
+	LastID     string     `json:"lastId,omitempty"`
+	Processed  int64      `json:"processed"`
+	Percent    float64    `json:"percent"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// ParseBound converts a range bound given as stream ID, "-", "+" or RFC 3339 timestamp to a stream ID
+func ParseBound(bound string) (string, error) {
+	if bound == StreamStart || bound == StreamEnd || streamIDPattern.MatchString(bound) {
+		return bound, nil
+	}
+	t, err := time.Parse(time.RFC3339, bound)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidBound, bound)
+	}
+	return strconv.FormatUint(broker.StreamIDAt(t).Millis, 10), nil
+}
+
+// Run handles events of the stream range in order, report is called with progress after every batch.
+// Open end of the range is fixed at start, so events produced during replay are not handled.
+func Run(ctx context.Context, source Source, handler Handler, opts Options, progress Progress, report func(Progress)) Progress {
+	progress.State = StateRunning
+	report(progress)
+
+	err := run(ctx, source, handler, opts, &progress, report)
+	finishedAt := time.Now().UTC()
+	progress.FinishedAt = &finishedAt
+	switch {
+	case err == nil:
+		progress.State = StateCompleted
+		progress.Percent = 100
+	case errors.Is(err, context.Canceled):
+		progress.State = StateCancelled
+	default:
+		progress.State = StateFailed
+		progress.Error = err.Error()
+	}
+	report(progress)
+	return progress
+}
+
+func run(ctx context.Context, source Source, handler Handler, opts Options, progress *Progress, report func(Progress)) error {
+	from, to := opts.From, opts.To
+	if from == "" {
+		from = StreamStart
+	}
+	if to == "" || to == StreamEnd {
+		last, err := source.LastID(ctx)
+		if err != nil || last == "" {
+			return err
+		}
+		to = last
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	var throttle <-chan time.Time
+	if opts.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.Rate))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	end, err := broker.ParseStreamID(to)
+	if err != nil {
+		return err
+	}
+	var start *broker.StreamID
+	for {
+		entries, err := source.Range(ctx, from, to, batchSize)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		for _, entry := range entries {
+			if throttle != nil {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-throttle:
+				}
+			}
+			if err := handler(ctx, entry.Event); err != nil {
+				return fmt.Errorf("entry %s: %w", entry.ID, err)
+			}
+
+			id, err := broker.ParseStreamID(entry.ID)
+			if err != nil {
+				return err
+			}
+			if start == nil {
+				start = &id
+			}
+			progress.LastID = entry.ID
+			progress.Processed++
+		}
+		last, _ := broker.ParseStreamID(progress.LastID)
+		progress.Percent = percent(start.Millis, last.Millis, end.Millis)
+		report(*progress)
+
+		if int64(len(entries)) < batchSize {
+			return nil
+		}
+		from = last.Next().String()
+	}
+}
+
+func percent(startMs, currentMs, endMs uint64) float64 {
+	if endMs <= startMs {
+		return 100
+	}
+	return float64(currentMs-startMs) * 100 / float64(endMs-startMs)
+}
@@ -0,0 +1,203 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/broker"
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+// sliceSource serves entries kept in order of their IDs
+type sliceSource []Entry
+
+func (s sliceSource) Range(_ context.Context, from, to string, count int64) ([]Entry, error) {
+	var entries []Entry
+	for _, entry := range s {
+		if compareIDs(entry.ID, from) >= 0 && (to == StreamEnd || compareIDs(entry.ID, to) <= 0) && int64(len(entries)) < count {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (s sliceSource) LastID(context.Context) (string, error) {
+	if len(s) == 0 {
+		return "", nil
+	}
+	return s[len(s)-1].ID, nil
+}
+
+func compareIDs(a, b string) int {
+	if b == StreamStart {
+		return 1
+	}
+	idA, _ := broker.ParseStreamID(a)
+	idB, _ := broker.ParseStreamID(b)
+	switch {
+	case idA.Less(idB):
+		return -1
+	case idB.Less(idA):
+		return 1
+	default:
+		return 0
+	}
+}
+
+func newSource(n int) sliceSource {
+	source := make(sliceSource, n)
+	for i := range source {
+		source[i] = Entry{
+			ID:    fmt.Sprintf("%d-0", 1000+i*10),
+			Event: event.NewPriceChanged(uuid.New(), uint64(i+1), money.Money{Amount: int64(i), Currency: "USD"}, money.Money{Amount: int64(i + 1), Currency: "USD"}),
+		}
+	}
+	return source
+}
+
+func TestRunHandlesRangeInBatches(t *testing.T) {
+	// Arrange
+	source := newSource(5)
+	var handled []uuid.UUID
+	handler := func(_ context.Context, e event.PriceChanged) error {
+		handled = append(handled, e.ID)
+		return nil
+	}
+	var reports []Progress
+
+	// Act
+	progress := Run(context.Background(), source, handler, Options{From: "1010", To: "1030", BatchSize: 2}, Progress{}, func(p Progress) {
+		reports = append(reports, p)
+	})
+
+	// Assert
+	require.Equal(t, StateCompleted, progress.State)
+	require.Equal(t, int64(3), progress.Processed)
+	require.Equal(t, "1030-0", progress.LastID)
+	require.Equal(t, []uuid.UUID{source[1].Event.ID, source[2].Event.ID, source[3].Event.ID}, handled)
+	require.Equal(t, float64(50), reports[1].Percent)
+	require.NotNil(t, progress.FinishedAt)
+}
+
+func TestRunFixesOpenEnd(t *testing.T) {
+	// Arrange
+	source := newSource(3)
+
+	// Act
+	progress := Run(context.Background(), source, func(context.Context, event.PriceChanged) error {
+		return nil
+	}, Options{}, Progress{}, func(Progress) {})
+
+	// Assert
+	require.Equal(t, StateCompleted, progress.State)
+	require.Equal(t, int64(3), progress.Processed)
+}
+
+func TestRunStopsOnHandlerError(t *testing.T) {
+	// Arrange
+	source := newSource(3)
+	handler := func(_ context.Context, e event.PriceChanged) error {
+		if e.ID == source[1].Event.ID {
+			return errors.New("broken")
+		}
+		return nil
+	}
+
+	// Act
+	progress := Run(context.Background(), source, handler, Options{}, Progress{}, func(Progress) {})
+
+	// Assert
+	require.Equal(t, StateFailed, progress.State)
+	require.Equal(t, int64(1), progress.Processed)
+	require.Equal(t, "entry 1010-0: broken", progress.Error)
+}
+
+func TestRunLimitsRate(t *testing.T) {
+	// Arrange
+	source := newSource(3)
+	start := time.Now()
+
+	// Act
+	progress := Run(context.Background(), source, func(context.Context, event.PriceChanged) error {
+		return nil
+	}, Options{Rate: 50}, Progress{}, func(Progress) {})
+
+	// Assert
+	require.Equal(t, StateCompleted, progress.State)
+	require.GreaterOrEqual(t, time.Since(start), 60*time.Millisecond)
+}
+
+func TestParseBound(t *testing.T) {
+	for bound, expected := range map[string]string{
+		"-":                    "-",
+		"+":                    "+",
+		"1627804800000":        "1627804800000",
+		"1627804800000-5":      "1627804800000-5",
+		"2021-08-01T08:00:00Z": "1627804800000",
+	} {
+		actual, err := ParseBound(bound)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+	}
+
+	_, err := ParseBound("yesterday")
+	require.ErrorIs(t, err, ErrInvalidBound)
+}
+
+func TestJobs(t *testing.T) {
+	// Arrange
+	source := newSource(3)
+	var handledTenant string
+	j := NewJobs(func(string) Source { return source }, map[string]Handler{
+		"noop": func(ctx context.Context, _ event.PriceChanged) error {
+			handledTenant, _ = tenant.FromContext(ctx)
+			return nil
+		},
+	})
+	ctx := tenant.WithID(context.Background(), "shelter-1")
+
+	// Act
+	started, err := j.Start(ctx, Request{Handler: "noop"})
+	require.NoError(t, err)
+
+	// Assert
+	require.Eventually(t, func() bool {
+		progress, err := j.Get(ctx, started.ID)
+		return err == nil && progress.State == StateCompleted && progress.Processed == 3
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, "shelter-1", handledTenant)
+
+	_, err = j.Start(ctx, Request{Handler: "unknown"})
+	require.ErrorIs(t, err, ErrUnknownHandler)
+	_, err = j.Get(ctx, uuid.New())
+	require.ErrorIs(t, err, ErrJobNotFound)
+	_, err = j.Get(tenant.WithID(context.Background(), "shelter-2"), started.ID)
+	require.ErrorIs(t, err, ErrJobNotFound)
+}
+
+func TestJobsForgetOldFinishedJobs(t *testing.T) {
+	// Arrange
+	j := NewJobs(func(string) Source { return newSource(0) }, map[string]Handler{
+		"noop": func(context.Context, event.PriceChanged) error { return nil },
+	}).(*jobs)
+	ctx := tenant.WithID(context.Background(), "shelter-1")
+	longAgo := time.Now().Add(-2 * finishedJobTTL)
+	old := Progress{ID: uuid.New(), Handler: "noop", State: StateCompleted, FinishedAt: &longAgo}
+	j.jobs[old.ID] = &job{tenantID: "shelter-1", progress: old, cancel: func() {}}
+
+	// Act
+	_, err := j.Start(ctx, Request{Handler: "noop"})
+
+	// Assert
+	require.NoError(t, err)
+	_, err = j.Get(ctx, old.ID)
+	require.ErrorIs(t, err, ErrJobNotFound)
+}
@@ -4,26 +4,72 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"github.com/evleria/cats-app/internal/money"
 	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/tenant"
 )
 
 var (
 	// ErrNotFound means entity is not found in repository
 	ErrNotFound = errors.New("not found")
+	// ErrConflict means entity is in a state that does not allow the operation
+	ErrConflict = errors.New("conflict")
 )
 
-// Cats contains methods for manipulating with cats collection
+// Cats contains methods for manipulating with cats collection.
+// Every query is restricted to tenant carried by context and fails with tenant.ErrMissing without it.
 type Cats interface {
-	Insert(ctx context.Context, name, color string, age int, price float64) (uuid.UUID, error)
-	GetAll(ctx context.Context) ([]entities.Cat, error)
+	Insert(ctx context.Context, cat entities.Cat) (uuid.UUID, error)
+	GetAll(ctx context.Context, filter Filter, page Page) ([]entities.Cat, error)
+	Search(ctx context.Context, query string, filter Filter, page Page) ([]SearchResult, error)
 	GetOne(ctx context.Context, id uuid.UUID) (entities.Cat, error)
+	// RecordView counts a view of a cat
+	RecordView(ctx context.Context, id uuid.UUID) error
 	Delete(ctx context.Context, id uuid.UUID) error
-	UpdatePrice(ctx context.Context, id uuid.UUID, price float64) error
+	UpdatePrice(ctx context.Context, id uuid.UUID, price money.Money) (oldPrice money.Money, priceVersion uint64, err error)
+	// UpdatePriceIfVersion updates price only while price version is still the given one, ErrConflict means it has changed
+	UpdatePriceIfVersion(ctx context.Context, id uuid.UUID, price money.Money, priceVersion uint64) (oldPrice money.Money, newVersion uint64, err error)
+	// SetAutoPrice records a price set by a pricing rule, nil means the price is no longer automatic
+	SetAutoPrice(ctx context.Context, id uuid.UUID, autoPrice *entities.AutoPrice) error
+	Reserve(ctx context.Context, id uuid.UUID, ttl time.Duration) (entities.Reservation, error)
+	CancelReservation(ctx context.Context, id, reservationID uuid.UUID) error
+	Purchase(ctx context.Context, id, reservationID uuid.UUID) (entities.Cat, error)
+	ReleaseExpiredReservations(ctx context.Context) ([]uuid.UUID, error)
+	AddPhoto(ctx context.Context, id uuid.UUID, photo entities.Photo) error
+	RemovePhoto(ctx context.Context, id, photoID uuid.UUID) (entities.Photo, error)
+	// Tenants returns IDs of tenants having cats, it is the only method that does not require tenant in context
+	Tenants(ctx context.Context) ([]string, error)
+}
+
+// Filter contains optional conditions for listing cats, zero values are ignored
+type Filter struct {
+	Color       string
+	Breed       string
+	Sex         entities.Sex
+	Status      entities.Status
+	Tags        []string
+	Vaccination string
+	MinAge      *int
+	MaxAge      *int
+}
+
+// Page limits a number of returned entities, zero limit means no limit
+type Page struct {
+	Limit  int64
+	Offset int64
+}
+
+// SearchResult contains a cat found by full-text search and its relevance score
+type SearchResult struct {
+	Cat   entities.Cat
+	Score float64
 }
 
 type cats struct {
@@ -37,24 +83,34 @@ func NewCatsRepository(mongoDB *mongo.Database) Cats {
 	}
 }
 
-func (c *cats) Insert(ctx context.Context, name, color string, age int, price float64) (uuid.UUID, error) {
-	cat := entities.Cat{
-		ID:    uuid.New(),
-		Name:  name,
-		Color: color,
-		Age:   age,
-		Price: price,
+func (c *cats) Insert(ctx context.Context, cat entities.Cat) (uuid.UUID, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	cat.ID = uuid.New()
+	cat.TenantID = tenantID
+	cat.Status = entities.StatusAvailable
+	cat.PriceVersion = 1
+	cat.ListedAt = time.Now().UTC()
+	if cat.BirthDate != nil {
+		cat.Age = 0
 	}
 
-	_, err := c.collection.InsertOne(ctx, cat)
+	_, err = c.collection.InsertOne(ctx, cat)
 	if err != nil {
 		return uuid.UUID{}, err
 	}
 	return cat.ID, err
 }
 
-func (c *cats) GetAll(ctx context.Context) ([]entities.Cat, error) {
-	cursor, err := c.collection.Find(ctx, bson.M{})
+func (c *cats) GetAll(ctx context.Context, filter Filter, page Page) ([]entities.Cat, error) {
+	query, err := scope(ctx, filter.query(time.Now().UTC()))
+	if err != nil {
+		return nil, err
+	}
+	opts := options.Find().SetSkip(page.Offset).SetLimit(page.Limit)
+	cursor, err := c.collection.Find(ctx, query, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -66,7 +122,40 @@ func (c *cats) GetAll(ctx context.Context) ([]entities.Cat, error) {
 		if err := cursor.Decode(cat); err != nil {
 			return nil, err
 		}
-		result = append(result, *cat)
+		result = append(result, normalize(*cat))
+	}
+
+	if err := cursor.Close(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *cats) Search(ctx context.Context, query string, filter Filter, page Page) ([]SearchResult, error) {
+	conditions, err := scope(ctx, filter.query(time.Now().UTC()))
+	if err != nil {
+		return nil, err
+	}
+	conditions["$text"] = bson.M{"$search": query}
+	score := bson.M{"score": bson.M{"$meta": "textScore"}}
+	opts := options.Find().SetProjection(score).SetSort(score).SetSkip(page.Offset).SetLimit(page.Limit)
+
+	cursor, err := c.collection.Find(ctx, conditions, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []SearchResult{}
+
+	for cursor.Next(ctx) {
+		scored := new(struct {
+			entities.Cat `bson:",inline"`
+			Score        float64 `bson:"score"`
+		})
+		if err := cursor.Decode(scored); err != nil {
+			return nil, err
+		}
+		result = append(result, SearchResult{Cat: normalize(scored.Cat), Score: scored.Score})
 	}
 
 	if err := cursor.Close(ctx); err != nil {
@@ -77,17 +166,40 @@ func (c *cats) GetAll(ctx context.Context) ([]entities.Cat, error) {
 
 func (c *cats) GetOne(ctx context.Context, id uuid.UUID) (entities.Cat, error) {
 	cat := entities.Cat{}
-	err := c.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&cat)
+	filter, err := scope(ctx, bson.M{"_id": id})
+	if err != nil {
+		return cat, err
+	}
+	err = c.collection.FindOne(ctx, filter).Decode(&cat)
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		return cat, ErrNotFound
 	} else if err != nil {
 		return cat, err
 	}
-	return cat, nil
+	return normalize(cat), nil
+}
+
+func (c *cats) RecordView(ctx context.Context, id uuid.UUID) error {
+	filter, err := scope(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	result, err := c.collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"views": 1}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
 }
 
 func (c *cats) Delete(ctx context.Context, id uuid.UUID) error {
-	if r, err := c.collection.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+	filter, err := scope(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if r, err := c.collection.DeleteOne(ctx, filter); err != nil {
 		return err
 	} else if r.DeletedCount == 0 {
 		return ErrNotFound
@@ -95,11 +207,289 @@ func (c *cats) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-func (c *cats) UpdatePrice(ctx context.Context, id uuid.UUID, price float64) error {
-	if r, err := c.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"price": price}}); err != nil {
+func (c *cats) UpdatePrice(ctx context.Context, id uuid.UUID, price money.Money) (money.Money, uint64, error) {
+	return c.updatePrice(ctx, bson.M{"_id": id}, price)
+}
+
+func (c *cats) UpdatePriceIfVersion(ctx context.Context, id uuid.UUID, price money.Money, priceVersion uint64) (money.Money, uint64, error) {
+	oldPrice, newVersion, err := c.updatePrice(ctx, bson.M{"_id": id, "priceVersion": priceVersion}, price)
+	if errors.Is(err, ErrNotFound) {
+		return oldPrice, newVersion, c.conflictOrNotFound(ctx, id)
+	}
+	return oldPrice, newVersion, err
+}
+
+// updatePrice sets price and increments price version in one atomic update, so versions of concurrent updates never collide
+func (c *cats) updatePrice(ctx context.Context, filter bson.M, price money.Money) (money.Money, uint64, error) {
+	filter, err := scope(ctx, filter)
+	if err != nil {
+		return money.Money{}, 0, err
+	}
+	update := bson.M{"$set": bson.M{"price": price}, "$inc": bson.M{"priceVersion": 1}}
+	opts := options.FindOneAndUpdate().SetProjection(bson.M{"price": 1, "priceVersion": 1})
+
+	old := entities.Cat{}
+	err = c.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&old)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return money.Money{}, 0, ErrNotFound
+	} else if err != nil {
+		return money.Money{}, 0, err
+	}
+	return old.Price, old.PriceVersion + 1, nil
+}
+
+func (c *cats) SetAutoPrice(ctx context.Context, id uuid.UUID, autoPrice *entities.AutoPrice) error {
+	filter, err := scope(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	update := bson.M{"$set": bson.M{"autoPrice": autoPrice}}
+	if autoPrice == nil {
+		update = bson.M{"$unset": bson.M{"autoPrice": ""}}
+	}
+	if r, err := c.collection.UpdateOne(ctx, filter, update); err != nil {
+		return err
+	} else if r.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (c *cats) Reserve(ctx context.Context, id uuid.UUID, ttl time.Duration) (entities.Reservation, error) {
+	now := time.Now().UTC()
+	filter, err := scope(ctx, bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{"status": bson.M{"$in": bson.A{entities.StatusAvailable, nil}}},
+			bson.M{"status": entities.StatusReserved, "reservation.expiresAt": bson.M{"$lte": now}},
+		},
+	})
+	if err != nil {
+		return entities.Reservation{}, err
+	}
+	// pipeline update copies current price into reservation in a single atomic step
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"status": entities.StatusReserved,
+		"reservation": bson.M{
+			"id":        uuid.New(),
+			"price":     "$price",
+			"expiresAt": now.Add(ttl),
+		},
+	}}}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	cat := entities.Cat{}
+	err = c.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&cat)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return entities.Reservation{}, c.conflictOrNotFound(ctx, id)
+	} else if err != nil {
+		return entities.Reservation{}, err
+	}
+	return *cat.Reservation, nil
+}
+
+func (c *cats) CancelReservation(ctx context.Context, id, reservationID uuid.UUID) error {
+	filter, err := scope(ctx, bson.M{"_id": id, "status": entities.StatusReserved, "reservation.id": reservationID})
+	if err != nil {
+		return err
+	}
+	update := bson.M{
+		"$set":   bson.M{"status": entities.StatusAvailable},
+		"$unset": bson.M{"reservation": ""},
+	}
+	if r, err := c.collection.UpdateOne(ctx, filter, update); err != nil {
+		return err
+	} else if r.MatchedCount == 0 {
+		return c.conflictOrNotFound(ctx, id)
+	}
+	return nil
+}
+
+func (c *cats) Purchase(ctx context.Context, id, reservationID uuid.UUID) (entities.Cat, error) {
+	now := time.Now().UTC()
+	filter, err := scope(ctx, bson.M{
+		"_id":                   id,
+		"status":                entities.StatusReserved,
+		"reservation.id":        reservationID,
+		"reservation.expiresAt": bson.M{"$gt": now},
+	})
+	if err != nil {
+		return entities.Cat{}, err
+	}
+	// sale price is taken from reservation, so price updates after reservation do not affect it
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"status": entities.StatusSold,
+			"sale": bson.M{
+				"reservationId": "$reservation.id",
+				"price":         "$reservation.price",
+				"soldAt":        now,
+			},
+		}}},
+		{{Key: "$unset", Value: "reservation"}},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	cat := entities.Cat{}
+	err = c.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&cat)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return cat, c.conflictOrNotFound(ctx, id)
+	} else if err != nil {
+		return cat, err
+	}
+	return cat, nil
+}
+
+func (c *cats) ReleaseExpiredReservations(ctx context.Context) ([]uuid.UUID, error) {
+	now := time.Now().UTC()
+	filter, err := scope(ctx, bson.M{"status": entities.StatusReserved, "reservation.expiresAt": bson.M{"$lte": now}})
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := c.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	expired := []entities.Cat{}
+	if err := cursor.All(ctx, &expired); err != nil {
+		return nil, err
+	}
+
+	released := make([]uuid.UUID, 0, len(expired))
+	update := bson.M{
+		"$set":   bson.M{"status": entities.StatusAvailable},
+		"$unset": bson.M{"reservation": ""},
+	}
+	for _, cat := range expired {
+		// filter is repeated so a cat reserved again in the meantime is left untouched
+		filter["_id"] = cat.ID
+		r, err := c.collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return released, err
+		}
+		if r.ModifiedCount > 0 {
+			released = append(released, cat.ID)
+		}
+	}
+	return released, nil
+}
+
+func (c *cats) AddPhoto(ctx context.Context, id uuid.UUID, photo entities.Photo) error {
+	filter, err := scope(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if r, err := c.collection.UpdateOne(ctx, filter, bson.M{"$push": bson.M{"photos": photo}}); err != nil {
 		return err
 	} else if r.MatchedCount == 0 {
 		return ErrNotFound
 	}
 	return nil
 }
+
+func (c *cats) RemovePhoto(ctx context.Context, id, photoID uuid.UUID) (entities.Photo, error) {
+	filter, err := scope(ctx, bson.M{"_id": id, "photos.id": photoID})
+	if err != nil {
+		return entities.Photo{}, err
+	}
+	update := bson.M{"$pull": bson.M{"photos": bson.M{"id": photoID}}}
+	opts := options.FindOneAndUpdate().SetProjection(bson.M{"photos": bson.M{"$elemMatch": bson.M{"id": photoID}}})
+
+	cat := entities.Cat{}
+	err = c.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&cat)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && len(cat.Photos) == 0) {
+		return entities.Photo{}, ErrNotFound
+	} else if err != nil {
+		return entities.Photo{}, err
+	}
+	return cat.Photos[0], nil
+}
+
+func (c *cats) Tenants(ctx context.Context) ([]string, error) {
+	values, err := c.collection.Distinct(ctx, "tenantId", bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	tenants := make([]string, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(string); ok {
+			tenants = append(tenants, id)
+		}
+	}
+	return tenants, nil
+}
+
+func (c *cats) conflictOrNotFound(ctx context.Context, id uuid.UUID) error {
+	if _, err := c.GetOne(ctx, id); err != nil {
+		return err
+	}
+	return ErrConflict
+}
+
+// scope restricts filter to tenant carried by ctx
+func scope(ctx context.Context, filter bson.M) (bson.M, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filter["tenantId"] = tenantID
+	return filter, nil
+}
+
+func normalize(cat entities.Cat) entities.Cat {
+	if cat.Status == "" {
+		cat.Status = entities.StatusAvailable
+	}
+	return cat
+}
+
+func (f Filter) query(now time.Time) bson.M {
+	query := bson.M{}
+	if f.Color != "" {
+		query["color"] = f.Color
+	}
+	if f.Breed != "" {
+		query["breed"] = f.Breed
+	}
+	if f.Sex != "" {
+		query["sex"] = f.Sex
+	}
+	if f.Status == entities.StatusAvailable {
+		query["status"] = bson.M{"$in": bson.A{entities.StatusAvailable, nil}}
+	} else if f.Status != "" {
+		query["status"] = f.Status
+	}
+	if len(f.Tags) > 0 {
+		query["tags"] = bson.M{"$all": f.Tags}
+	}
+	if f.Vaccination != "" {
+		query["vaccinations.name"] = f.Vaccination
+	}
+	if f.MinAge != nil || f.MaxAge != nil {
+		query["$or"] = f.ageQuery(now)
+	}
+	return query
+}
+
+// ageQuery matches both cats with birth date and legacy cats with static age only.
+// Legacy cats of age 0 may have no age field at all, as it used to be omitted when empty.
+func (f Filter) ageQuery(now time.Time) bson.A {
+	birthDate, age := bson.M{}, bson.M{}
+	if f.MinAge != nil {
+		birthDate["$lte"] = now.AddDate(-*f.MinAge, 0, 0)
+		age["$gte"] = *f.MinAge
+	}
+	if f.MaxAge != nil {
+		birthDate["$gt"] = now.AddDate(-*f.MaxAge-1, 0, 0)
+		age["$lte"] = *f.MaxAge
+	}
+	query := bson.A{
+		bson.M{"birthDate": birthDate},
+		bson.M{"birthDate": bson.M{"$exists": false}, "age": age},
+	}
+	if (f.MinAge == nil || *f.MinAge <= 0) && (f.MaxAge == nil || *f.MaxAge >= 0) {
+		query = append(query, bson.M{"birthDate": bson.M{"$exists": false}, "age": bson.M{"$exists": false}})
+	}
+	return query
+}
@@ -1,13 +1,131 @@
 // Package entities contains structs that reflect database entities
 package entities
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/evleria/cats-app/internal/money"
+)
+
+// Status describes a sale state of a cat
+type Status string
+
+const (
+	// StatusAvailable means a cat can be reserved
+	StatusAvailable Status = "available"
+	// StatusReserved means a cat is held by a reservation
+	StatusReserved Status = "reserved"
+	// StatusSold means a cat has been purchased
+	StatusSold Status = "sold"
+)
+
+// Sex describes sex of a cat
+type Sex string
+
+const (
+	// SexMale is a male cat
+	SexMale Sex = "male"
+	// SexFemale is a female cat
+	SexFemale Sex = "female"
+)
 
 // Cat contains all data related to cat and stored in database
 type Cat struct {
-	ID    uuid.UUID `bson:"_id"`
-	Name  string    `bson:"name"`
-	Color string    `bson:"color"`
-	Age   int       `bson:"age"`
-	Price float64   `bson:"price"`
+	ID           uuid.UUID     `bson:"_id"`
+	TenantID     string        `bson:"tenantId"`
+	Name         string        `bson:"name"`
+	Color        string        `bson:"color"`
+	Breed        string        `bson:"breed,omitempty"`
+	Sex          Sex           `bson:"sex,omitempty"`
+	BirthDate    *time.Time    `bson:"birthDate,omitempty"`
+	Age          int           `bson:"age"`
+	Description  string        `bson:"description,omitempty"`
+	Tags         []string      `bson:"tags,omitempty"`
+	Vaccinations []Vaccination `bson:"vaccinations,omitempty"`
+	Photos       []Photo       `bson:"photos,omitempty"`
+	Price        money.Money   `bson:"price"`
+	PriceVersion uint64        `bson:"priceVersion,omitempty"`
+	Status       Status        `bson:"status,omitempty"`
+	Reservation  *Reservation  `bson:"reservation,omitempty"`
+	Sale         *Sale         `bson:"sale,omitempty"`
+	ListedAt     time.Time     `bson:"listedAt"`
+	Views        int64         `bson:"views,omitempty"`
+	AutoPrice    *AutoPrice    `bson:"autoPrice,omitempty"`
+}
+
+// AgeAt returns age of a cat in full years at given moment.
+// Documents created before birth date was introduced only have a static age, which is returned as is.
+func (c Cat) AgeAt(now time.Time) int {
+	if c.BirthDate == nil {
+		return c.Age
+	}
+	return YearsBetween(*c.BirthDate, now)
+}
+
+// YearsBetween returns number of full years passed from one moment to another
+func YearsBetween(from, to time.Time) int {
+	years := to.Year() - from.Year()
+	if to.Month() < from.Month() || (to.Month() == from.Month() && to.Day() < from.Day()) {
+		years--
+	}
+	if years < 0 {
+		return 0
+	}
+	return years
+}
+
+// DaysListedAt returns number of full days a cat has been listed for at given moment
+func (c Cat) DaysListedAt(now time.Time) int {
+	if c.ListedAt.IsZero() || now.Before(c.ListedAt) {
+		return 0
+	}
+	return int(now.Sub(c.ListedAt) / (24 * time.Hour))
+}
+
+// AutoPrice records a price set by a pricing rule and the price it was computed from.
+// As long as the price is unchanged, rules are applied to the base price again instead of compounding.
+type AutoPrice struct {
+	Rule      string      `bson:"rule"`
+	BasePrice money.Money `bson:"basePrice"`
+	Price     money.Money `bson:"price"`
+}
+
+// BasePrice returns price pricing rules are applied to, a price set manually after an automatic one becomes the new base
+func (c Cat) BasePrice() money.Money {
+	if c.AutoPrice != nil && c.AutoPrice.Price == c.Price {
+		return c.AutoPrice.BasePrice
+	}
+	return c.Price
+}
+
+// Vaccination contains data of a single vaccination of a cat
+type Vaccination struct {
+	Name       string     `bson:"name"`
+	Date       time.Time  `bson:"date"`
+	ValidUntil *time.Time `bson:"validUntil,omitempty"`
+}
+
+// Photo contains reference to a cat photo and its thumbnail stored in GridFS
+type Photo struct {
+	ID          uuid.UUID `bson:"id"`
+	ThumbnailID uuid.UUID `bson:"thumbnailId"`
+	ContentType string    `bson:"contentType"`
+	Size        int64     `bson:"size"`
+	UploadedAt  time.Time `bson:"uploadedAt"`
+}
+
+// Reservation contains data of a temporary hold on a cat
+type Reservation struct {
+	ID        uuid.UUID   `bson:"id"`
+	Price     money.Money `bson:"price"`
+	ExpiresAt time.Time   `bson:"expiresAt"`
+}
+
+// Sale contains data of a completed purchase of a cat
+type Sale struct {
+	ReservationID uuid.UUID   `bson:"reservationId"`
+	Price         money.Money `bson:"price"`
+	SoldAt        time.Time   `bson:"soldAt"`
 }
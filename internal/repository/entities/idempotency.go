@@ -0,0 +1,11 @@
+package entities
+
+// IdempotencyRecord contains a result of a request identified by idempotency key.
+// Record without Completed flag marks a request that is still being processed.
+type IdempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Completed   bool   `json:"completed"`
+	StatusCode  int    `json:"statusCode,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
@@ -0,0 +1,20 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/evleria/cats-app/internal/money"
+)
+
+// PriceChange is an entry of cat price history, it is identified by ID of the price event it is built from
+type PriceChange struct {
+	ID        uuid.UUID   `bson:"_id"`
+	TenantID  string      `bson:"tenantId"`
+	CatID     uuid.UUID   `bson:"catId"`
+	Sequence  uint64      `bson:"sequence,omitempty"`
+	OldPrice  money.Money `bson:"oldPrice"`
+	NewPrice  money.Money `bson:"newPrice"`
+	ChangedAt time.Time   `bson:"changedAt"`
+}
@@ -0,0 +1,66 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/evleria/cats-app/internal/money"
+)
+
+// ScheduleState describes progress of a price schedule
+type ScheduleState string
+
+const (
+	// SchedulePending means prices are not changed yet, the schedule can be updated or deleted
+	SchedulePending ScheduleState = "pending"
+	// ScheduleApplying means prices are being changed, an interrupted run is resumed by the next one
+	ScheduleApplying ScheduleState = "applying"
+	// ScheduleActive means a promotion has changed prices and waits for its end
+	ScheduleActive ScheduleState = "active"
+	// ScheduleReverting means prices changed by a promotion are being restored
+	ScheduleReverting ScheduleState = "reverting"
+	// ScheduleCompleted means a price change is applied, or a promotion has ended
+	ScheduleCompleted ScheduleState = "completed"
+)
+
+// PriceSchedule is a future price change of a cat or of cats matching a filter.
+// A schedule with an end is a promotion, prices it has changed are restored when it ends.
+// New price is either a fixed price or a discount off current price of each cat.
+type PriceSchedule struct {
+	ID              uuid.UUID       `bson:"_id"`
+	TenantID        string          `bson:"tenantId"`
+	CatID           *uuid.UUID      `bson:"catId,omitempty"`
+	Filter          *ScheduleFilter `bson:"filter,omitempty"`
+	Price           *money.Money    `bson:"price,omitempty"`
+	DiscountPercent int             `bson:"discountPercent,omitempty"`
+	StartsAt        time.Time       `bson:"startsAt"`
+	EndsAt          *time.Time      `bson:"endsAt,omitempty"`
+	State           ScheduleState   `bson:"state"`
+	Applied         []AppliedPrice  `bson:"applied,omitempty"`
+	LastError       string          `bson:"lastError,omitempty"`
+	CreatedAt       time.Time       `bson:"createdAt"`
+	UpdatedAt       time.Time       `bson:"updatedAt"`
+}
+
+// ScheduleFilter selects cats a schedule applies to when it starts, zero values are ignored
+type ScheduleFilter struct {
+	Color  string   `bson:"color,omitempty"`
+	Breed  string   `bson:"breed,omitempty"`
+	Tags   []string `bson:"tags,omitempty"`
+	MinAge *int     `bson:"minAge,omitempty"`
+	MaxAge *int     `bson:"maxAge,omitempty"`
+}
+
+// AppliedPrice is a price a schedule has set to a cat along with the price it replaced
+type AppliedPrice struct {
+	CatID         uuid.UUID   `bson:"catId"`
+	OriginalPrice money.Money `bson:"originalPrice"`
+	Price         money.Money `bson:"price"`
+	Reverted      bool        `bson:"reverted,omitempty"`
+}
+
+// IsPromotion reports whether prices are restored when the schedule ends
+func (s PriceSchedule) IsPromotion() bool {
+	return s.EndsAt != nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+// processingTTL limits how long a key stays locked when a request never completes
+const processingTTL = time.Minute
+
+// IdempotencyKeys contains methods for storing results of requests by idempotency keys.
+// Keys are scoped to tenant carried by context, so equal keys of different tenants never collide.
+type IdempotencyKeys interface {
+	// Acquire locks a key for processing, if the key is already used its record is returned with false
+	Acquire(ctx context.Context, key, fingerprint string) (entities.IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, key string, record entities.IdempotencyRecord) error
+	Release(ctx context.Context, key string) error
+}
+
+type idempotencyKeys struct {
+	redis *redis.Client
+	ttl   time.Duration
+}
+
+// NewIdempotencyKeysRepository creates new idempotency keys repository, completed records expire after ttl
+func NewIdempotencyKeysRepository(redisClient *redis.Client, ttl time.Duration) IdempotencyKeys {
+	return &idempotencyKeys{
+		redis: redisClient,
+		ttl:   ttl,
+	}
+}
+
+func (i *idempotencyKeys) Acquire(ctx context.Context, key, fingerprint string) (entities.IdempotencyRecord, bool, error) {
+	record := entities.IdempotencyRecord{Fingerprint: fingerprint}
+	redisKey, err := scopedKey(ctx, key)
+	if err != nil {
+		return record, false, err
+	}
+	value, err := json.Marshal(record)
+	if err != nil {
+		return record, false, err
+	}
+
+	acquired, err := i.redis.SetNX(ctx, redisKey, value, processingTTL).Result()
+	if err != nil || acquired {
+		return record, acquired, err
+	}
+
+	stored, err := i.redis.Get(ctx, redisKey).Bytes()
+	if err == redis.Nil {
+		// previous record has just expired, so try again
+		return i.Acquire(ctx, key, fingerprint)
+	} else if err != nil {
+		return record, false, err
+	}
+
+	err = json.Unmarshal(stored, &record)
+	return record, false, err
+}
+
+func (i *idempotencyKeys) Complete(ctx context.Context, key string, record entities.IdempotencyRecord) error {
+	redisKey, err := scopedKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	record.Completed = true
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return i.redis.Set(ctx, redisKey, value, i.ttl).Err()
+}
+
+func (i *idempotencyKeys) Release(ctx context.Context, key string) error {
+	redisKey, err := scopedKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	return i.redis.Del(ctx, redisKey).Err()
+}
+
+// scopedKey returns redis key or mongo id of idempotency key of tenant carried by ctx,
+// keys of default tenant are named as before multi-tenancy
+func scopedKey(ctx context.Context, key string) (string, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	return tenant.Topic("idempotency", tenantID) + ":" + key, nil
+}
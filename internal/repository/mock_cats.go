@@ -4,12 +4,13 @@ package repository
 
 import (
 	context "context"
+	time "time"
 
+	uuid "github.com/google/uuid"
 	mock "github.com/stretchr/testify/mock"
 
+	money "github.com/evleria/cats-app/internal/money"
 	entities "github.com/evleria/cats-app/internal/repository/entities"
-
-	uuid "github.com/google/uuid"
 )
 
 // MockCats is an autogenerated mock type for the Cats type
@@ -17,6 +18,34 @@ type MockCats struct {
 	mock.Mock
 }
 
+// AddPhoto provides a mock function with given fields: ctx, id, photo
+func (_m *MockCats) AddPhoto(ctx context.Context, id uuid.UUID, photo entities.Photo) error {
+	ret := _m.Called(ctx, id, photo)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, entities.Photo) error); ok {
+		r0 = rf(ctx, id, photo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CancelReservation provides a mock function with given fields: ctx, id, reservationID
+func (_m *MockCats) CancelReservation(ctx context.Context, id uuid.UUID, reservationID uuid.UUID) error {
+	ret := _m.Called(ctx, id, reservationID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r0 = rf(ctx, id, reservationID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Delete provides a mock function with given fields: ctx, id
 func (_m *MockCats) Delete(ctx context.Context, id uuid.UUID) error {
 	ret := _m.Called(ctx, id)
@@ -31,13 +60,13 @@ func (_m *MockCats) Delete(ctx context.Context, id uuid.UUID) error {
 	return r0
 }
 
-// GetAll provides a mock function with given fields: ctx
-func (_m *MockCats) GetAll(ctx context.Context) ([]entities.Cat, error) {
-	ret := _m.Called(ctx)
+// GetAll provides a mock function with given fields: ctx, filter, page
+func (_m *MockCats) GetAll(ctx context.Context, filter Filter, page Page) ([]entities.Cat, error) {
+	ret := _m.Called(ctx, filter, page)
 
 	var r0 []entities.Cat
-	if rf, ok := ret.Get(0).(func(context.Context) []entities.Cat); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, Filter, Page) []entities.Cat); ok {
+		r0 = rf(ctx, filter, page)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]entities.Cat)
@@ -45,8 +74,8 @@ func (_m *MockCats) GetAll(ctx context.Context) ([]entities.Cat, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, Filter, Page) error); ok {
+		r1 = rf(ctx, filter, page)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -75,13 +104,13 @@ func (_m *MockCats) GetOne(ctx context.Context, id uuid.UUID) (entities.Cat, err
 	return r0, r1
 }
 
-// Insert provides a mock function with given fields: ctx, name, color, age, price
-func (_m *MockCats) Insert(ctx context.Context, name string, color string, age int, price float64) (uuid.UUID, error) {
-	ret := _m.Called(ctx, name, color, age, price)
+// Insert provides a mock function with given fields: ctx, cat
+func (_m *MockCats) Insert(ctx context.Context, cat entities.Cat) (uuid.UUID, error) {
+	ret := _m.Called(ctx, cat)
 
 	var r0 uuid.UUID
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, int, float64) uuid.UUID); ok {
-		r0 = rf(ctx, name, color, age, price)
+	if rf, ok := ret.Get(0).(func(context.Context, entities.Cat) uuid.UUID); ok {
+		r0 = rf(ctx, cat)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(uuid.UUID)
@@ -89,8 +118,8 @@ func (_m *MockCats) Insert(ctx context.Context, name string, color string, age i
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, int, float64) error); ok {
-		r1 = rf(ctx, name, color, age, price)
+	if rf, ok := ret.Get(1).(func(context.Context, entities.Cat) error); ok {
+		r1 = rf(ctx, cat)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -98,16 +127,218 @@ func (_m *MockCats) Insert(ctx context.Context, name string, color string, age i
 	return r0, r1
 }
 
-// UpdatePrice provides a mock function with given fields: ctx, id, price
-func (_m *MockCats) UpdatePrice(ctx context.Context, id uuid.UUID, price float64) error {
-	ret := _m.Called(ctx, id, price)
+// Purchase provides a mock function with given fields: ctx, id, reservationID
+func (_m *MockCats) Purchase(ctx context.Context, id uuid.UUID, reservationID uuid.UUID) (entities.Cat, error) {
+	ret := _m.Called(ctx, id, reservationID)
+
+	var r0 entities.Cat
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) entities.Cat); ok {
+		r0 = rf(ctx, id, reservationID)
+	} else {
+		r0 = ret.Get(0).(entities.Cat)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r1 = rf(ctx, id, reservationID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordView provides a mock function with given fields: ctx, id
+func (_m *MockCats) RecordView(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, float64) error); ok {
-		r0 = rf(ctx, id, price)
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Error(0)
 	}
 
 	return r0
 }
+
+// ReleaseExpiredReservations provides a mock function with given fields: ctx
+func (_m *MockCats) ReleaseExpiredReservations(ctx context.Context) ([]uuid.UUID, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []uuid.UUID
+	if rf, ok := ret.Get(0).(func(context.Context) []uuid.UUID); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uuid.UUID)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemovePhoto provides a mock function with given fields: ctx, id, photoID
+func (_m *MockCats) RemovePhoto(ctx context.Context, id uuid.UUID, photoID uuid.UUID) (entities.Photo, error) {
+	ret := _m.Called(ctx, id, photoID)
+
+	var r0 entities.Photo
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) entities.Photo); ok {
+		r0 = rf(ctx, id, photoID)
+	} else {
+		r0 = ret.Get(0).(entities.Photo)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r1 = rf(ctx, id, photoID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Reserve provides a mock function with given fields: ctx, id, ttl
+func (_m *MockCats) Reserve(ctx context.Context, id uuid.UUID, ttl time.Duration) (entities.Reservation, error) {
+	ret := _m.Called(ctx, id, ttl)
+
+	var r0 entities.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, time.Duration) entities.Reservation); ok {
+		r0 = rf(ctx, id, ttl)
+	} else {
+		r0 = ret.Get(0).(entities.Reservation)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, time.Duration) error); ok {
+		r1 = rf(ctx, id, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Search provides a mock function with given fields: ctx, query, filter, page
+func (_m *MockCats) Search(ctx context.Context, query string, filter Filter, page Page) ([]SearchResult, error) {
+	ret := _m.Called(ctx, query, filter, page)
+
+	var r0 []SearchResult
+	if rf, ok := ret.Get(0).(func(context.Context, string, Filter, Page) []SearchResult); ok {
+		r0 = rf(ctx, query, filter, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]SearchResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, Filter, Page) error); ok {
+		r1 = rf(ctx, query, filter, page)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetAutoPrice provides a mock function with given fields: ctx, id, autoPrice
+func (_m *MockCats) SetAutoPrice(ctx context.Context, id uuid.UUID, autoPrice *entities.AutoPrice) error {
+	ret := _m.Called(ctx, id, autoPrice)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, *entities.AutoPrice) error); ok {
+		r0 = rf(ctx, id, autoPrice)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Tenants provides a mock function with given fields: ctx
+func (_m *MockCats) Tenants(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdatePrice provides a mock function with given fields: ctx, id, price
+func (_m *MockCats) UpdatePrice(ctx context.Context, id uuid.UUID, price money.Money) (money.Money, uint64, error) {
+	ret := _m.Called(ctx, id, price)
+
+	var r0 money.Money
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, money.Money) money.Money); ok {
+		r0 = rf(ctx, id, price)
+	} else {
+		r0 = ret.Get(0).(money.Money)
+	}
+
+	var r1 uint64
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, money.Money) uint64); ok {
+		r1 = rf(ctx, id, price)
+	} else {
+		r1 = ret.Get(1).(uint64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, uuid.UUID, money.Money) error); ok {
+		r2 = rf(ctx, id, price)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// UpdatePriceIfVersion provides a mock function with given fields: ctx, id, price, priceVersion
+func (_m *MockCats) UpdatePriceIfVersion(ctx context.Context, id uuid.UUID, price money.Money, priceVersion uint64) (money.Money, uint64, error) {
+	ret := _m.Called(ctx, id, price, priceVersion)
+
+	var r0 money.Money
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, money.Money, uint64) money.Money); ok {
+		r0 = rf(ctx, id, price, priceVersion)
+	} else {
+		r0 = ret.Get(0).(money.Money)
+	}
+
+	var r1 uint64
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, money.Money, uint64) uint64); ok {
+		r1 = rf(ctx, id, price, priceVersion)
+	} else {
+		r1 = ret.Get(1).(uint64)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, uuid.UUID, money.Money, uint64) error); ok {
+		r2 = rf(ctx, id, price, priceVersion)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
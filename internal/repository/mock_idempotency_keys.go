@@ -0,0 +1,72 @@
+// Code generated by mockery v0.0.0-dev. DO NOT EDIT.
+
+package repository
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	entities "github.com/evleria/cats-app/internal/repository/entities"
+)
+
+// MockIdempotencyKeys is an autogenerated mock type for the IdempotencyKeys type
+type MockIdempotencyKeys struct {
+	mock.Mock
+}
+
+// Acquire provides a mock function with given fields: ctx, key, fingerprint
+func (_m *MockIdempotencyKeys) Acquire(ctx context.Context, key string, fingerprint string) (entities.IdempotencyRecord, bool, error) {
+	ret := _m.Called(ctx, key, fingerprint)
+
+	var r0 entities.IdempotencyRecord
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) entities.IdempotencyRecord); ok {
+		r0 = rf(ctx, key, fingerprint)
+	} else {
+		r0 = ret.Get(0).(entities.IdempotencyRecord)
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) bool); ok {
+		r1 = rf(ctx, key, fingerprint)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, string) error); ok {
+		r2 = rf(ctx, key, fingerprint)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Complete provides a mock function with given fields: ctx, key, record
+func (_m *MockIdempotencyKeys) Complete(ctx context.Context, key string, record entities.IdempotencyRecord) error {
+	ret := _m.Called(ctx, key, record)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, entities.IdempotencyRecord) error); ok {
+		r0 = rf(ctx, key, record)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Release provides a mock function with given fields: ctx, key
+func (_m *MockIdempotencyKeys) Release(ctx context.Context, key string) error {
+	ret := _m.Called(ctx, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,66 @@
+// Code generated by mockery v0.0.0-dev. DO NOT EDIT.
+
+package repository
+
+import (
+	context "context"
+
+	uuid "github.com/google/uuid"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockPhotos is an autogenerated mock type for the Photos type
+type MockPhotos struct {
+	mock.Mock
+}
+
+// Delete provides a mock function with given fields: ctx, id
+func (_m *MockPhotos) Delete(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Open provides a mock function with given fields: ctx, id
+func (_m *MockPhotos) Open(ctx context.Context, id uuid.UUID) (*PhotoFile, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *PhotoFile
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) *PhotoFile); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*PhotoFile)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Upload provides a mock function with given fields: ctx, id, contentType, data
+func (_m *MockPhotos) Upload(ctx context.Context, id uuid.UUID, contentType string, data []byte) error {
+	ret := _m.Called(ctx, id, contentType, data)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string, []byte) error); ok {
+		r0 = rf(ctx, id, contentType, data)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,30 @@
+// Code generated by mockery v0.0.0-dev. DO NOT EDIT.
+
+package repository
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	entities "github.com/evleria/cats-app/internal/repository/entities"
+)
+
+// MockPriceHistory is an autogenerated mock type for the PriceHistory type
+type MockPriceHistory struct {
+	mock.Mock
+}
+
+// Record provides a mock function with given fields: ctx, change
+func (_m *MockPriceHistory) Record(ctx context.Context, change entities.PriceChange) error {
+	ret := _m.Called(ctx, change)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, entities.PriceChange) error); ok {
+		r0 = rf(ctx, change)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
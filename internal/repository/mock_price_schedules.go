@@ -0,0 +1,238 @@
+// Code generated by mockery v0.0.0-dev. DO NOT EDIT.
+
+package repository
+
+import (
+	context "context"
+	time "time"
+
+	uuid "github.com/google/uuid"
+	mock "github.com/stretchr/testify/mock"
+
+	entities "github.com/evleria/cats-app/internal/repository/entities"
+)
+
+// MockPriceSchedules is an autogenerated mock type for the PriceSchedules type
+type MockPriceSchedules struct {
+	mock.Mock
+}
+
+// Delete provides a mock function with given fields: ctx, id
+func (_m *MockPriceSchedules) Delete(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Due provides a mock function with given fields: ctx, now
+func (_m *MockPriceSchedules) Due(ctx context.Context, now time.Time) ([]entities.PriceSchedule, error) {
+	ret := _m.Called(ctx, now)
+
+	var r0 []entities.PriceSchedule
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) []entities.PriceSchedule); ok {
+		r0 = rf(ctx, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entities.PriceSchedule)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAll provides a mock function with given fields: ctx, page
+func (_m *MockPriceSchedules) GetAll(ctx context.Context, page Page) ([]entities.PriceSchedule, error) {
+	ret := _m.Called(ctx, page)
+
+	var r0 []entities.PriceSchedule
+	if rf, ok := ret.Get(0).(func(context.Context, Page) []entities.PriceSchedule); ok {
+		r0 = rf(ctx, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entities.PriceSchedule)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, Page) error); ok {
+		r1 = rf(ctx, page)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOne provides a mock function with given fields: ctx, id
+func (_m *MockPriceSchedules) GetOne(ctx context.Context, id uuid.UUID) (entities.PriceSchedule, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 entities.PriceSchedule
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) entities.PriceSchedule); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(entities.PriceSchedule)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Insert provides a mock function with given fields: ctx, schedule
+func (_m *MockPriceSchedules) Insert(ctx context.Context, schedule entities.PriceSchedule) (uuid.UUID, error) {
+	ret := _m.Called(ctx, schedule)
+
+	var r0 uuid.UUID
+	if rf, ok := ret.Get(0).(func(context.Context, entities.PriceSchedule) uuid.UUID); ok {
+		r0 = rf(ctx, schedule)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, entities.PriceSchedule) error); ok {
+		r1 = rf(ctx, schedule)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkReverted provides a mock function with given fields: ctx, id, catID
+func (_m *MockPriceSchedules) MarkReverted(ctx context.Context, id uuid.UUID, catID uuid.UUID) error {
+	ret := _m.Called(ctx, id, catID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r0 = rf(ctx, id, catID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordApplied provides a mock function with given fields: ctx, id, applied
+func (_m *MockPriceSchedules) RecordApplied(ctx context.Context, id uuid.UUID, applied entities.AppliedPrice) error {
+	ret := _m.Called(ctx, id, applied)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, entities.AppliedPrice) error); ok {
+		r0 = rf(ctx, id, applied)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Running provides a mock function with given fields: ctx
+func (_m *MockPriceSchedules) Running(ctx context.Context) ([]entities.PriceSchedule, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []entities.PriceSchedule
+	if rf, ok := ret.Get(0).(func(context.Context) []entities.PriceSchedule); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entities.PriceSchedule)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetLastError provides a mock function with given fields: ctx, id, message
+func (_m *MockPriceSchedules) SetLastError(ctx context.Context, id uuid.UUID, message string) error {
+	ret := _m.Called(ctx, id, message)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, string) error); ok {
+		r0 = rf(ctx, id, message)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Tenants provides a mock function with given fields: ctx
+func (_m *MockPriceSchedules) Tenants(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Transition provides a mock function with given fields: ctx, id, from, to
+func (_m *MockPriceSchedules) Transition(ctx context.Context, id uuid.UUID, from entities.ScheduleState, to entities.ScheduleState) error {
+	ret := _m.Called(ctx, id, from, to)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, entities.ScheduleState, entities.ScheduleState) error); ok {
+		r0 = rf(ctx, id, from, to)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, schedule, state
+func (_m *MockPriceSchedules) Update(ctx context.Context, schedule entities.PriceSchedule, state entities.ScheduleState) error {
+	ret := _m.Called(ctx, schedule, state)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, entities.PriceSchedule, entities.ScheduleState) error); ok {
+		r0 = rf(ctx, schedule, state)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+// idempotencyDocument is an idempotency record stored in mongo, TTL index removes it some time after expiresAt,
+// so expiresAt is checked on reads as well
+type idempotencyDocument struct {
+	Key         string    `bson:"_id"`
+	TenantID    string    `bson:"tenantId"`
+	Fingerprint string    `bson:"fingerprint"`
+	Completed   bool      `bson:"completed"`
+	StatusCode  int       `bson:"statusCode,omitempty"`
+	ContentType string    `bson:"contentType,omitempty"`
+	Body        []byte    `bson:"body,omitempty"`
+	ExpiresAt   time.Time `bson:"expiresAt"`
+}
+
+func (d idempotencyDocument) record() entities.IdempotencyRecord {
+	return entities.IdempotencyRecord{
+		Fingerprint: d.Fingerprint,
+		Completed:   d.Completed,
+		StatusCode:  d.StatusCode,
+		ContentType: d.ContentType,
+		Body:        d.Body,
+	}
+}
+
+type mongoIdempotencyKeys struct {
+	collection *mongo.Collection
+	ttl        time.Duration
+}
+
+// NewMongoIdempotencyKeysRepository creates new idempotency keys repository keeping records in mongo,
+// completed records expire after ttl
+func NewMongoIdempotencyKeysRepository(mongoDB *mongo.Database, ttl time.Duration) IdempotencyKeys {
+	return &mongoIdempotencyKeys{
+		collection: mongoDB.Collection("idempotency_keys"),
+		ttl:        ttl,
+	}
+}
+
+func (i *mongoIdempotencyKeys) Acquire(ctx context.Context, key, fingerprint string) (entities.IdempotencyRecord, bool, error) {
+	doc, err := i.document(ctx, key, entities.IdempotencyRecord{Fingerprint: fingerprint}, processingTTL)
+	if err != nil {
+		return doc.record(), false, err
+	}
+
+	_, err = i.collection.InsertOne(ctx, doc)
+	if err == nil {
+		return doc.record(), true, nil
+	} else if !mongo.IsDuplicateKeyError(err) {
+		return doc.record(), false, err
+	}
+
+	var stored idempotencyDocument
+	err = i.collection.FindOne(ctx, bson.M{"_id": doc.Key}).Decode(&stored)
+	if err == mongo.ErrNoDocuments {
+		// previous record has just been removed, so try again
+		return i.Acquire(ctx, key, fingerprint)
+	} else if err != nil {
+		return doc.record(), false, err
+	}
+
+	if stored.ExpiresAt.Before(time.Now()) {
+		// expired record is not removed by TTL index yet, only one of concurrent requests removes it
+		_, err = i.collection.DeleteOne(ctx, bson.M{"_id": doc.Key, "expiresAt": stored.ExpiresAt})
+		if err != nil {
+			return doc.record(), false, err
+		}
+		return i.Acquire(ctx, key, fingerprint)
+	}
+	return stored.record(), false, nil
+}
+
+func (i *mongoIdempotencyKeys) Complete(ctx context.Context, key string, record entities.IdempotencyRecord) error {
+	record.Completed = true
+	doc, err := i.document(ctx, key, record, i.ttl)
+	if err != nil {
+		return err
+	}
+	_, err = i.collection.ReplaceOne(ctx, bson.M{"_id": doc.Key}, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (i *mongoIdempotencyKeys) Release(ctx context.Context, key string) error {
+	scoped, err := scopedKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	_, err = i.collection.DeleteOne(ctx, bson.M{"_id": scoped})
+	return err
+}
+
+// document builds document of record by idempotency key of tenant carried by ctx, it expires after ttl
+func (i *mongoIdempotencyKeys) document(ctx context.Context, key string, record entities.IdempotencyRecord, ttl time.Duration) (idempotencyDocument, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return idempotencyDocument{}, err
+	}
+	scoped, err := scopedKey(ctx, key)
+	if err != nil {
+		return idempotencyDocument{}, err
+	}
+	return idempotencyDocument{
+		Key:         scoped,
+		TenantID:    tenantID,
+		Fingerprint: record.Fingerprint,
+		Completed:   record.Completed,
+		StatusCode:  record.StatusCode,
+		ContentType: record.ContentType,
+		Body:        record.Body,
+		ExpiresAt:   time.Now().Add(ttl),
+	}, nil
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Photos contains methods for manipulating with photo files
+type Photos interface {
+	Upload(ctx context.Context, id uuid.UUID, contentType string, data []byte) error
+	// Open opens a file for reading without loading it into memory, the file must be closed
+	Open(ctx context.Context, id uuid.UUID) (*PhotoFile, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// PhotoFile is an open photo file, it reads content in chunks as they are needed
+type PhotoFile struct {
+	io.ReadSeekCloser
+	ContentType string
+	Size        int64
+}
+
+type photos struct {
+	db *mongo.Database
+}
+
+// NewPhotosRepository creates new photos repository backed by GridFS
+func NewPhotosRepository(mongoDB *mongo.Database) Photos {
+	return &photos{
+		db: mongoDB,
+	}
+}
+
+func (p *photos) Upload(ctx context.Context, id uuid.UUID, contentType string, data []byte) error {
+	bucket, err := p.bucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	opts := options.GridFSUpload().SetMetadata(bson.M{"contentType": contentType})
+	return bucket.UploadFromStreamWithID(id, id.String(), bytes.NewReader(data), opts)
+}
+
+func (p *photos) Open(ctx context.Context, id uuid.UUID) (*PhotoFile, error) {
+	bucket, err := p.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := bucket.OpenDownloadStream(id)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	file := stream.GetFile()
+	contentType, _ := file.Metadata.Lookup("contentType").StringValueOK()
+	return &PhotoFile{
+		ReadSeekCloser: &downloadFile{bucket: bucket, id: id, size: file.Length, stream: stream},
+		ContentType:    contentType,
+		Size:           file.Length,
+	}, nil
+}
+
+func (p *photos) Delete(ctx context.Context, id uuid.UUID) error {
+	bucket, err := p.bucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = bucket.Delete(id)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// downloadFile reads a GridFS file from any position, its stream skips chunks to go forward and is reopened to go back
+type downloadFile struct {
+	bucket   *gridfs.Bucket
+	id       uuid.UUID
+	size     int64
+	stream   *gridfs.DownloadStream
+	position int64 // position of stream
+	offset   int64 // position set by Seek
+}
+
+func (f *downloadFile) Read(b []byte) (int, error) {
+	if f.offset >= f.size {
+		return 0, io.EOF
+	}
+	if f.offset < f.position {
+		if err := f.stream.Close(); err != nil {
+			return 0, err
+		}
+		stream, err := f.bucket.OpenDownloadStream(f.id)
+		if err != nil {
+			return 0, err
+		}
+		f.stream, f.position = stream, 0
+	}
+	if f.offset > f.position {
+		skipped, err := f.stream.Skip(f.offset - f.position)
+		f.position += skipped
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.stream.Read(b)
+	f.position += int64(n)
+	f.offset = f.position
+	return n, err
+}
+
+func (f *downloadFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *downloadFile) Close() error {
+	return f.stream.Close()
+}
+
+// bucket creates a bucket per operation, because GridFS deadlines are stored in bucket itself
+func (p *photos) bucket(ctx context.Context) (*gridfs.Bucket, error) {
+	bucket, err := gridfs.NewBucket(p.db, options.GridFSBucket().SetName("photos"))
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, _ := ctx.Deadline()
+	if err := bucket.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	if err := bucket.SetWriteDeadline(deadline); err != nil {
+		return nil, err
+	}
+	return bucket, nil
+}
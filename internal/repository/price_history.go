@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+// PriceHistory contains methods for manipulating with price history projection
+type PriceHistory interface {
+	Record(ctx context.Context, change entities.PriceChange) error
+}
+
+type priceHistory struct {
+	collection *mongo.Collection
+}
+
+// NewPriceHistoryRepository creates new price history repository
+func NewPriceHistoryRepository(mongoDB *mongo.Database) PriceHistory {
+	return &priceHistory{
+		collection: mongoDB.Collection("price_history"),
+	}
+}
+
+// Record stores a price change of tenant carried by ctx replacing the one built from the same event,
+// so recording is idempotent
+func (p *priceHistory) Record(ctx context.Context, change entities.PriceChange) error {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	change.TenantID = tenantID
+
+	opts := options.Replace().SetUpsert(true)
+	_, err = p.collection.ReplaceOne(ctx, bson.M{"_id": change.ID, "tenantId": tenantID}, change, opts)
+	return err
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+// PriceSchedules contains methods for manipulating with price schedules collection.
+// Every query is restricted to tenant carried by context and fails with tenant.ErrMissing without it.
+// Changes of state are conditional on current state, so a schedule is run by one instance at a time.
+type PriceSchedules interface {
+	Insert(ctx context.Context, schedule entities.PriceSchedule) (uuid.UUID, error)
+	GetAll(ctx context.Context, page Page) ([]entities.PriceSchedule, error)
+	GetOne(ctx context.Context, id uuid.UUID) (entities.PriceSchedule, error)
+	// Update replaces a schedule that is still in given state
+	Update(ctx context.Context, schedule entities.PriceSchedule, state entities.ScheduleState) error
+	// Delete deletes a schedule that is pending or completed
+	Delete(ctx context.Context, id uuid.UUID) error
+	// Due returns schedules to run at now: started pending ones, ended active ones and interrupted runs
+	Due(ctx context.Context, now time.Time) ([]entities.PriceSchedule, error)
+	// Running returns schedules that are applying, active or reverting, i.e. having prices of cats changed or about to
+	Running(ctx context.Context) ([]entities.PriceSchedule, error)
+	// Transition changes state of a schedule clearing its last error, ErrConflict means it is not in state from anymore
+	Transition(ctx context.Context, id uuid.UUID, from, to entities.ScheduleState) error
+	// SetLastError stores why the last run of a schedule has failed
+	SetLastError(ctx context.Context, id uuid.UUID, message string) error
+	// RecordApplied stores a price set to a cat unless a price of that cat is already stored
+	RecordApplied(ctx context.Context, id uuid.UUID, applied entities.AppliedPrice) error
+	// MarkReverted marks a price set to a cat as restored
+	MarkReverted(ctx context.Context, id, catID uuid.UUID) error
+	// Tenants returns IDs of tenants having schedules that are not completed yet, so completed schedules kept for history
+	// do not make tenants to be run. It is the only method that does not require tenant in context
+	Tenants(ctx context.Context) ([]string, error)
+}
+
+type priceSchedules struct {
+	collection *mongo.Collection
+}
+
+// NewPriceSchedulesRepository creates new price schedules repository
+func NewPriceSchedulesRepository(mongoDB *mongo.Database) PriceSchedules {
+	return &priceSchedules{
+		collection: mongoDB.Collection("price_schedules"),
+	}
+}
+
+func (p *priceSchedules) Insert(ctx context.Context, schedule entities.PriceSchedule) (uuid.UUID, error) {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	now := time.Now().UTC()
+	schedule.ID = uuid.New()
+	schedule.TenantID = tenantID
+	schedule.State = entities.SchedulePending
+	schedule.Applied = nil
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+
+	_, err = p.collection.InsertOne(ctx, schedule)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	return schedule.ID, nil
+}
+
+func (p *priceSchedules) GetAll(ctx context.Context, page Page) ([]entities.PriceSchedule, error) {
+	filter, err := scope(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "startsAt", Value: 1}}).SetSkip(page.Offset).SetLimit(page.Limit)
+	return p.find(ctx, filter, opts)
+}
+
+func (p *priceSchedules) GetOne(ctx context.Context, id uuid.UUID) (entities.PriceSchedule, error) {
+	schedule := entities.PriceSchedule{}
+	filter, err := scope(ctx, bson.M{"_id": id})
+	if err != nil {
+		return schedule, err
+	}
+	err = p.collection.FindOne(ctx, filter).Decode(&schedule)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return schedule, ErrNotFound
+	}
+	return schedule, err
+}
+
+func (p *priceSchedules) Update(ctx context.Context, schedule entities.PriceSchedule, state entities.ScheduleState) error {
+	filter, err := scope(ctx, bson.M{"_id": schedule.ID, "state": state})
+	if err != nil {
+		return err
+	}
+	schedule.TenantID = filter["tenantId"].(string)
+	schedule.UpdatedAt = time.Now().UTC()
+
+	r, err := p.collection.ReplaceOne(ctx, filter, schedule)
+	if err != nil {
+		return err
+	} else if r.MatchedCount == 0 {
+		return p.conflictOrNotFound(ctx, schedule.ID)
+	}
+	return nil
+}
+
+func (p *priceSchedules) Delete(ctx context.Context, id uuid.UUID) error {
+	filter, err := scope(ctx, bson.M{
+		"_id":   id,
+		"state": bson.M{"$in": bson.A{entities.SchedulePending, entities.ScheduleCompleted}},
+	})
+	if err != nil {
+		return err
+	}
+	r, err := p.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	} else if r.DeletedCount == 0 {
+		return p.conflictOrNotFound(ctx, id)
+	}
+	return nil
+}
+
+func (p *priceSchedules) Due(ctx context.Context, now time.Time) ([]entities.PriceSchedule, error) {
+	filter, err := scope(ctx, bson.M{"$or": bson.A{
+		bson.M{"state": entities.SchedulePending, "startsAt": bson.M{"$lte": now}},
+		bson.M{"state": entities.ScheduleActive, "endsAt": bson.M{"$lte": now}},
+		bson.M{"state": bson.M{"$in": bson.A{entities.ScheduleApplying, entities.ScheduleReverting}}},
+	}})
+	if err != nil {
+		return nil, err
+	}
+	return p.find(ctx, filter, options.Find().SetSort(bson.D{{Key: "startsAt", Value: 1}}))
+}
+
+func (p *priceSchedules) Running(ctx context.Context) ([]entities.PriceSchedule, error) {
+	filter, err := scope(ctx, bson.M{"state": bson.M{"$in": bson.A{
+		entities.ScheduleApplying, entities.ScheduleActive, entities.ScheduleReverting,
+	}}})
+	if err != nil {
+		return nil, err
+	}
+	return p.find(ctx, filter, options.Find())
+}
+
+func (p *priceSchedules) Transition(ctx context.Context, id uuid.UUID, from, to entities.ScheduleState) error {
+	filter, err := scope(ctx, bson.M{"_id": id, "state": from})
+	if err != nil {
+		return err
+	}
+	update := bson.M{
+		"$set":   bson.M{"state": to, "updatedAt": time.Now().UTC()},
+		"$unset": bson.M{"lastError": ""},
+	}
+	r, err := p.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	} else if r.MatchedCount == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (p *priceSchedules) SetLastError(ctx context.Context, id uuid.UUID, message string) error {
+	filter, err := scope(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	_, err = p.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"lastError": message, "updatedAt": time.Now().UTC()}})
+	return err
+}
+
+func (p *priceSchedules) RecordApplied(ctx context.Context, id uuid.UUID, applied entities.AppliedPrice) error {
+	filter, err := scope(ctx, bson.M{"_id": id, "applied.catId": bson.M{"$ne": applied.CatID}})
+	if err != nil {
+		return err
+	}
+	_, err = p.collection.UpdateOne(ctx, filter, bson.M{"$push": bson.M{"applied": applied}})
+	return err
+}
+
+func (p *priceSchedules) MarkReverted(ctx context.Context, id, catID uuid.UUID) error {
+	filter, err := scope(ctx, bson.M{"_id": id, "applied.catId": catID})
+	if err != nil {
+		return err
+	}
+	_, err = p.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"applied.$.reverted": true}})
+	return err
+}
+
+func (p *priceSchedules) Tenants(ctx context.Context) ([]string, error) {
+	values, err := p.collection.Distinct(ctx, "tenantId", bson.M{"state": bson.M{"$ne": entities.ScheduleCompleted}})
+	if err != nil {
+		return nil, err
+	}
+	tenants := make([]string, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(string); ok {
+			tenants = append(tenants, id)
+		}
+	}
+	return tenants, nil
+}
+
+func (p *priceSchedules) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]entities.PriceSchedule, error) {
+	cursor, err := p.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	result := []entities.PriceSchedule{}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (p *priceSchedules) conflictOrNotFound(ctx context.Context, id uuid.UUID) error {
+	if _, err := p.GetOne(ctx, id); err != nil {
+		return err
+	}
+	return ErrConflict
+}
@@ -3,64 +3,233 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/money"
 	"github.com/evleria/cats-app/internal/producer"
 	"github.com/evleria/cats-app/internal/repository"
 	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/tenant"
 )
 
 // Cats contains usecase logic for cats
 type Cats interface {
-	GetAll(ctx context.Context) ([]entities.Cat, error)
+	GetAll(ctx context.Context, filter repository.Filter, page repository.Page) ([]entities.Cat, error)
+	Search(ctx context.Context, query string, filter repository.Filter, page repository.Page) ([]SearchResult, error)
 	GetOne(ctx context.Context, id uuid.UUID) (entities.Cat, error)
-	CreateNew(ctx context.Context, name, color string, age int, price float64) (uuid.UUID, error)
+	// RecordView counts a view of a cat, it is called by clients showing a cat, views are a signal of pricing rules
+	RecordView(ctx context.Context, id uuid.UUID) error
+	CreateNew(ctx context.Context, cat entities.Cat) (uuid.UUID, error)
 	Delete(ctx context.Context, id uuid.UUID) error
-	UpdatePrice(ctx context.Context, id uuid.UUID, price float64) error
+	UpdatePrice(ctx context.Context, id uuid.UUID, price money.Money) error
+	// UpdatePriceIfVersion updates price only while price version is still the one it was read with,
+	// ErrPriceChanged means it has been changed since
+	UpdatePriceIfVersion(ctx context.Context, id uuid.UUID, price money.Money, priceVersion uint64) error
+	Reserve(ctx context.Context, id uuid.UUID) (entities.Reservation, error)
+	CancelReservation(ctx context.Context, id, reservationID uuid.UUID) error
+	Purchase(ctx context.Context, id, reservationID uuid.UUID) (entities.Sale, error)
+	ReleaseExpiredReservations(ctx context.Context) error
+}
+
+// SearchResult contains a cat found by full-text search, its relevance score and highlighted snippets by field name
+type SearchResult struct {
+	Cat        entities.Cat
+	Score      float64
+	Highlights map[string]string
 }
 
 type cats struct {
-	repository    repository.Cats
-	priceProducer producer.Price
+	repository     repository.Cats
+	priceProducer  producer.Price
+	statusProducer producer.Status
+	reservationTTL time.Duration
 }
 
 // NewCatsService creates new cats service
-func NewCatsService(catsRepository repository.Cats, priceProducer producer.Price) Cats {
+func NewCatsService(catsRepository repository.Cats, priceProducer producer.Price, statusProducer producer.Status, reservationTTL time.Duration) Cats {
 	return &cats{
-		repository:    catsRepository,
-		priceProducer: priceProducer,
+		repository:     catsRepository,
+		priceProducer:  priceProducer,
+		statusProducer: statusProducer,
+		reservationTTL: reservationTTL,
+	}
+}
+
+func (c *cats) GetAll(ctx context.Context, filter repository.Filter, page repository.Page) ([]entities.Cat, error) {
+	if err := validatePage(page); err != nil {
+		return nil, err
 	}
+	return c.repository.GetAll(ctx, filter, page)
 }
 
-func (c *cats) GetAll(ctx context.Context) ([]entities.Cat, error) {
-	return c.repository.GetAll(ctx)
+func (c *cats) Search(ctx context.Context, query string, filter repository.Filter, page repository.Page) ([]SearchResult, error) {
+	if query == "" {
+		return nil, NewValidationError("query", "must not be empty")
+	}
+	if err := validatePage(page); err != nil {
+		return nil, err
+	}
+	found, err := c.repository.Search(ctx, query, filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]SearchResult, 0, len(found))
+	for _, r := range found {
+		result = append(result, SearchResult{
+			Cat:        r.Cat,
+			Score:      r.Score,
+			Highlights: highlight(r.Cat, query),
+		})
+	}
+	return result, nil
 }
 
 func (c *cats) GetOne(ctx context.Context, id uuid.UUID) (entities.Cat, error) {
-	return c.repository.GetOne(ctx, id)
+	cat, err := c.repository.GetOne(ctx, id)
+	return cat, translate(err, ErrCatNotFound, nil)
+}
+
+func (c *cats) RecordView(ctx context.Context, id uuid.UUID) error {
+	return translate(c.repository.RecordView(ctx, id), ErrCatNotFound, nil)
 }
 
-func (c *cats) CreateNew(ctx context.Context, name, color string, age int, price float64) (uuid.UUID, error) {
-	id, err := c.repository.Insert(ctx, name, color, age, price)
+func (c *cats) CreateNew(ctx context.Context, cat entities.Cat) (uuid.UUID, error) {
+	if err := ValidateNewCat(cat, time.Now()); err != nil {
+		return uuid.Nil, err
+	}
+	id, err := c.repository.Insert(ctx, cat)
 	if err != nil {
 		return id, err
 	}
 
-	err = c.priceProducer.Produce(ctx, id, price)
-	return id, err
+	return id, c.producePrice(ctx, id, 1, money.Money{Currency: cat.Price.Currency}, cat.Price)
 }
 
 func (c *cats) Delete(ctx context.Context, id uuid.UUID) error {
-	return c.repository.Delete(ctx, id)
+	return translate(c.repository.Delete(ctx, id), ErrCatNotFound, nil)
+}
+
+func (c *cats) UpdatePrice(ctx context.Context, id uuid.UUID, price money.Money) error {
+	if err := validatePrice(price); err != nil {
+		return err
+	}
+	oldPrice, priceVersion, err := c.repository.UpdatePrice(ctx, id, price)
+	if err != nil {
+		return translate(err, ErrCatNotFound, nil)
+	}
+
+	return c.producePrice(ctx, id, priceVersion, oldPrice, price)
+}
+
+func (c *cats) UpdatePriceIfVersion(ctx context.Context, id uuid.UUID, price money.Money, priceVersion uint64) error {
+	if err := validatePrice(price); err != nil {
+		return err
+	}
+	oldPrice, newVersion, err := c.repository.UpdatePriceIfVersion(ctx, id, price, priceVersion)
+	if err != nil {
+		return translate(err, ErrCatNotFound, ErrPriceChanged)
+	}
+
+	return c.producePrice(ctx, id, newVersion, oldPrice, price)
+}
+
+// producePrice produces price change event of tenant carried by ctx
+func (c *cats) producePrice(ctx context.Context, id uuid.UUID, sequence uint64, oldPrice, newPrice money.Money) error {
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	e := event.NewPriceChanged(id, sequence, oldPrice, newPrice)
+	e.TenantID = tenantID
+	return c.priceProducer.Produce(ctx, e)
+}
+
+func (c *cats) Reserve(ctx context.Context, id uuid.UUID) (entities.Reservation, error) {
+	reservation, err := c.repository.Reserve(ctx, id, c.reservationTTL)
+	if err != nil {
+		return reservation, translate(err, ErrCatNotFound, ErrCatNotAvailable)
+	}
+
+	err = c.statusProducer.Produce(ctx, id, string(entities.StatusReserved))
+	return reservation, err
+}
+
+func (c *cats) CancelReservation(ctx context.Context, id, reservationID uuid.UUID) error {
+	err := c.repository.CancelReservation(ctx, id, reservationID)
+	if err != nil {
+		return translate(err, ErrCatNotFound, ErrReservationNotActive)
+	}
+
+	return c.statusProducer.Produce(ctx, id, string(entities.StatusAvailable))
 }
 
-func (c *cats) UpdatePrice(ctx context.Context, id uuid.UUID, price float64) error {
-	err := c.repository.UpdatePrice(ctx, id, price)
+func (c *cats) Purchase(ctx context.Context, id, reservationID uuid.UUID) (entities.Sale, error) {
+	cat, err := c.repository.Purchase(ctx, id, reservationID)
+	if err != nil {
+		return entities.Sale{}, translate(err, ErrCatNotFound, ErrReservationNotActive)
+	}
+
+	err = c.statusProducer.Produce(ctx, id, string(entities.StatusSold))
+	return *cat.Sale, err
+}
+
+// ValidateNewCat checks invariants of a cat that do not depend on transport, cats inserted bypassing the service must satisfy them too
+func ValidateNewCat(cat entities.Cat, now time.Time) error {
+	switch {
+	case cat.Name == "":
+		return NewValidationError("name", "must not be empty")
+	case cat.BirthDate != nil && cat.BirthDate.After(now):
+		return NewValidationError("birthDate", "must not be in the future")
+	}
+	return validatePrice(cat.Price)
+}
+
+// validatePage rejects negative limit and offset, which the repository would fail on or take for another limit
+func validatePage(page repository.Page) error {
+	if page.Limit < 0 {
+		return NewValidationError("limit", "must not be negative")
+	}
+	if page.Offset < 0 {
+		return NewValidationError("offset", "must not be negative")
+	}
+	return nil
+}
+
+func validatePrice(price money.Money) error {
+	if price.Amount < 0 {
+		return NewValidationError("price.amount", "must not be negative")
+	}
+	if err := money.ValidateCurrency(price.Currency); err != nil {
+		return NewValidationError("price.currency", "must be an ISO 4217 currency code, e.g. "+money.DefaultCurrency)
+	}
+	return nil
+}
+
+// ReleaseExpiredReservations releases expired reservations of every tenant within context of that tenant
+func (c *cats) ReleaseExpiredReservations(ctx context.Context) error {
+	tenants, err := c.repository.Tenants(ctx)
 	if err != nil {
 		return err
 	}
+	for _, tenantID := range tenants {
+		if err := c.releaseExpiredReservations(tenant.WithID(ctx, tenantID)); err != nil {
+			return fmt.Errorf("tenant %s: %w", tenantID, err)
+		}
+	}
+	return nil
+}
 
-	err = c.priceProducer.Produce(ctx, id, price)
+func (c *cats) releaseExpiredReservations(ctx context.Context) error {
+	released, err := c.repository.ReleaseExpiredReservations(ctx)
+	for _, id := range released {
+		if err := c.statusProducer.Produce(ctx, id, string(entities.StatusAvailable)); err != nil {
+			return err
+		}
+	}
 	return err
 }
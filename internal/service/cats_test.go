@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/internal/producer"
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+func TestCreateNewValidation(t *testing.T) {
+	future := time.Now().Add(24 * time.Hour)
+	for name, cat := range map[string]entities.Cat{
+		"name":           {Price: money.Money{Amount: 1000, Currency: "USD"}},
+		"price.amount":   {Name: "Bella", Price: money.Money{Amount: -1, Currency: "USD"}},
+		"price.currency": {Name: "Bella", Price: money.Money{Amount: 1000, Currency: "usd"}},
+		"birthDate":      {Name: "Bella", Price: money.Money{Amount: 1000, Currency: "USD"}, BirthDate: &future},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			s := NewCatsService(new(repository.MockCats), new(producer.MockPrice), new(producer.MockStatus), time.Minute)
+
+			// Act
+			_, err := s.CreateNew(context.Background(), cat)
+
+			// Assert
+			var validationErr *ValidationError
+			require.True(t, errors.As(err, &validationErr))
+			require.Equal(t, name, validationErr.Field)
+		})
+	}
+}
+
+func TestSearchEmptyQuery(t *testing.T) {
+	// Arrange
+	s := NewCatsService(new(repository.MockCats), new(producer.MockPrice), new(producer.MockStatus), time.Minute)
+
+	// Act
+	_, err := s.Search(context.Background(), "", repository.Filter{}, repository.Page{})
+
+	// Assert
+	require.EqualError(t, err, "query must not be empty")
+}
+
+func TestGetAllNegativePage(t *testing.T) {
+	for name, page := range map[string]repository.Page{
+		"limit":  {Limit: -1},
+		"offset": {Limit: 10, Offset: -1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			s := NewCatsService(new(repository.MockCats), new(producer.MockPrice), new(producer.MockStatus), time.Minute)
+
+			// Act
+			_, getAllErr := s.GetAll(context.Background(), repository.Filter{}, page)
+			_, searchErr := s.Search(context.Background(), "bella", repository.Filter{}, page)
+
+			// Assert
+			for _, err := range []error{getAllErr, searchErr} {
+				var validationErr *ValidationError
+				require.ErrorAs(t, err, &validationErr)
+				require.Equal(t, name, validationErr.Field)
+			}
+		})
+	}
+}
+
+func TestReserveConflict(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	repo := new(repository.MockCats)
+	repo.On("Reserve", mock.Anything, id, time.Minute).Return(entities.Reservation{}, repository.ErrConflict)
+	s := NewCatsService(repo, new(producer.MockPrice), new(producer.MockStatus), time.Minute)
+
+	// Act
+	_, err := s.Reserve(context.Background(), id)
+
+	// Assert
+	require.ErrorIs(t, err, ErrCatNotAvailable)
+	require.ErrorIs(t, err, repository.ErrConflict)
+}
+
+func TestDeleteNotFound(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	repo := new(repository.MockCats)
+	repo.On("Delete", mock.Anything, id).Return(repository.ErrNotFound)
+	s := NewCatsService(repo, new(producer.MockPrice), new(producer.MockStatus), time.Minute)
+
+	// Act
+	err := s.Delete(context.Background(), id)
+
+	// Assert
+	require.ErrorIs(t, err, ErrCatNotFound)
+}
+
+func TestDeleteFailure(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	failure := errors.New("connection reset")
+	repo := new(repository.MockCats)
+	repo.On("Delete", mock.Anything, id).Return(failure)
+	s := NewCatsService(repo, new(producer.MockPrice), new(producer.MockStatus), time.Minute)
+
+	// Act
+	err := s.Delete(context.Background(), id)
+
+	// Assert
+	require.Equal(t, failure, err)
+}
+
+func TestReleaseExpiredReservationsOfEveryTenant(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	repo := new(repository.MockCats)
+	repo.On("Tenants", mock.Anything).Return([]string{tenant.Default, "shelter-1"}, nil)
+	repo.On("ReleaseExpiredReservations", mockTenant(tenant.Default)).Return(nil, nil)
+	repo.On("ReleaseExpiredReservations", mockTenant("shelter-1")).Return([]uuid.UUID{id}, nil)
+	statusProducer := new(producer.MockStatus)
+	statusProducer.On("Produce", mockTenant("shelter-1"), id, string(entities.StatusAvailable)).Return(nil)
+	s := NewCatsService(repo, new(producer.MockPrice), statusProducer, time.Minute)
+
+	// Act
+	err := s.ReleaseExpiredReservations(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+	statusProducer.AssertExpectations(t)
+}
+
+func TestUpdatePriceProducesEventOfTenant(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	repo := new(repository.MockCats)
+	newPrice := money.Money{Amount: 799, Currency: "USD"}
+	repo.On("UpdatePrice", mock.Anything, id, newPrice).Return(money.Money{Amount: 999, Currency: "USD"}, uint64(2), nil)
+	priceProducer := new(producer.MockPrice)
+	priceProducer.On("Produce", mock.Anything, mock.MatchedBy(func(e event.PriceChanged) bool {
+		return e.TenantID == "shelter-1" && e.CatID == id && e.Sequence == 2 && e.NewPrice == newPrice && e.OldPrice.Amount == 999
+	})).Return(nil)
+	s := NewCatsService(repo, priceProducer, new(producer.MockStatus), time.Minute)
+
+	// Act
+	err := s.UpdatePrice(tenant.WithID(context.Background(), "shelter-1"), id, newPrice)
+
+	// Assert
+	require.NoError(t, err)
+	priceProducer.AssertExpectations(t)
+}
+
+func TestUpdatePriceIfVersionConflict(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	repo := new(repository.MockCats)
+	newPrice := money.Money{Amount: 799, Currency: "USD"}
+	repo.On("UpdatePriceIfVersion", mock.Anything, id, newPrice, uint64(2)).Return(money.Money{}, uint64(0), repository.ErrConflict)
+	priceProducer := new(producer.MockPrice)
+	s := NewCatsService(repo, priceProducer, new(producer.MockStatus), time.Minute)
+
+	// Act
+	err := s.UpdatePriceIfVersion(tenant.WithID(context.Background(), "shelter-1"), id, newPrice, 2)
+
+	// Assert
+	require.ErrorIs(t, err, ErrPriceChanged)
+	priceProducer.AssertNotCalled(t, "Produce", mock.Anything, mock.Anything)
+}
+
+// mockTenant matches a context carrying given tenant
+func mockTenant(id string) interface{} {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		actual, err := tenant.FromContext(ctx)
+		return err == nil && actual == id
+	})
+}
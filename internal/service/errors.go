@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/internal/repository"
+)
+
+// Kind is a class of domain errors, transports map kinds to their status codes
+type Kind int
+
+const (
+	// KindNotFound means an entity the usecase works with does not exist
+	KindNotFound Kind = iota + 1
+	// KindConflict means current state of an entity does not allow the usecase
+	KindConflict
+	// KindTooLarge means input exceeds a size limit
+	KindTooLarge
+	// KindUnsupported means input has a format that is not supported
+	KindUnsupported
+	// KindUnprocessable means input is valid, but data needed to process it is missing
+	KindUnprocessable
+)
+
+// Error is a domain error with a stable code, its message is safe to show to clients
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	cause   error
+}
+
+var (
+	// ErrCatNotFound means there is no cat with given ID
+	ErrCatNotFound = newError(KindNotFound, "cat_not_found", "cat is not found", repository.ErrNotFound)
+	// ErrPhotoNotFound means a cat has no photo with given ID
+	ErrPhotoNotFound = newError(KindNotFound, "photo_not_found", "photo is not found", repository.ErrNotFound)
+	// ErrCatNotAvailable means a cat is already reserved or sold
+	ErrCatNotAvailable = newError(KindConflict, "cat_not_available", "cat is not available", repository.ErrConflict)
+	// ErrReservationNotActive means a reservation has expired, has been cancelled or belongs to another hold
+	ErrReservationNotActive = newError(KindConflict, "reservation_not_active", "reservation is not active", repository.ErrConflict)
+	// ErrPriceChanged means price of a cat has been changed since it was read
+	ErrPriceChanged = newError(KindConflict, "price_changed", "price has changed since it was read", repository.ErrConflict)
+	// ErrScheduleNotFound means there is no price schedule with given ID
+	ErrScheduleNotFound = newError(KindNotFound, "schedule_not_found", "schedule is not found", repository.ErrNotFound)
+	// ErrScheduleNotEditable means a schedule has already started, or is being run, so it cannot be changed
+	ErrScheduleNotEditable = newError(KindConflict, "schedule_not_editable", "schedule has already started", repository.ErrConflict)
+	// ErrPhotoTooLarge means uploaded photo exceeds size limit
+	ErrPhotoTooLarge = newError(KindTooLarge, "photo_too_large", "photo is too large", nil)
+	// ErrUnsupportedPhotoType means uploaded photo has a MIME type that is not allowed
+	ErrUnsupportedPhotoType = newError(KindUnsupported, "unsupported_photo_type", "unsupported photo type", nil)
+	// ErrRateUnavailable means there is no exchange rate to the requested display currency
+	ErrRateUnavailable = newError(KindUnprocessable, "rate_unavailable", "exchange rate is not available", money.ErrNoRate)
+)
+
+func newError(kind Kind, code, message string, cause error) *Error {
+	return &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: message,
+		cause:   cause,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns underlying error the domain error stands for, if any
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// translate replaces repository errors with domain errors of the usecase
+func translate(err, notFound, conflict error) error {
+	switch {
+	case notFound != nil && errors.Is(err, repository.ErrNotFound):
+		return notFound
+	case conflict != nil && errors.Is(err, repository.ErrConflict):
+		return conflict
+	}
+	return err
+}
+
+// ValidationCode is a stable code of validation errors
+const ValidationCode = "validation_failed"
+
+// ValidationError means input of a usecase is invalid
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+// NewValidationError creates validation error of a field
+func NewValidationError(field, message string) *ValidationError {
+	return &ValidationError{
+		Field:   field,
+		Message: message,
+	}
+}
+
+func (e *ValidationError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s %s", e.Field, e.Message)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/evleria/cats-app/internal/repository/entities"
+)
+
+const (
+	// snippetLength is a maximum length of a snippet cut from long text, in bytes
+	snippetLength = 160
+	// snippetLead is a length of text kept before the first match in a snippet, in bytes
+	snippetLead = 40
+)
+
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// highlight returns HTML-escaped snippets of searchable cat fields that contain query terms,
+// matched words are wrapped into <em> tags. Fields without matches are omitted.
+func highlight(cat entities.Cat, query string) map[string]string {
+	terms := searchTerms(query)
+	fields := map[string]string{
+		"name":        cat.Name,
+		"color":       cat.Color,
+		"breed":       cat.Breed,
+		"description": cat.Description,
+	}
+
+	result := map[string]string{}
+	for field, text := range fields {
+		if snippet, ok := snippet(text, terms); ok {
+			result[field] = snippet
+		}
+	}
+	return result
+}
+
+// searchTerms extracts lowercase words from query, negated terms are skipped
+func searchTerms(query string) []string {
+	var terms []string
+	for _, token := range strings.Fields(strings.ToLower(query)) {
+		if strings.HasPrefix(token, "-") {
+			continue
+		}
+		terms = append(terms, wordPattern.FindAllString(token, -1)...)
+	}
+	return terms
+}
+
+func snippet(text string, terms []string) (string, bool) {
+	words := wordPattern.FindAllStringIndex(text, -1)
+
+	var matches [][]int
+	for _, word := range words {
+		if matchesAny(strings.ToLower(text[word[0]:word[1]]), terms) {
+			matches = append(matches, word)
+		}
+	}
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	start, end := 0, len(text)
+	if len(text) > snippetLength {
+		start, end = window(words, matches[0][0])
+	}
+
+	builder := strings.Builder{}
+	if start > 0 {
+		builder.WriteString("…")
+	}
+	position := start
+	for _, match := range matches {
+		if match[0] < start || match[1] > end {
+			continue
+		}
+		builder.WriteString(html.EscapeString(text[position:match[0]]))
+		builder.WriteString("<em>")
+		builder.WriteString(html.EscapeString(text[match[0]:match[1]]))
+		builder.WriteString("</em>")
+		position = match[1]
+	}
+	builder.WriteString(html.EscapeString(text[position:end]))
+	if end < len(text) {
+		builder.WriteString("…")
+	}
+	return builder.String(), true
+}
+
+// window finds word boundaries of a snippet around the first match
+func window(words [][]int, firstMatch int) (start, end int) {
+	start = firstMatch
+	for _, word := range words {
+		if word[0] >= firstMatch-snippetLead {
+			start = word[0]
+			break
+		}
+	}
+	end = start
+	for _, word := range words {
+		if word[0] >= start && word[1] <= start+snippetLength {
+			end = word[1]
+		}
+	}
+	return start, end
+}
+
+// matchesAny roughly emulates stemming of text index: "cat" matches "cats" and vice versa
+func matchesAny(word string, terms []string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) || (len(word) >= 3 && strings.HasPrefix(term, word)) {
+			return true
+		}
+	}
+	return false
+}
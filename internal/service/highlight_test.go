@@ -0,0 +1,51 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/repository/entities"
+)
+
+func TestHighlight(t *testing.T) {
+	// Arrange
+	cat := entities.Cat{Name: "Ms. Bella", Color: "brown", Breed: "Maine Coon", Description: "Loves <b>big</b> boxes"}
+
+	// Act
+	result := highlight(cat, "bella BOX -brown")
+
+	// Assert
+	require.Equal(t, map[string]string{
+		"name":        "Ms. <em>Bella</em>",
+		"description": "Loves &lt;b&gt;big&lt;/b&gt; <em>boxes</em>",
+	}, result)
+}
+
+func TestHighlightStemmedTerm(t *testing.T) {
+	// Arrange
+	cat := entities.Cat{Description: "A very playful cat"}
+
+	// Act
+	result := highlight(cat, "cats")
+
+	// Assert
+	require.Equal(t, "A very playful <em>cat</em>", result["description"])
+}
+
+func TestHighlightLongDescription(t *testing.T) {
+	// Arrange
+	description := strings.Repeat("lorem ipsum ", 20) + "sleeps on a warm windowsill " + strings.Repeat("dolor sit amet ", 20)
+	cat := entities.Cat{Description: description}
+
+	// Act
+	result := highlight(cat, "windowsill")
+
+	// Assert
+	snippet := result["description"]
+	require.True(t, strings.HasPrefix(snippet, "…"))
+	require.True(t, strings.HasSuffix(snippet, "…"))
+	require.Contains(t, snippet, "warm <em>windowsill</em>")
+	require.LessOrEqual(t, len(snippet), snippetLength+len("……<em></em>"))
+}
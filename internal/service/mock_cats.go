@@ -5,11 +5,12 @@ package service
 import (
 	context "context"
 
+	uuid "github.com/google/uuid"
 	mock "github.com/stretchr/testify/mock"
 
+	money "github.com/evleria/cats-app/internal/money"
+	repository "github.com/evleria/cats-app/internal/repository"
 	entities "github.com/evleria/cats-app/internal/repository/entities"
-
-	uuid "github.com/google/uuid"
 )
 
 // MockCats is an autogenerated mock type for the Cats type
@@ -17,13 +18,27 @@ type MockCats struct {
 	mock.Mock
 }
 
-// CreateNew provides a mock function with given fields: ctx, name, color, age, price
-func (_m *MockCats) CreateNew(ctx context.Context, name string, color string, age int, price float64) (uuid.UUID, error) {
-	ret := _m.Called(ctx, name, color, age, price)
+// CancelReservation provides a mock function with given fields: ctx, id, reservationID
+func (_m *MockCats) CancelReservation(ctx context.Context, id uuid.UUID, reservationID uuid.UUID) error {
+	ret := _m.Called(ctx, id, reservationID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r0 = rf(ctx, id, reservationID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateNew provides a mock function with given fields: ctx, cat
+func (_m *MockCats) CreateNew(ctx context.Context, cat entities.Cat) (uuid.UUID, error) {
+	ret := _m.Called(ctx, cat)
 
 	var r0 uuid.UUID
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, int, float64) uuid.UUID); ok {
-		r0 = rf(ctx, name, color, age, price)
+	if rf, ok := ret.Get(0).(func(context.Context, entities.Cat) uuid.UUID); ok {
+		r0 = rf(ctx, cat)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(uuid.UUID)
@@ -31,8 +46,8 @@ func (_m *MockCats) CreateNew(ctx context.Context, name string, color string, ag
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, int, float64) error); ok {
-		r1 = rf(ctx, name, color, age, price)
+	if rf, ok := ret.Get(1).(func(context.Context, entities.Cat) error); ok {
+		r1 = rf(ctx, cat)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -54,13 +69,13 @@ func (_m *MockCats) Delete(ctx context.Context, id uuid.UUID) error {
 	return r0
 }
 
-// GetAll provides a mock function with given fields: ctx
-func (_m *MockCats) GetAll(ctx context.Context) ([]entities.Cat, error) {
-	ret := _m.Called(ctx)
+// GetAll provides a mock function with given fields: ctx, filter, page
+func (_m *MockCats) GetAll(ctx context.Context, filter repository.Filter, page repository.Page) ([]entities.Cat, error) {
+	ret := _m.Called(ctx, filter, page)
 
 	var r0 []entities.Cat
-	if rf, ok := ret.Get(0).(func(context.Context) []entities.Cat); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, repository.Filter, repository.Page) []entities.Cat); ok {
+		r0 = rf(ctx, filter, page)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]entities.Cat)
@@ -68,8 +83,8 @@ func (_m *MockCats) GetAll(ctx context.Context) ([]entities.Cat, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, repository.Filter, repository.Page) error); ok {
+		r1 = rf(ctx, filter, page)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -98,12 +113,105 @@ func (_m *MockCats) GetOne(ctx context.Context, id uuid.UUID) (entities.Cat, err
 	return r0, r1
 }
 
+// Purchase provides a mock function with given fields: ctx, id, reservationID
+func (_m *MockCats) Purchase(ctx context.Context, id uuid.UUID, reservationID uuid.UUID) (entities.Sale, error) {
+	ret := _m.Called(ctx, id, reservationID)
+
+	var r0 entities.Sale
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) entities.Sale); ok {
+		r0 = rf(ctx, id, reservationID)
+	} else {
+		r0 = ret.Get(0).(entities.Sale)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r1 = rf(ctx, id, reservationID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordView provides a mock function with given fields: ctx, id
+func (_m *MockCats) RecordView(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReleaseExpiredReservations provides a mock function with given fields: ctx
+func (_m *MockCats) ReleaseExpiredReservations(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Reserve provides a mock function with given fields: ctx, id
+func (_m *MockCats) Reserve(ctx context.Context, id uuid.UUID) (entities.Reservation, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 entities.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) entities.Reservation); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(entities.Reservation)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Search provides a mock function with given fields: ctx, query, filter, page
+func (_m *MockCats) Search(ctx context.Context, query string, filter repository.Filter, page repository.Page) ([]SearchResult, error) {
+	ret := _m.Called(ctx, query, filter, page)
+
+	var r0 []SearchResult
+	if rf, ok := ret.Get(0).(func(context.Context, string, repository.Filter, repository.Page) []SearchResult); ok {
+		r0 = rf(ctx, query, filter, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]SearchResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, repository.Filter, repository.Page) error); ok {
+		r1 = rf(ctx, query, filter, page)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdatePrice provides a mock function with given fields: ctx, id, price
-func (_m *MockCats) UpdatePrice(ctx context.Context, id uuid.UUID, price float64) error {
+func (_m *MockCats) UpdatePrice(ctx context.Context, id uuid.UUID, price money.Money) error {
 	ret := _m.Called(ctx, id, price)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, float64) error); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, money.Money) error); ok {
 		r0 = rf(ctx, id, price)
 	} else {
 		r0 = ret.Error(0)
@@ -111,3 +219,17 @@ func (_m *MockCats) UpdatePrice(ctx context.Context, id uuid.UUID, price float64
 
 	return r0
 }
+
+// UpdatePriceIfVersion provides a mock function with given fields: ctx, id, price, priceVersion
+func (_m *MockCats) UpdatePriceIfVersion(ctx context.Context, id uuid.UUID, price money.Money, priceVersion uint64) error {
+	ret := _m.Called(ctx, id, price, priceVersion)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, money.Money, uint64) error); ok {
+		r0 = rf(ctx, id, price, priceVersion)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
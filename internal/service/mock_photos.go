@@ -0,0 +1,106 @@
+// Code generated by mockery v0.0.0-dev. DO NOT EDIT.
+
+package service
+
+import (
+	context "context"
+	io "io"
+
+	uuid "github.com/google/uuid"
+	mock "github.com/stretchr/testify/mock"
+
+	entities "github.com/evleria/cats-app/internal/repository/entities"
+)
+
+// MockPhotos is an autogenerated mock type for the Photos type
+type MockPhotos struct {
+	mock.Mock
+}
+
+// Delete provides a mock function with given fields: ctx, catID, photoID
+func (_m *MockPhotos) Delete(ctx context.Context, catID uuid.UUID, photoID uuid.UUID) error {
+	ret := _m.Called(ctx, catID, photoID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID) error); ok {
+		r0 = rf(ctx, catID, photoID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAll provides a mock function with given fields: ctx, catID
+func (_m *MockPhotos) GetAll(ctx context.Context, catID uuid.UUID) ([]entities.Photo, error) {
+	ret := _m.Called(ctx, catID)
+
+	var r0 []entities.Photo
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) []entities.Photo); ok {
+		r0 = rf(ctx, catID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entities.Photo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, catID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOne provides a mock function with given fields: ctx, catID, photoID, thumbnail
+func (_m *MockPhotos) GetOne(ctx context.Context, catID uuid.UUID, photoID uuid.UUID, thumbnail bool) (entities.Photo, io.ReadSeekCloser, error) {
+	ret := _m.Called(ctx, catID, photoID, thumbnail)
+
+	var r0 entities.Photo
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, uuid.UUID, bool) entities.Photo); ok {
+		r0 = rf(ctx, catID, photoID, thumbnail)
+	} else {
+		r0 = ret.Get(0).(entities.Photo)
+	}
+
+	var r1 io.ReadSeekCloser
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, uuid.UUID, bool) io.ReadSeekCloser); ok {
+		r1 = rf(ctx, catID, photoID, thumbnail)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(io.ReadSeekCloser)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, uuid.UUID, uuid.UUID, bool) error); ok {
+		r2 = rf(ctx, catID, photoID, thumbnail)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Upload provides a mock function with given fields: ctx, catID, data
+func (_m *MockPhotos) Upload(ctx context.Context, catID uuid.UUID, data []byte) (entities.Photo, error) {
+	ret := _m.Called(ctx, catID, data)
+
+	var r0 entities.Photo
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, []byte) entities.Photo); ok {
+		r0 = rf(ctx, catID, data)
+	} else {
+		r0 = ret.Get(0).(entities.Photo)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, []byte) error); ok {
+		r1 = rf(ctx, catID, data)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
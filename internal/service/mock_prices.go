@@ -0,0 +1,39 @@
+// Code generated by mockery v0.0.0-dev. DO NOT EDIT.
+
+package service
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	money "github.com/evleria/cats-app/internal/money"
+)
+
+// MockPrices is an autogenerated mock type for the Prices type
+type MockPrices struct {
+	mock.Mock
+}
+
+// Display provides a mock function with given fields: ctx, prices, currency
+func (_m *MockPrices) Display(ctx context.Context, prices []money.Money, currency string) ([]money.Money, error) {
+	ret := _m.Called(ctx, prices, currency)
+
+	var r0 []money.Money
+	if rf, ok := ret.Get(0).(func(context.Context, []money.Money, string) []money.Money); ok {
+		r0 = rf(ctx, prices, currency)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]money.Money)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []money.Money, string) error); ok {
+		r1 = rf(ctx, prices, currency)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
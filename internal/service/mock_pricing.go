@@ -0,0 +1,68 @@
+// Code generated by mockery v0.0.0-dev. DO NOT EDIT.
+
+package service
+
+import (
+	context "context"
+
+	uuid "github.com/google/uuid"
+	mock "github.com/stretchr/testify/mock"
+
+	repository "github.com/evleria/cats-app/internal/repository"
+)
+
+// MockPricing is an autogenerated mock type for the Pricing type
+type MockPricing struct {
+	mock.Mock
+}
+
+// Preview provides a mock function with given fields: ctx, filter
+func (_m *MockPricing) Preview(ctx context.Context, filter repository.Filter) ([]PriceChange, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []PriceChange
+	if rf, ok := ret.Get(0).(func(context.Context, repository.Filter) []PriceChange); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]PriceChange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, repository.Filter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Reprice provides a mock function with given fields: ctx, id
+func (_m *MockPricing) Reprice(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RunAll provides a mock function with given fields: ctx
+func (_m *MockPricing) RunAll(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,127 @@
+// Code generated by mockery v0.0.0-dev. DO NOT EDIT.
+
+package service
+
+import (
+	context "context"
+
+	uuid "github.com/google/uuid"
+	mock "github.com/stretchr/testify/mock"
+
+	repository "github.com/evleria/cats-app/internal/repository"
+	entities "github.com/evleria/cats-app/internal/repository/entities"
+)
+
+// MockSchedules is an autogenerated mock type for the Schedules type
+type MockSchedules struct {
+	mock.Mock
+}
+
+// Create provides a mock function with given fields: ctx, schedule
+func (_m *MockSchedules) Create(ctx context.Context, schedule entities.PriceSchedule) (uuid.UUID, error) {
+	ret := _m.Called(ctx, schedule)
+
+	var r0 uuid.UUID
+	if rf, ok := ret.Get(0).(func(context.Context, entities.PriceSchedule) uuid.UUID); ok {
+		r0 = rf(ctx, schedule)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, entities.PriceSchedule) error); ok {
+		r1 = rf(ctx, schedule)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: ctx, id
+func (_m *MockSchedules) Delete(ctx context.Context, id uuid.UUID) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAll provides a mock function with given fields: ctx, page
+func (_m *MockSchedules) GetAll(ctx context.Context, page repository.Page) ([]entities.PriceSchedule, error) {
+	ret := _m.Called(ctx, page)
+
+	var r0 []entities.PriceSchedule
+	if rf, ok := ret.Get(0).(func(context.Context, repository.Page) []entities.PriceSchedule); ok {
+		r0 = rf(ctx, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entities.PriceSchedule)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, repository.Page) error); ok {
+		r1 = rf(ctx, page)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOne provides a mock function with given fields: ctx, id
+func (_m *MockSchedules) GetOne(ctx context.Context, id uuid.UUID) (entities.PriceSchedule, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 entities.PriceSchedule
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) entities.PriceSchedule); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(entities.PriceSchedule)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RunDue provides a mock function with given fields: ctx, fence
+func (_m *MockSchedules) RunDue(ctx context.Context, fence func(context.Context) error) error {
+	ret := _m.Called(ctx, fence)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(context.Context) error) error); ok {
+		r0 = rf(ctx, fence)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, schedule
+func (_m *MockSchedules) Update(ctx context.Context, schedule entities.PriceSchedule) error {
+	ret := _m.Called(ctx, schedule)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, entities.PriceSchedule) error); ok {
+		r0 = rf(ctx, schedule)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/repository/entities"
+)
+
+// Photos contains usecase logic for cat photos
+type Photos interface {
+	Upload(ctx context.Context, catID uuid.UUID, data []byte) (entities.Photo, error)
+	GetAll(ctx context.Context, catID uuid.UUID) ([]entities.Photo, error)
+	// GetOne returns a photo or its thumbnail with its content, which must be closed
+	GetOne(ctx context.Context, catID, photoID uuid.UUID, thumbnail bool) (entities.Photo, io.ReadSeekCloser, error)
+	Delete(ctx context.Context, catID, photoID uuid.UUID) error
+}
+
+// PhotoLimits contains restrictions applied to uploaded photos
+type PhotoLimits struct {
+	MaxSize       int64
+	AllowedTypes  []string
+	ThumbnailSize int
+}
+
+type photos struct {
+	catsRepository   repository.Cats
+	photosRepository repository.Photos
+	limits           PhotoLimits
+}
+
+// NewPhotosService creates new photos service
+func NewPhotosService(catsRepository repository.Cats, photosRepository repository.Photos, limits PhotoLimits) Photos {
+	return &photos{
+		catsRepository:   catsRepository,
+		photosRepository: photosRepository,
+		limits:           limits,
+	}
+}
+
+func (p *photos) Upload(ctx context.Context, catID uuid.UUID, data []byte) (entities.Photo, error) {
+	photo := entities.Photo{
+		ID:          uuid.New(),
+		ThumbnailID: uuid.New(),
+		ContentType: http.DetectContentType(data),
+		Size:        int64(len(data)),
+		UploadedAt:  time.Now().UTC(),
+	}
+	if photo.Size > p.limits.MaxSize {
+		return photo, ErrPhotoTooLarge
+	}
+	if !p.isAllowed(photo.ContentType) {
+		return photo, ErrUnsupportedPhotoType
+	}
+	if _, err := p.catsRepository.GetOne(ctx, catID); err != nil {
+		return photo, translate(err, ErrCatNotFound, nil)
+	}
+
+	thumbnail, thumbnailType, err := makeThumbnail(data, p.limits.ThumbnailSize)
+	if errors.Is(err, ErrPhotoTooLarge) {
+		return photo, err
+	} else if err != nil {
+		return photo, ErrUnsupportedPhotoType
+	}
+	if err := p.photosRepository.Upload(ctx, photo.ID, photo.ContentType, data); err != nil {
+		return photo, err
+	}
+	if err := p.photosRepository.Upload(ctx, photo.ThumbnailID, thumbnailType, thumbnail); err != nil {
+		p.deleteFiles(ctx, photo.ID)
+		return photo, err
+	}
+	if err := p.catsRepository.AddPhoto(ctx, catID, photo); err != nil {
+		// files of a photo no cat refers to would never be deleted
+		p.deleteFiles(ctx, photo.ID, photo.ThumbnailID)
+		return photo, translate(err, ErrCatNotFound, nil)
+	}
+	return photo, nil
+}
+
+func (p *photos) GetAll(ctx context.Context, catID uuid.UUID) ([]entities.Photo, error) {
+	cat, err := p.catsRepository.GetOne(ctx, catID)
+	if err != nil {
+		return nil, translate(err, ErrCatNotFound, nil)
+	}
+	return cat.Photos, nil
+}
+
+func (p *photos) GetOne(ctx context.Context, catID, photoID uuid.UUID, thumbnail bool) (entities.Photo, io.ReadSeekCloser, error) {
+	photo, err := p.find(ctx, catID, photoID)
+	if err != nil {
+		return photo, nil, err
+	}
+
+	fileID := photo.ID
+	if thumbnail {
+		fileID = photo.ThumbnailID
+	}
+	file, err := p.photosRepository.Open(ctx, fileID)
+	if err != nil {
+		return photo, nil, translate(err, ErrPhotoNotFound, nil)
+	}
+	if thumbnail {
+		photo.ContentType = file.ContentType
+		photo.Size = file.Size
+	}
+	return photo, file, nil
+}
+
+func (p *photos) Delete(ctx context.Context, catID, photoID uuid.UUID) error {
+	photo, err := p.catsRepository.RemovePhoto(ctx, catID, photoID)
+	if err != nil {
+		return translate(err, ErrPhotoNotFound, nil)
+	}
+
+	for _, fileID := range []uuid.UUID{photo.ID, photo.ThumbnailID} {
+		if err := p.photosRepository.Delete(ctx, fileID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteFiles deletes files of a photo that has failed to upload, a failure is only logged as the upload has failed anyway
+func (p *photos) deleteFiles(ctx context.Context, fileIDs ...uuid.UUID) {
+	for _, fileID := range fileIDs {
+		if err := p.photosRepository.Delete(ctx, fileID); err != nil {
+			logging.Warnf("cannot delete photo file %s: %v\n", fileID, err)
+		}
+	}
+}
+
+func (p *photos) find(ctx context.Context, catID, photoID uuid.UUID) (entities.Photo, error) {
+	photos, err := p.GetAll(ctx, catID)
+	if err != nil {
+		return entities.Photo{}, err
+	}
+	for _, photo := range photos {
+		if photo.ID == photoID {
+			return photo, nil
+		}
+	}
+	return entities.Photo{}, ErrPhotoNotFound
+}
+
+func (p *photos) isAllowed(contentType string) bool {
+	for _, allowed := range p.limits.AllowedTypes {
+		if contentType == allowed {
+			return true
+		}
+	}
+	return false
+}
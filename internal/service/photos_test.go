@@ -0,0 +1,39 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/repository/entities"
+)
+
+func TestUploadPhotoDeletesFilesWhenCatIsNotUpdated(t *testing.T) {
+	// Arrange
+	catID := uuid.New()
+	buffer := new(bytes.Buffer)
+	require.NoError(t, png.Encode(buffer, image.NewRGBA(image.Rect(0, 0, 40, 40))))
+	catsRepo := new(repository.MockCats)
+	catsRepo.On("GetOne", mock.Anything, catID).Return(entities.Cat{ID: catID}, nil)
+	catsRepo.On("AddPhoto", mock.Anything, catID, mock.Anything).Return(errors.New("mongo is down"))
+	photosRepo := new(repository.MockPhotos)
+	photosRepo.On("Upload", mock.Anything, mock.Anything, "image/png", mock.Anything).Return(nil)
+	photosRepo.On("Delete", mock.Anything, mock.Anything).Return(nil)
+	s := NewPhotosService(catsRepo, photosRepo, PhotoLimits{MaxSize: 1 << 20, AllowedTypes: []string{"image/png"}, ThumbnailSize: 10})
+
+	// Act
+	photo, err := s.Upload(context.Background(), catID, buffer.Bytes())
+
+	// Assert
+	require.EqualError(t, err, "mongo is down")
+	photosRepo.AssertCalled(t, "Delete", mock.Anything, photo.ID)
+	photosRepo.AssertCalled(t, "Delete", mock.Anything, photo.ThumbnailID)
+}
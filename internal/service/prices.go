@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/evleria/cats-app/internal/money"
+)
+
+// Prices contains usecase logic for showing prices in a currency clients choose
+type Prices interface {
+	// Display converts prices to currency keeping their order, empty currency leaves them as they are
+	Display(ctx context.Context, prices []money.Money, currency string) ([]money.Money, error)
+}
+
+type prices struct {
+	converter *money.Converter
+}
+
+// NewPricesService creates new prices service
+func NewPricesService(converter *money.Converter) Prices {
+	return &prices{
+		converter: converter,
+	}
+}
+
+func (p *prices) Display(ctx context.Context, prices []money.Money, currency string) ([]money.Money, error) {
+	if currency == "" {
+		return prices, nil
+	}
+	if err := money.ValidateCurrency(currency); err != nil {
+		return nil, NewValidationError("currency", "must be an ISO 4217 currency code, e.g. "+money.DefaultCurrency)
+	}
+
+	result := make([]money.Money, 0, len(prices))
+	for _, price := range prices {
+		converted, err := p.converter.Convert(ctx, price, currency)
+		if errors.Is(err, money.ErrNoRate) {
+			return nil, ErrRateUnavailable
+		} else if err != nil {
+			return nil, err
+		}
+		result = append(result, converted)
+	}
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/money"
+)
+
+func TestDisplayConvertsPrices(t *testing.T) {
+	// Arrange
+	rates := money.Rates{Base: "USD", Rates: map[string]*big.Rat{"EUR": big.NewRat(92, 100)}}
+	s := NewPricesService(money.NewConverter(rates))
+	prices := []money.Money{{Amount: 999, Currency: "USD"}, {Amount: 500, Currency: "EUR"}}
+
+	// Act
+	displayed, err := s.Display(context.Background(), prices, "EUR")
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, []money.Money{{Amount: 919, Currency: "EUR"}, {Amount: 500, Currency: "EUR"}}, displayed)
+}
+
+func TestDisplayWithoutCurrency(t *testing.T) {
+	// Arrange
+	s := NewPricesService(money.NewConverter(nil))
+	prices := []money.Money{{Amount: 999, Currency: "USD"}}
+
+	// Act
+	displayed, err := s.Display(context.Background(), prices, "")
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, prices, displayed)
+}
+
+func TestDisplayErrors(t *testing.T) {
+	// Arrange
+	s := NewPricesService(money.NewConverter(nil))
+	prices := []money.Money{{Amount: 999, Currency: "USD"}}
+
+	// Act
+	_, noRateErr := s.Display(context.Background(), prices, "EUR")
+	_, invalidErr := s.Display(context.Background(), prices, "euro")
+
+	// Assert
+	require.ErrorIs(t, noRateErr, ErrRateUnavailable)
+	var validationErr *ValidationError
+	require.True(t, errors.As(invalidErr, &validationErr))
+	require.Equal(t, "currency", validationErr.Field)
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/internal/pricing"
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+// PriceChange is a price pricing rules give a cat and why, automatic prices are set by auto rules only,
+// while suggest rules only propose prices in previews.
+// Rule is empty when no automatic rule matches an automatically priced cat anymore, so its base price is restored.
+type PriceChange struct {
+	Cat       entities.Cat
+	Facts     pricing.Facts
+	BasePrice money.Money
+	Price     money.Money
+	Rule      string
+	Automatic bool
+	Reasons   []string
+}
+
+// Pricing contains usecase logic for rule-based prices.
+// Rules are applied to a base price, i.e. the last price set by hand, so automatic prices never compound.
+// Prices are changed through Cats.UpdatePrice, so price events are produced as for manual changes.
+type Pricing interface {
+	// Preview returns prices rules would give to cats matching filter, automatic or suggested, without changing anything
+	Preview(ctx context.Context, filter repository.Filter) ([]PriceChange, error)
+	// Reprice applies automatic rules to a single cat, e.g. when it is added or its price is changed by hand
+	Reprice(ctx context.Context, id uuid.UUID) error
+	// RunAll applies automatic rules to cats of every tenant.
+	// It must be run by a single instance at a time, e.g. by a leader.
+	RunAll(ctx context.Context) error
+}
+
+// pricingBatchSize is a number of cats loaded at once when rules are applied to all cats
+const pricingBatchSize = 500
+
+type pricingService struct {
+	catsRepository      repository.Cats
+	schedulesRepository repository.PriceSchedules
+	cats                Cats
+	rules               pricing.Provider
+}
+
+// NewPricingService creates new pricing service, cats service must not reprice cats on its own
+func NewPricingService(catsRepository repository.Cats, schedulesRepository repository.PriceSchedules, catsService Cats, rules pricing.Provider) Pricing {
+	return &pricingService{
+		catsRepository:      catsRepository,
+		schedulesRepository: schedulesRepository,
+		cats:                catsService,
+		rules:               rules,
+	}
+}
+
+func (p *pricingService) Preview(ctx context.Context, filter repository.Filter) ([]PriceChange, error) {
+	cats, promoted, err := p.candidates(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	rules, now := p.rules.Rules(), time.Now()
+	changes := make([]PriceChange, 0)
+	for _, cat := range cats {
+		if promoted[cat.ID] {
+			continue
+		}
+		if change, ok := evaluate(rules, cat, now); ok {
+			changes = append(changes, change)
+		}
+		changes = append(changes, suggest(rules, cat, now)...)
+	}
+	return changes, nil
+}
+
+func (p *pricingService) Reprice(ctx context.Context, id uuid.UUID) error {
+	cat, err := p.cats.GetOne(ctx, id)
+	if err != nil {
+		return err
+	}
+	if cat.Status == entities.StatusSold {
+		return nil
+	}
+	promoted, err := p.promoted(ctx)
+	if err != nil || promoted[cat.ID] {
+		return err
+	}
+	return p.apply(ctx, p.rules.Rules(), cat, time.Now())
+}
+
+func (p *pricingService) RunAll(ctx context.Context) error {
+	tenants, err := p.catsRepository.Tenants(ctx)
+	if err != nil {
+		return err
+	}
+	for _, tenantID := range tenants {
+		if err := p.run(tenant.WithID(ctx, tenantID)); err != nil {
+			return fmt.Errorf("tenant %s: %w", tenantID, err)
+		}
+	}
+	return nil
+}
+
+// run applies automatic rules to cats of tenant carried by ctx, cats are loaded in pages of pricingBatchSize
+func (p *pricingService) run(ctx context.Context) error {
+	promoted, err := p.promoted(ctx)
+	if err != nil {
+		return err
+	}
+	rules, now := p.rules.Rules(), time.Now()
+	for page := (repository.Page{Limit: pricingBatchSize}); ; page.Offset += page.Limit {
+		cats, err := p.cats.GetAll(ctx, repository.Filter{}, page)
+		if err != nil {
+			return err
+		}
+		for _, cat := range cats {
+			if cat.Status == entities.StatusSold || promoted[cat.ID] {
+				continue
+			}
+			if err := p.apply(ctx, rules, cat, now); err != nil && !errors.Is(err, ErrCatNotFound) {
+				return fmt.Errorf("cat %s: %w", cat.ID, err)
+			}
+		}
+		if int64(len(cats)) < page.Limit {
+			return nil
+		}
+	}
+}
+
+// candidates returns unsold cats matching filter for preview and cats having prices of running schedules,
+// those keep the scheduled price until the schedule is over
+func (p *pricingService) candidates(ctx context.Context, filter repository.Filter) ([]entities.Cat, map[uuid.UUID]bool, error) {
+	cats, err := p.cats.GetAll(ctx, filter, repository.Page{})
+	if err != nil {
+		return nil, nil, err
+	}
+	promoted, err := p.promoted(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	unsold := make([]entities.Cat, 0, len(cats))
+	for _, cat := range cats {
+		if cat.Status != entities.StatusSold {
+			unsold = append(unsold, cat)
+		}
+	}
+	return unsold, promoted, nil
+}
+
+func (p *pricingService) promoted(ctx context.Context) (map[uuid.UUID]bool, error) {
+	running, err := p.schedulesRepository.Running(ctx)
+	if err != nil {
+		return nil, err
+	}
+	promoted := make(map[uuid.UUID]bool)
+	for _, schedule := range running {
+		for _, applied := range schedule.Applied {
+			promoted[applied.CatID] = promoted[applied.CatID] || !applied.Reverted
+		}
+	}
+	return promoted, nil
+}
+
+// apply sets an automatic price of a cat. Automatic price is recorded before it is set and cleared after base price
+// is restored, so an interrupted run never makes an automatic price look like one set by hand.
+// Price is set only if it has not changed since cat was read, otherwise the cat is left to the next run of rules.
+func (p *pricingService) apply(ctx context.Context, rules pricing.Rules, cat entities.Cat, now time.Time) error {
+	change, ok := evaluate(rules, cat, now)
+	if !ok || !change.Automatic {
+		return nil
+	}
+	if change.Rule != "" {
+		autoPrice := &entities.AutoPrice{Rule: change.Rule, BasePrice: change.BasePrice, Price: change.Price}
+		if err := p.catsRepository.SetAutoPrice(ctx, cat.ID, autoPrice); err != nil {
+			return translate(err, ErrCatNotFound, nil)
+		}
+	}
+	err := p.cats.UpdatePriceIfVersion(ctx, cat.ID, change.Price, cat.PriceVersion)
+	if errors.Is(err, ErrPriceChanged) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if change.Rule == "" {
+		return translate(p.catsRepository.SetAutoPrice(ctx, cat.ID, nil), ErrCatNotFound, nil)
+	}
+	return nil
+}
+
+// evaluate returns a price change automatic rules make to a cat, false means its price stays as it is.
+// Suggest rules are not considered, so they neither change prices nor hide automatic rules of lower priority.
+func evaluate(rules pricing.Rules, cat entities.Cat, now time.Time) (PriceChange, bool) {
+	change := newPriceChange(cat, now)
+
+	var reasons []string
+	rule, matchReasons, matched := rules.OfMode(pricing.ModeAuto).Evaluate(change.Facts)
+	if matched {
+		price, applicable := rule.Apply(change.BasePrice)
+		if applicable {
+			change.Price = price
+			change.Rule = rule.Name
+			change.Automatic = true
+			change.Reasons = append(matchReasons, rule.Describe())
+			return change, price != cat.Price
+		}
+		reasons = []string{fmt.Sprintf("%s, which is not in %s", rule.Describe(), change.BasePrice.Currency)}
+	} else {
+		reasons = []string{"no automatic rule matches"}
+	}
+
+	if change.BasePrice == cat.Price {
+		return change, false
+	}
+	// current price is automatic, but no automatic rule backs it anymore
+	change.Price = change.BasePrice
+	change.Automatic = true
+	change.Reasons = append(reasons, "base price is restored")
+	return change, true
+}
+
+// suggest returns prices suggest rules matching a cat propose, in order of their priority
+func suggest(rules pricing.Rules, cat entities.Cat, now time.Time) []PriceChange {
+	var suggestions []PriceChange
+	for _, rule := range rules.OfMode(pricing.ModeSuggest) {
+		change := newPriceChange(cat, now)
+		reasons, matched := rule.Match(change.Facts)
+		price, applicable := rule.Apply(change.BasePrice)
+		if !matched || !applicable || price == cat.Price {
+			continue
+		}
+		change.Price = price
+		change.Rule = rule.Name
+		change.Reasons = append(reasons, rule.Describe())
+		suggestions = append(suggestions, change)
+	}
+	return suggestions
+}
+
+func newPriceChange(cat entities.Cat, now time.Time) PriceChange {
+	return PriceChange{
+		Cat: cat,
+		Facts: pricing.Facts{
+			Age:        cat.AgeAt(now),
+			DaysListed: cat.DaysListedAt(now),
+			Views:      cat.Views,
+			Tags:       cat.Tags,
+		},
+		BasePrice: cat.BasePrice(),
+	}
+}
+
+type repricingCats struct {
+	Cats
+	pricing Pricing
+}
+
+// NewRepricingCats decorates cats service, so automatic rules apply to new cats and to prices changed by hand,
+// a price set by hand becomes a base price of rules. Pricing service must use an undecorated cats service.
+func NewRepricingCats(catsService Cats, pricingService Pricing) Cats {
+	return &repricingCats{
+		Cats:    catsService,
+		pricing: pricingService,
+	}
+}
+
+func (r *repricingCats) CreateNew(ctx context.Context, cat entities.Cat) (uuid.UUID, error) {
+	id, err := r.Cats.CreateNew(ctx, cat)
+	if err != nil {
+		return id, err
+	}
+	r.reprice(ctx, id)
+	return id, nil
+}
+
+func (r *repricingCats) UpdatePrice(ctx context.Context, id uuid.UUID, price money.Money) error {
+	if err := r.Cats.UpdatePrice(ctx, id, price); err != nil {
+		return err
+	}
+	r.reprice(ctx, id)
+	return nil
+}
+
+// reprice applies automatic rules after a change has succeeded, so its failure is only logged,
+// the next run of rules prices the cat anyway
+func (r *repricingCats) reprice(ctx context.Context, id uuid.UUID) {
+	if err := r.pricing.Reprice(ctx, id); err != nil {
+		logging.Warnf("cannot reprice cat %s: %v\n", id, err)
+	}
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/internal/pricing"
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/repository/entities"
+)
+
+var (
+	minDaysListed = 30
+	pricingRules  = pricing.Rules{
+		{Name: "stale", Mode: pricing.ModeAuto, When: pricing.Conditions{MinDaysListed: &minDaysListed}, AdjustPercent: -20},
+		{Name: "calm", Mode: pricing.ModeSuggest, When: pricing.Conditions{Tags: []string{"calm"}}, AdjustPercent: 10},
+	}
+)
+
+func TestPreviewPricing(t *testing.T) {
+	// Arrange
+	usd := func(amount int64) money.Money { return money.Money{Amount: amount, Currency: "USD"} }
+	stale := entities.Cat{ID: uuid.New(), Price: usd(1000), ListedAt: time.Now().Add(-40 * 24 * time.Hour)}
+	calm := entities.Cat{ID: uuid.New(), Price: usd(1000), ListedAt: time.Now(), Tags: []string{"calm"}}
+	fresh := entities.Cat{ID: uuid.New(), Price: usd(1000), ListedAt: time.Now()}
+	promoted := entities.Cat{ID: uuid.New(), Price: usd(500), ListedAt: time.Now().Add(-40 * 24 * time.Hour)}
+	sold := entities.Cat{ID: uuid.New(), Price: usd(1000), ListedAt: time.Now().Add(-40 * 24 * time.Hour), Status: entities.StatusSold}
+	filter := repository.Filter{Color: "black"}
+
+	cats := new(MockCats)
+	cats.On("GetAll", mock.Anything, filter, repository.Page{}).Return([]entities.Cat{stale, calm, fresh, promoted, sold}, nil)
+	schedules := new(repository.MockPriceSchedules)
+	schedules.On("Running", mock.Anything).Return([]entities.PriceSchedule{
+		{Applied: []entities.AppliedPrice{{CatID: promoted.ID, OriginalPrice: usd(1000), Price: usd(500)}}},
+	}, nil)
+	s := NewPricingService(new(repository.MockCats), schedules, cats, pricingRules)
+
+	// Act
+	changes, err := s.Preview(context.Background(), filter)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.Equal(t, stale.ID, changes[0].Cat.ID)
+	require.Equal(t, usd(800), changes[0].Price)
+	require.True(t, changes[0].Automatic)
+	require.Equal(t, []string{"listed for 40 days, at least 30", "rule stale adjusts base price by -20%"}, changes[0].Reasons)
+	require.Equal(t, calm.ID, changes[1].Cat.ID)
+	require.Equal(t, usd(1100), changes[1].Price)
+	require.False(t, changes[1].Automatic)
+	cats.AssertNotCalled(t, "UpdatePriceIfVersion", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPreviewPricingKeepsAutoPriceUnderSuggestRule(t *testing.T) {
+	// Arrange
+	base := money.Money{Amount: 1000, Currency: "USD"}
+	discounted := money.Money{Amount: 800, Currency: "USD"}
+	cat := entities.Cat{
+		ID:        uuid.New(),
+		Price:     discounted,
+		ListedAt:  time.Now().Add(-40 * 24 * time.Hour),
+		Tags:      []string{"calm"},
+		AutoPrice: &entities.AutoPrice{Rule: "stale", BasePrice: base, Price: discounted},
+	}
+	// suggest rule goes first, so it would win over automatic rule of lower priority
+	rules := pricing.Rules{pricingRules[1], pricingRules[0]}
+
+	cats := new(MockCats)
+	cats.On("GetAll", mock.Anything, repository.Filter{}, repository.Page{}).Return([]entities.Cat{cat}, nil)
+	schedules := new(repository.MockPriceSchedules)
+	schedules.On("Running", mock.Anything).Return(nil, nil)
+	s := NewPricingService(new(repository.MockCats), schedules, cats, rules)
+
+	// Act
+	changes, err := s.Preview(context.Background(), repository.Filter{})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, "calm", changes[0].Rule)
+	require.Equal(t, money.Money{Amount: 1100, Currency: "USD"}, changes[0].Price)
+	require.False(t, changes[0].Automatic)
+}
+
+func TestRunAllPricingRecordsAutoPriceBeforeSettingIt(t *testing.T) {
+	// Arrange
+	cat := entities.Cat{ID: uuid.New(), Price: money.Money{Amount: 1000, Currency: "USD"}, PriceVersion: 3, ListedAt: time.Now().Add(-31 * 24 * time.Hour)}
+	discounted := money.Money{Amount: 800, Currency: "USD"}
+	var calls []string
+
+	catsRepo := new(repository.MockCats)
+	catsRepo.On("Tenants", mock.Anything).Return([]string{"shelter-1"}, nil)
+	catsRepo.On("SetAutoPrice", mock.Anything, cat.ID, &entities.AutoPrice{Rule: "stale", BasePrice: cat.Price, Price: discounted}).
+		Run(func(mock.Arguments) { calls = append(calls, "SetAutoPrice") }).Return(nil)
+	cats := new(MockCats)
+	cats.On("GetAll", mock.Anything, repository.Filter{}, repository.Page{Limit: pricingBatchSize}).Return([]entities.Cat{cat}, nil)
+	cats.On("UpdatePriceIfVersion", mock.Anything, cat.ID, discounted, uint64(3)).
+		Run(func(mock.Arguments) { calls = append(calls, "UpdatePrice") }).Return(nil)
+	schedules := new(repository.MockPriceSchedules)
+	schedules.On("Running", mock.Anything).Return(nil, nil)
+	s := NewPricingService(catsRepo, schedules, cats, pricingRules)
+
+	// Act
+	err := s.RunAll(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, []string{"SetAutoPrice", "UpdatePrice"}, calls)
+}
+
+func TestRunAllPricingLoadsCatsInPages(t *testing.T) {
+	// Arrange
+	sold := make([]entities.Cat, pricingBatchSize)
+	for i := range sold {
+		sold[i] = entities.Cat{ID: uuid.New(), Status: entities.StatusSold}
+	}
+	cat := entities.Cat{ID: uuid.New(), Price: money.Money{Amount: 1000, Currency: "USD"}, ListedAt: time.Now().Add(-31 * 24 * time.Hour)}
+	discounted := money.Money{Amount: 800, Currency: "USD"}
+
+	catsRepo := new(repository.MockCats)
+	catsRepo.On("Tenants", mock.Anything).Return([]string{"shelter-1"}, nil)
+	catsRepo.On("SetAutoPrice", mock.Anything, cat.ID, mock.Anything).Return(nil)
+	cats := new(MockCats)
+	cats.On("GetAll", mock.Anything, repository.Filter{}, repository.Page{Limit: pricingBatchSize}).Return(sold, nil)
+	cats.On("GetAll", mock.Anything, repository.Filter{}, repository.Page{Limit: pricingBatchSize, Offset: pricingBatchSize}).
+		Return([]entities.Cat{cat}, nil)
+	cats.On("UpdatePriceIfVersion", mock.Anything, cat.ID, discounted, cat.PriceVersion).Return(nil)
+	schedules := new(repository.MockPriceSchedules)
+	schedules.On("Running", mock.Anything).Return(nil, nil)
+	s := NewPricingService(catsRepo, schedules, cats, pricingRules)
+
+	// Act
+	err := s.RunAll(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	cats.AssertExpectations(t)
+}
+
+func TestRunAllPricingDoesNotCompound(t *testing.T) {
+	// Arrange
+	base := money.Money{Amount: 1000, Currency: "USD"}
+	discounted := money.Money{Amount: 800, Currency: "USD"}
+	cat := entities.Cat{
+		ID:        uuid.New(),
+		Price:     discounted,
+		ListedAt:  time.Now().Add(-60 * 24 * time.Hour),
+		AutoPrice: &entities.AutoPrice{Rule: "stale", BasePrice: base, Price: discounted},
+	}
+
+	catsRepo := new(repository.MockCats)
+	catsRepo.On("Tenants", mock.Anything).Return([]string{"shelter-1"}, nil)
+	cats := new(MockCats)
+	cats.On("GetAll", mock.Anything, repository.Filter{}, repository.Page{Limit: pricingBatchSize}).Return([]entities.Cat{cat}, nil)
+	schedules := new(repository.MockPriceSchedules)
+	schedules.On("Running", mock.Anything).Return(nil, nil)
+	s := NewPricingService(catsRepo, schedules, cats, pricingRules)
+
+	// Act
+	err := s.RunAll(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	cats.AssertNotCalled(t, "UpdatePriceIfVersion", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	catsRepo.AssertNotCalled(t, "SetAutoPrice", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRepriceRestoresBasePriceWhenNoRuleMatches(t *testing.T) {
+	// Arrange
+	base := money.Money{Amount: 1000, Currency: "USD"}
+	discounted := money.Money{Amount: 800, Currency: "USD"}
+	cat := entities.Cat{
+		ID:        uuid.New(),
+		Price:     discounted,
+		ListedAt:  time.Now(),
+		AutoPrice: &entities.AutoPrice{Rule: "stale", BasePrice: base, Price: discounted},
+	}
+
+	catsRepo := new(repository.MockCats)
+	catsRepo.On("SetAutoPrice", mock.Anything, cat.ID, (*entities.AutoPrice)(nil)).Return(nil)
+	cats := new(MockCats)
+	cats.On("GetOne", mock.Anything, cat.ID).Return(cat, nil)
+	cats.On("UpdatePriceIfVersion", mock.Anything, cat.ID, base, cat.PriceVersion).Return(nil)
+	schedules := new(repository.MockPriceSchedules)
+	schedules.On("Running", mock.Anything).Return(nil, nil)
+	s := NewPricingService(catsRepo, schedules, cats, pricingRules)
+
+	// Act
+	err := s.Reprice(context.Background(), cat.ID)
+
+	// Assert
+	require.NoError(t, err)
+	cats.AssertExpectations(t)
+	catsRepo.AssertExpectations(t)
+}
+
+func TestRepriceSkipsCatWithChangedPrice(t *testing.T) {
+	// Arrange
+	cat := entities.Cat{ID: uuid.New(), Price: money.Money{Amount: 1000, Currency: "USD"}, PriceVersion: 2, ListedAt: time.Now().Add(-31 * 24 * time.Hour)}
+	discounted := money.Money{Amount: 800, Currency: "USD"}
+
+	catsRepo := new(repository.MockCats)
+	catsRepo.On("SetAutoPrice", mock.Anything, cat.ID, mock.Anything).Return(nil)
+	cats := new(MockCats)
+	cats.On("GetOne", mock.Anything, cat.ID).Return(cat, nil)
+	cats.On("UpdatePriceIfVersion", mock.Anything, cat.ID, discounted, uint64(2)).Return(ErrPriceChanged)
+	schedules := new(repository.MockPriceSchedules)
+	schedules.On("Running", mock.Anything).Return(nil, nil)
+	s := NewPricingService(catsRepo, schedules, cats, pricingRules)
+
+	// Act
+	err := s.Reprice(context.Background(), cat.ID)
+
+	// Assert
+	require.NoError(t, err)
+	cats.AssertExpectations(t)
+}
+
+func TestRepricingCatsRepricesPriceSetByHand(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	price := money.Money{Amount: 1200, Currency: "USD"}
+	cats := new(MockCats)
+	cats.On("UpdatePrice", mock.Anything, id, price).Return(nil)
+	p := new(MockPricing)
+	p.On("Reprice", mock.Anything, id).Return(nil)
+	s := NewRepricingCats(cats, p)
+
+	// Act
+	err := s.UpdatePrice(context.Background(), id, price)
+
+	// Assert
+	require.NoError(t, err)
+	p.AssertExpectations(t)
+}
+
+func TestRepricingCatsKeepsPriceSetByHandWhenRepricingFails(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	price := money.Money{Amount: 1200, Currency: "USD"}
+	cats := new(MockCats)
+	cats.On("UpdatePrice", mock.Anything, id, price).Return(nil)
+	p := new(MockPricing)
+	p.On("Reprice", mock.Anything, id).Return(errors.New("mongo is down"))
+	s := NewRepricingCats(cats, p)
+
+	// Act
+	err := s.UpdatePrice(context.Background(), id, price)
+
+	// Assert
+	require.NoError(t, err)
+	p.AssertExpectations(t)
+}
@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+// Schedules contains usecase logic for scheduled price changes and promotions.
+// Prices are changed through Cats.UpdatePrice, so price events are produced as for manual changes.
+type Schedules interface {
+	GetAll(ctx context.Context, page repository.Page) ([]entities.PriceSchedule, error)
+	GetOne(ctx context.Context, id uuid.UUID) (entities.PriceSchedule, error)
+	Create(ctx context.Context, schedule entities.PriceSchedule) (uuid.UUID, error)
+	// Update replaces a pending schedule, only the end of an active promotion can be changed
+	Update(ctx context.Context, schedule entities.PriceSchedule) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// RunDue applies started schedules and ends finished promotions of every tenant.
+	// It must be run by a single instance at a time, e.g. by a leader: fence is checked before every schedule
+	// and every price change, its error stops the run, e.g. when leadership has been lost.
+	RunDue(ctx context.Context, fence func(ctx context.Context) error) error
+}
+
+type schedules struct {
+	repository repository.PriceSchedules
+	cats       Cats
+}
+
+// NewSchedulesService creates new schedules service
+func NewSchedulesService(schedulesRepository repository.PriceSchedules, catsService Cats) Schedules {
+	return &schedules{
+		repository: schedulesRepository,
+		cats:       catsService,
+	}
+}
+
+func (s *schedules) GetAll(ctx context.Context, page repository.Page) ([]entities.PriceSchedule, error) {
+	if err := validatePage(page); err != nil {
+		return nil, err
+	}
+	return s.repository.GetAll(ctx, page)
+}
+
+func (s *schedules) GetOne(ctx context.Context, id uuid.UUID) (entities.PriceSchedule, error) {
+	schedule, err := s.repository.GetOne(ctx, id)
+	return schedule, translate(err, ErrScheduleNotFound, nil)
+}
+
+func (s *schedules) Create(ctx context.Context, schedule entities.PriceSchedule) (uuid.UUID, error) {
+	if err := validateSchedule(schedule, time.Now()); err != nil {
+		return uuid.UUID{}, err
+	}
+	if err := s.validateCurrency(ctx, schedule); err != nil {
+		return uuid.UUID{}, err
+	}
+	return s.repository.Insert(ctx, schedule)
+}
+
+func (s *schedules) Update(ctx context.Context, schedule entities.PriceSchedule) error {
+	existing, err := s.GetOne(ctx, schedule.ID)
+	if err != nil {
+		return err
+	}
+
+	switch existing.State {
+	case entities.SchedulePending:
+		if err := validateSchedule(schedule, time.Now()); err != nil {
+			return err
+		}
+		if err := s.validateCurrency(ctx, schedule); err != nil {
+			return err
+		}
+		schedule.TenantID = existing.TenantID
+		schedule.State = existing.State
+		schedule.Applied = nil
+		schedule.CreatedAt = existing.CreatedAt
+	case entities.ScheduleActive:
+		// prices are already changed, so a promotion can only be ended earlier or later, e.g. right now
+		if schedule.EndsAt == nil {
+			return NewValidationError("endsAt", "must be set for an active promotion")
+		}
+		endsAt := *schedule.EndsAt
+		schedule = existing
+		schedule.EndsAt = &endsAt
+	default:
+		return ErrScheduleNotEditable
+	}
+
+	err = s.repository.Update(ctx, schedule, existing.State)
+	return translate(err, ErrScheduleNotFound, ErrScheduleNotEditable)
+}
+
+func (s *schedules) Delete(ctx context.Context, id uuid.UUID) error {
+	err := s.repository.Delete(ctx, id)
+	return translate(err, ErrScheduleNotFound, ErrScheduleNotEditable)
+}
+
+func (s *schedules) RunDue(ctx context.Context, fence func(ctx context.Context) error) error {
+	tenants, err := s.repository.Tenants(ctx)
+	if err != nil {
+		return err
+	}
+	for _, tenantID := range tenants {
+		if err := s.runDue(tenant.WithID(ctx, tenantID), fence); err != nil {
+			return fmt.Errorf("tenant %s: %w", tenantID, err)
+		}
+	}
+	return nil
+}
+
+// runDue runs due schedules of tenant carried by ctx, a failed schedule is retried on the next run
+// and does not hold back the others
+func (s *schedules) runDue(ctx context.Context, fence func(ctx context.Context) error) error {
+	now := time.Now().UTC()
+	due, err := s.repository.Due(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, schedule := range due {
+		if err := fence(ctx); err != nil {
+			return err
+		}
+		err := s.run(ctx, schedule, now, fence)
+		if err == nil || errors.Is(err, repository.ErrConflict) {
+			// conflict means the schedule has been changed by a user or another run in the meantime
+			continue
+		}
+		if err := fence(ctx); err != nil {
+			// the run has been stopped by fence, which is not a failure of the schedule
+			return err
+		}
+		if err := s.repository.SetLastError(ctx, schedule.ID, err.Error()); err != nil {
+			return err
+		}
+		if firstErr == nil {
+			firstErr = fmt.Errorf("schedule %s: %w", schedule.ID, err)
+		}
+	}
+	return firstErr
+}
+
+// run moves a schedule through its states, each transition is conditional, so a state is entered once
+func (s *schedules) run(ctx context.Context, schedule entities.PriceSchedule, now time.Time, fence func(ctx context.Context) error) error {
+	switch schedule.State {
+	case entities.SchedulePending:
+		if schedule.IsPromotion() && !schedule.EndsAt.After(now) {
+			// a promotion that has ended before it could start, e.g. while scheduler was down, changes nothing
+			return s.repository.Transition(ctx, schedule.ID, entities.SchedulePending, entities.ScheduleCompleted)
+		}
+		if err := s.repository.Transition(ctx, schedule.ID, entities.SchedulePending, entities.ScheduleApplying); err != nil {
+			return err
+		}
+		schedule.State = entities.ScheduleApplying
+		return s.run(ctx, schedule, now, fence)
+	case entities.ScheduleApplying:
+		if err := s.apply(ctx, schedule, fence); err != nil {
+			return err
+		}
+		next := entities.ScheduleCompleted
+		if schedule.IsPromotion() {
+			next = entities.ScheduleActive
+		}
+		return s.repository.Transition(ctx, schedule.ID, entities.ScheduleApplying, next)
+	case entities.ScheduleActive:
+		if err := s.repository.Transition(ctx, schedule.ID, entities.ScheduleActive, entities.ScheduleReverting); err != nil {
+			return err
+		}
+		schedule.State = entities.ScheduleReverting
+		return s.run(ctx, schedule, now, fence)
+	case entities.ScheduleReverting:
+		if err := s.revert(ctx, schedule, fence); err != nil {
+			return err
+		}
+		return s.repository.Transition(ctx, schedule.ID, entities.ScheduleReverting, entities.ScheduleCompleted)
+	}
+	return nil
+}
+
+// apply sets new prices to target cats. A price is recorded before it is set,
+// so an interrupted run is resumed without discounting a cat twice.
+func (s *schedules) apply(ctx context.Context, schedule entities.PriceSchedule, fence func(ctx context.Context) error) error {
+	targets, err := s.targets(ctx, schedule)
+	if err != nil {
+		return err
+	}
+	applied := make(map[uuid.UUID]entities.AppliedPrice, len(schedule.Applied))
+	for _, a := range schedule.Applied {
+		applied[a.CatID] = a
+	}
+
+	for _, cat := range targets {
+		a, ok := applied[cat.ID]
+		if !ok && schedule.Price != nil && schedule.Price.Currency != cat.Price.Currency {
+			// a cat priced in another currency after the schedule was created keeps its price
+			logging.Warnf("schedule %s skips cat %s priced in %s\n", schedule.ID, cat.ID, cat.Price.Currency)
+			continue
+		}
+		if !ok {
+			a = entities.AppliedPrice{CatID: cat.ID, OriginalPrice: cat.Price, Price: scheduledPrice(schedule, cat.Price)}
+			if err := s.repository.RecordApplied(ctx, schedule.ID, a); err != nil {
+				return err
+			}
+		}
+		if cat.Price == a.Price {
+			continue
+		}
+		if err := fence(ctx); err != nil {
+			return err
+		}
+		if err := s.cats.UpdatePrice(ctx, cat.ID, a.Price); err != nil && !errors.Is(err, ErrCatNotFound) {
+			return fmt.Errorf("cat %s: %w", cat.ID, err)
+		}
+	}
+	return nil
+}
+
+// revert restores prices changed by a promotion, a price changed by someone else during the promotion is kept
+func (s *schedules) revert(ctx context.Context, schedule entities.PriceSchedule, fence func(ctx context.Context) error) error {
+	for _, a := range schedule.Applied {
+		if a.Reverted {
+			continue
+		}
+		cat, err := s.cats.GetOne(ctx, a.CatID)
+		if err != nil && !errors.Is(err, ErrCatNotFound) {
+			return fmt.Errorf("cat %s: %w", a.CatID, err)
+		}
+		if err == nil && cat.Price == a.Price {
+			if err := fence(ctx); err != nil {
+				return err
+			}
+			if err := s.cats.UpdatePrice(ctx, a.CatID, a.OriginalPrice); err != nil && !errors.Is(err, ErrCatNotFound) {
+				return fmt.Errorf("cat %s: %w", a.CatID, err)
+			}
+		}
+		if err := s.repository.MarkReverted(ctx, schedule.ID, a.CatID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// targets returns cats a schedule applies to, sold cats are skipped as their price does not matter anymore
+func (s *schedules) targets(ctx context.Context, schedule entities.PriceSchedule) ([]entities.Cat, error) {
+	var cats []entities.Cat
+	if schedule.CatID != nil {
+		cat, err := s.cats.GetOne(ctx, *schedule.CatID)
+		if errors.Is(err, ErrCatNotFound) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		cats = []entities.Cat{cat}
+	} else {
+		var err error
+		cats, err = s.cats.GetAll(ctx, scheduleFilter(*schedule.Filter), repository.Page{})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	targets := make([]entities.Cat, 0, len(cats))
+	for _, cat := range cats {
+		if cat.Status != entities.StatusSold {
+			targets = append(targets, cat)
+		}
+	}
+	return targets, nil
+}
+
+// scheduledPrice is a fixed price of a schedule, or current price with discount rounded half up to a minor unit
+func scheduledPrice(schedule entities.PriceSchedule, current money.Money) money.Money {
+	if schedule.Price != nil {
+		return *schedule.Price
+	}
+	discounted := (current.Amount*int64(100-schedule.DiscountPercent) + 50) / 100
+	return money.Money{Amount: discounted, Currency: current.Currency}
+}
+
+func scheduleFilter(filter entities.ScheduleFilter) repository.Filter {
+	return repository.Filter{
+		Color:  filter.Color,
+		Breed:  filter.Breed,
+		Tags:   filter.Tags,
+		MinAge: filter.MinAge,
+		MaxAge: filter.MaxAge,
+	}
+}
+
+// validateSchedule checks that a schedule has a single target and a single way to get new price
+func validateSchedule(schedule entities.PriceSchedule, now time.Time) error {
+	switch {
+	case (schedule.CatID == nil) == (schedule.Filter == nil):
+		return NewValidationError("catId", "exactly one of catId and filter must be set")
+	case schedule.Filter != nil && isEmptyFilter(*schedule.Filter):
+		return NewValidationError("filter", "must have at least one condition")
+	case (schedule.Price == nil) == (schedule.DiscountPercent == 0):
+		return NewValidationError("price", "exactly one of price and discountPercent must be set")
+	case schedule.Price == nil && (schedule.DiscountPercent < 1 || schedule.DiscountPercent > 99):
+		return NewValidationError("discountPercent", "must be between 1 and 99")
+	case schedule.StartsAt.IsZero():
+		return NewValidationError("startsAt", "must be set")
+	case schedule.EndsAt != nil && !schedule.EndsAt.After(schedule.StartsAt):
+		return NewValidationError("endsAt", "must be after startsAt")
+	case schedule.EndsAt != nil && !schedule.EndsAt.After(now):
+		return NewValidationError("endsAt", "must be in the future")
+	}
+	if schedule.Price != nil {
+		return validatePrice(*schedule.Price)
+	}
+	return nil
+}
+
+// validateCurrency checks that a fixed price is in currency of cats it is set to, as prices are not converted
+func (s *schedules) validateCurrency(ctx context.Context, schedule entities.PriceSchedule) error {
+	if schedule.Price == nil {
+		return nil
+	}
+	targets, err := s.targets(ctx, schedule)
+	if err != nil {
+		return err
+	}
+	for _, cat := range targets {
+		if cat.Price.Currency != schedule.Price.Currency {
+			return NewValidationError("price.currency", "must be "+cat.Price.Currency+" as price of cat "+cat.ID.String())
+		}
+	}
+	return nil
+}
+
+func isEmptyFilter(filter entities.ScheduleFilter) bool {
+	return filter.Color == "" && filter.Breed == "" && len(filter.Tags) == 0 && filter.MinAge == nil && filter.MaxAge == nil
+}
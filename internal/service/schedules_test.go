@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/repository/entities"
+)
+
+func TestCreateScheduleValidation(t *testing.T) {
+	catID := uuid.New()
+	startsAt := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+	price := money.Money{Amount: 500, Currency: "USD"}
+	for name, schedule := range map[string]entities.PriceSchedule{
+		"catId":           {Price: &price, StartsAt: startsAt},
+		"filter":          {Filter: &entities.ScheduleFilter{}, Price: &price, StartsAt: startsAt},
+		"price":           {CatID: &catID, StartsAt: startsAt},
+		"discountPercent": {CatID: &catID, DiscountPercent: 100, StartsAt: startsAt},
+		"startsAt":        {CatID: &catID, Price: &price},
+		"endsAt":          {CatID: &catID, Price: &price, StartsAt: startsAt.Add(-2 * time.Hour), EndsAt: &past},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			s := NewSchedulesService(new(repository.MockPriceSchedules), new(MockCats))
+
+			// Act
+			_, err := s.Create(context.Background(), schedule)
+
+			// Assert
+			var validationErr *ValidationError
+			require.True(t, errors.As(err, &validationErr))
+			require.Equal(t, name, validationErr.Field)
+		})
+	}
+}
+
+func TestCreateScheduleInOtherCurrency(t *testing.T) {
+	// Arrange
+	cat := entities.Cat{ID: uuid.New(), Price: money.Money{Amount: 900, Currency: "EUR"}, Status: entities.StatusAvailable}
+	price := money.Money{Amount: 500, Currency: "USD"}
+	cats := new(MockCats)
+	cats.On("GetOne", mock.Anything, cat.ID).Return(cat, nil)
+	repo := new(repository.MockPriceSchedules)
+	s := NewSchedulesService(repo, cats)
+
+	// Act
+	_, err := s.Create(context.Background(), entities.PriceSchedule{CatID: &cat.ID, Price: &price, StartsAt: time.Now().Add(time.Hour)})
+
+	// Assert
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	require.Equal(t, "price.currency", validationErr.Field)
+	repo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+}
+
+func TestUpdateCompletedSchedule(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	repo := new(repository.MockPriceSchedules)
+	repo.On("GetOne", mock.Anything, id).Return(entities.PriceSchedule{ID: id, State: entities.ScheduleCompleted}, nil)
+	s := NewSchedulesService(repo, new(MockCats))
+
+	// Act
+	err := s.Update(context.Background(), entities.PriceSchedule{ID: id})
+
+	// Assert
+	require.ErrorIs(t, err, ErrScheduleNotEditable)
+}
+
+func TestRunDueAppliesDiscount(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	endsAt := time.Now().Add(time.Hour)
+	schedule := entities.PriceSchedule{
+		ID:              id,
+		Filter:          &entities.ScheduleFilter{Breed: "Siamese"},
+		DiscountPercent: 25,
+		StartsAt:        time.Now().Add(-time.Minute),
+		EndsAt:          &endsAt,
+		State:           entities.SchedulePending,
+	}
+	available := entities.Cat{ID: uuid.New(), Price: money.Money{Amount: 999, Currency: "USD"}, Status: entities.StatusAvailable}
+	sold := entities.Cat{ID: uuid.New(), Price: money.Money{Amount: 999, Currency: "USD"}, Status: entities.StatusSold}
+	discounted := money.Money{Amount: 749, Currency: "USD"}
+
+	repo := new(repository.MockPriceSchedules)
+	repo.On("Tenants", mock.Anything).Return([]string{"shelter-1"}, nil)
+	repo.On("Due", mock.Anything, mock.Anything).Return([]entities.PriceSchedule{schedule}, nil)
+	repo.On("Transition", mock.Anything, id, entities.SchedulePending, entities.ScheduleApplying).Return(nil)
+	repo.On("RecordApplied", mock.Anything, id, entities.AppliedPrice{CatID: available.ID, OriginalPrice: available.Price, Price: discounted}).Return(nil)
+	repo.On("Transition", mock.Anything, id, entities.ScheduleApplying, entities.ScheduleActive).Return(nil)
+	cats := new(MockCats)
+	cats.On("GetAll", mock.Anything, repository.Filter{Breed: "Siamese"}, repository.Page{}).Return([]entities.Cat{available, sold}, nil)
+	cats.On("UpdatePrice", mock.Anything, available.ID, discounted).Return(nil)
+	s := NewSchedulesService(repo, cats)
+
+	// Act
+	err := s.RunDue(context.Background(), unfenced)
+
+	// Assert
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+	cats.AssertExpectations(t)
+}
+
+func TestRunDueKeepsPriceChangedDuringPromotion(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	endsAt := time.Now().Add(-time.Minute)
+	changed := entities.AppliedPrice{CatID: uuid.New(), OriginalPrice: money.Money{Amount: 999, Currency: "USD"}, Price: money.Money{Amount: 749, Currency: "USD"}}
+	untouched := entities.AppliedPrice{CatID: uuid.New(), OriginalPrice: money.Money{Amount: 500, Currency: "USD"}, Price: money.Money{Amount: 375, Currency: "USD"}}
+	schedule := entities.PriceSchedule{ID: id, EndsAt: &endsAt, State: entities.ScheduleActive, Applied: []entities.AppliedPrice{changed, untouched}}
+
+	repo := new(repository.MockPriceSchedules)
+	repo.On("Tenants", mock.Anything).Return([]string{"shelter-1"}, nil)
+	repo.On("Due", mock.Anything, mock.Anything).Return([]entities.PriceSchedule{schedule}, nil)
+	repo.On("Transition", mock.Anything, id, entities.ScheduleActive, entities.ScheduleReverting).Return(nil)
+	repo.On("MarkReverted", mock.Anything, id, mock.Anything).Return(nil)
+	repo.On("Transition", mock.Anything, id, entities.ScheduleReverting, entities.ScheduleCompleted).Return(nil)
+	cats := new(MockCats)
+	cats.On("GetOne", mock.Anything, changed.CatID).Return(entities.Cat{ID: changed.CatID, Price: money.Money{Amount: 600, Currency: "USD"}}, nil)
+	cats.On("GetOne", mock.Anything, untouched.CatID).Return(entities.Cat{ID: untouched.CatID, Price: untouched.Price}, nil)
+	cats.On("UpdatePrice", mock.Anything, untouched.CatID, untouched.OriginalPrice).Return(nil)
+	s := NewSchedulesService(repo, cats)
+
+	// Act
+	err := s.RunDue(context.Background(), unfenced)
+
+	// Assert
+	require.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "MarkReverted", 2)
+	cats.AssertNumberOfCalls(t, "UpdatePrice", 1)
+}
+
+func TestRunDueCompletesEndedPromotion(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	endsAt := time.Now().Add(-time.Minute)
+	schedule := entities.PriceSchedule{ID: id, DiscountPercent: 10, EndsAt: &endsAt, State: entities.SchedulePending}
+	repo := new(repository.MockPriceSchedules)
+	repo.On("Tenants", mock.Anything).Return([]string{"shelter-1"}, nil)
+	repo.On("Due", mock.Anything, mock.Anything).Return([]entities.PriceSchedule{schedule}, nil)
+	repo.On("Transition", mock.Anything, id, entities.SchedulePending, entities.ScheduleCompleted).Return(nil)
+	cats := new(MockCats)
+	s := NewSchedulesService(repo, cats)
+
+	// Act
+	err := s.RunDue(context.Background(), unfenced)
+
+	// Assert
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+	cats.AssertNotCalled(t, "UpdatePrice", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRunDueRecordsFailure(t *testing.T) {
+	// Arrange
+	id, catID := uuid.New(), uuid.New()
+	price := money.Money{Amount: 500, Currency: "USD"}
+	schedule := entities.PriceSchedule{ID: id, CatID: &catID, Price: &price, State: entities.ScheduleApplying}
+	repo := new(repository.MockPriceSchedules)
+	repo.On("Tenants", mock.Anything).Return([]string{"shelter-1"}, nil)
+	repo.On("Due", mock.Anything, mock.Anything).Return([]entities.PriceSchedule{schedule}, nil)
+	repo.On("SetLastError", mock.Anything, id, "mongo is down").Return(nil)
+	cats := new(MockCats)
+	cats.On("GetOne", mock.Anything, catID).Return(entities.Cat{}, errors.New("mongo is down"))
+	s := NewSchedulesService(repo, cats)
+
+	// Act
+	err := s.RunDue(context.Background(), unfenced)
+
+	// Assert
+	require.EqualError(t, err, "tenant shelter-1: schedule "+id.String()+": mongo is down")
+	repo.AssertExpectations(t)
+}
+
+func TestRunDueStopsWhenFenced(t *testing.T) {
+	// Arrange
+	id, catID := uuid.New(), uuid.New()
+	price := money.Money{Amount: 500, Currency: "USD"}
+	schedule := entities.PriceSchedule{ID: id, CatID: &catID, Price: &price, State: entities.ScheduleApplying}
+	repo := new(repository.MockPriceSchedules)
+	repo.On("Tenants", mock.Anything).Return([]string{"shelter-1"}, nil)
+	repo.On("Due", mock.Anything, mock.Anything).Return([]entities.PriceSchedule{schedule}, nil)
+	cats := new(MockCats)
+	s := NewSchedulesService(repo, cats)
+	errNotLeader := errors.New("not a leader")
+
+	// Act
+	err := s.RunDue(context.Background(), func(context.Context) error { return errNotLeader })
+
+	// Assert
+	require.ErrorIs(t, err, errNotLeader)
+	repo.AssertExpectations(t)
+	cats.AssertNotCalled(t, "GetOne", mock.Anything, mock.Anything)
+}
+
+func unfenced(context.Context) error {
+	return nil
+}
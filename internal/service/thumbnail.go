@@ -0,0 +1,59 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif" // registers GIF decoder
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+)
+
+// maxPhotoPixels limits dimensions of photos, as a small compressed file may decode into gigabytes of pixels
+const maxPhotoPixels = 50_000_000
+
+// makeThumbnail scales image down to fit into a square of given size.
+// JPEG sources produce JPEG thumbnails, others produce PNG to keep transparency.
+// Images having more than maxPhotoPixels pixels are rejected with ErrPhotoTooLarge before they are decoded.
+func makeThumbnail(data []byte, size int) (thumbnail []byte, contentType string, err error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(config.Width)*int64(config.Height) > maxPhotoPixels {
+		return nil, "", ErrPhotoTooLarge
+	}
+
+	source, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > size || height > size {
+		if width > height {
+			width, height = size, height*size/width
+		} else {
+			width, height = width*size/height, size
+		}
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	target := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(target, target.Bounds(), source, bounds, draw.Over, nil)
+
+	buffer := new(bytes.Buffer)
+	if format == "jpeg" {
+		err = jpeg.Encode(buffer, target, &jpeg.Options{Quality: 85})
+		return buffer.Bytes(), "image/jpeg", err
+	}
+	err = png.Encode(buffer, target)
+	return buffer.Bytes(), "image/png", err
+}
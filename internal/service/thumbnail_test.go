@@ -0,0 +1,39 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakeThumbnail(t *testing.T) {
+	// Arrange
+	buffer := new(bytes.Buffer)
+	require.NoError(t, png.Encode(buffer, image.NewRGBA(image.Rect(0, 0, 400, 200))))
+
+	// Act
+	thumbnail, contentType, err := makeThumbnail(buffer.Bytes(), 100)
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, "image/png", contentType)
+	config, err := png.DecodeConfig(bytes.NewReader(thumbnail))
+	require.NoError(t, err)
+	require.Equal(t, 100, config.Width)
+	require.Equal(t, 50, config.Height)
+}
+
+func TestMakeThumbnailRejectsTooManyPixels(t *testing.T) {
+	// Arrange
+	// GIF header of a 60000x60000 image without image data, it is rejected before the missing data is noticed
+	data := append([]byte("GIF89a"), 0x60, 0xea, 0x60, 0xea, 0x00, 0x00, 0x00)
+
+	// Act
+	_, _, err := makeThumbnail(data, 100)
+
+	// Assert
+	require.ErrorIs(t, err, ErrPhotoTooLarge)
+}
@@ -0,0 +1,98 @@
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	// Header is a request header and gRPC metadata key tenant is taken from when tokens are not used,
+	// it is not authenticated, so config allows no tenants but Default then
+	Header = "X-Tenant-ID"
+	// AuthorizationHeader is a request header and gRPC metadata key carrying bearer token
+	AuthorizationHeader = "Authorization"
+)
+
+// Resolver finds tenant of a request and checks it is served by this deployment
+type Resolver struct {
+	tenants  map[string]bool
+	secret   []byte
+	claim    string
+	required bool
+}
+
+// NewResolver creates a resolver of given tenants. If secret is set, tenant is taken only from claim
+// of an HS256 bearer token signed with it, otherwise from tenant header, which selects nothing but Default tenant
+// as config requires the secret for any other. Requests without tenant belong to Default tenant unless required is set.
+func NewResolver(tenants []string, secret, claim string, required bool) *Resolver {
+	known := make(map[string]bool, len(tenants))
+	for _, id := range tenants {
+		known[id] = true
+	}
+	return &Resolver{
+		tenants:  known,
+		secret:   []byte(secret),
+		claim:    claim,
+		required: required,
+	}
+}
+
+// Resolve returns tenant of a request with given values of Authorization and tenant headers
+func (r *Resolver) Resolve(authorization, header string) (string, error) {
+	id := header
+	if len(r.secret) > 0 {
+		var err error
+		if id, err = r.fromToken(authorization); err != nil {
+			return "", err
+		}
+	}
+
+	if id == "" {
+		if r.required || len(r.secret) > 0 {
+			return "", ErrMissing
+		}
+		id = Default
+	}
+	if err := Validate(id); err != nil {
+		return "", err
+	}
+	if !r.tenants[id] {
+		return "", fmt.Errorf("%w %q", ErrUnknown, id)
+	}
+	return id, nil
+}
+
+func (r *Resolver) fromToken(authorization string) (string, error) {
+	raw := strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+	if raw == "" || raw == authorization {
+		return "", ErrMissing
+	}
+
+	token, err := jwt.Parse(raw, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method %q", token.Header["alg"])
+		}
+		return r.secret, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", fmt.Errorf("%w: unexpected claims", ErrInvalid)
+	}
+	id, ok := claims[r.claim].(string)
+	if !ok {
+		return "", fmt.Errorf("%w: token has no %q claim", ErrInvalid, r.claim)
+	}
+	return id, nil
+}
+
+// IsUnauthenticated reports whether err means a request has no valid tenant credentials,
+// as opposed to a well-formed request of a tenant not served here
+func IsUnauthenticated(err error) bool {
+	return errors.Is(err, ErrMissing) || errors.Is(err, ErrInvalid)
+}
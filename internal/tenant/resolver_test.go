@@ -0,0 +1,79 @@
+package tenant
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "secret"
+
+func TestResolveHeader(t *testing.T) {
+	// Arrange
+	r := NewResolver([]string{Default, "shelter-1"}, "", "", false)
+
+	// Act
+	id, err := r.Resolve("", "shelter-1")
+	defaultID, defaultErr := r.Resolve("", "")
+	_, unknownErr := r.Resolve("", "shelter-2")
+	_, invalidErr := r.Resolve("", "Shelter")
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, "shelter-1", id)
+	require.NoError(t, defaultErr)
+	require.Equal(t, Default, defaultID)
+	require.ErrorIs(t, unknownErr, ErrUnknown)
+	require.False(t, IsUnauthenticated(unknownErr))
+	require.ErrorIs(t, invalidErr, ErrInvalid)
+}
+
+func TestResolveRequired(t *testing.T) {
+	// Arrange
+	r := NewResolver([]string{Default}, "", "", true)
+
+	// Act
+	_, err := r.Resolve("", "")
+
+	// Assert
+	require.ErrorIs(t, err, ErrMissing)
+	require.True(t, IsUnauthenticated(err))
+}
+
+func TestResolveToken(t *testing.T) {
+	// Arrange
+	r := NewResolver([]string{Default, "shelter-1"}, testSecret, "tenant", false)
+	token := signToken(t, testSecret, jwt.MapClaims{"tenant": "shelter-1"})
+
+	// Act
+	id, err := r.Resolve("Bearer "+token, "default")
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, "shelter-1", id)
+}
+
+func TestResolveTokenRejected(t *testing.T) {
+	r := NewResolver([]string{Default, "shelter-1"}, testSecret, "tenant", false)
+	for name, authorization := range map[string]string{
+		"missing":       "",
+		"not bearer":    signToken(t, testSecret, jwt.MapClaims{"tenant": "shelter-1"}),
+		"wrong secret":  "Bearer " + signToken(t, "other", jwt.MapClaims{"tenant": "shelter-1"}),
+		"expired":       "Bearer " + signToken(t, testSecret, jwt.MapClaims{"tenant": "shelter-1", "exp": time.Now().Add(-time.Minute).Unix()}),
+		"without claim": "Bearer " + signToken(t, testSecret, jwt.MapClaims{"sub": "user"}),
+	} {
+		// Act
+		_, err := r.Resolve(authorization, "shelter-1")
+
+		// Assert
+		require.True(t, IsUnauthenticated(err), name)
+	}
+}
+
+func signToken(t *testing.T, secret string, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return token
+}
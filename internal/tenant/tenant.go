@@ -0,0 +1,58 @@
+// Package tenant identifies the shelter a request or an event belongs to.
+// Tenant travels in context, repositories refuse to work without it, so data of one tenant is never read for another.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Default is a tenant of data created before multi-tenancy and of requests without tenant when it is not required.
+// Its topics keep names they had before, so single-tenant deployments keep their streams and queues.
+const Default = "default"
+
+var (
+	// ErrMissing means context carries no tenant
+	ErrMissing = errors.New("tenant is missing")
+	// ErrInvalid means tenant ID is malformed
+	ErrInvalid = errors.New("invalid tenant")
+	// ErrUnknown means tenant is not served by this deployment
+	ErrUnknown = errors.New("unknown tenant")
+)
+
+// idPattern keeps tenant IDs usable in names of redis streams, rabbitMQ exchanges and NATS subjects
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+type contextKey struct{}
+
+// Validate checks that id is a well-formed tenant ID
+func Validate(id string) error {
+	if !idPattern.MatchString(id) {
+		return fmt.Errorf("%w %q: must be lowercase letters, digits and dashes", ErrInvalid, id)
+	}
+	return nil
+}
+
+// WithID returns a context carrying tenant ID
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns tenant ID carried by ctx
+func FromContext(ctx context.Context) (string, error) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	if !ok || id == "" {
+		return "", ErrMissing
+	}
+	return id, nil
+}
+
+// Topic returns name of a stream, exchange, queue or subject of a tenant, e.g. price.shelter-1
+func Topic(base, id string) string {
+	if id == Default || id == "" {
+		return base
+	}
+	return base + "." + id
+}
@@ -0,0 +1,52 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	for id, valid := range map[string]bool{
+		"default":    true,
+		"shelter-1":  true,
+		"":           false,
+		"-shelter":   false,
+		"Shelter":    false,
+		"shelter.1":  false,
+		"shelter_1":  false,
+		"shelter 1":  false,
+		"a23456789-": true,
+	} {
+		// Act
+		err := Validate(id)
+
+		// Assert
+		if valid {
+			require.NoError(t, err, id)
+		} else {
+			require.ErrorIs(t, err, ErrInvalid, id)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	// Arrange
+	ctx := WithID(context.Background(), "shelter-1")
+
+	// Act
+	id, err := FromContext(ctx)
+	_, missingErr := FromContext(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	require.Equal(t, "shelter-1", id)
+	require.ErrorIs(t, missingErr, ErrMissing)
+}
+
+func TestTopic(t *testing.T) {
+	require.Equal(t, "price", Topic("price", Default))
+	require.Equal(t, "price", Topic("price", ""))
+	require.Equal(t, "price.shelter-1", Topic("price", "shelter-1"))
+}
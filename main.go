@@ -2,154 +2,279 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
-	"net"
-	"time"
+	"os"
 
-	"github.com/caarlos0/env/v6"
 	"github.com/go-redis/redis/v8"
-	"github.com/google/uuid"
-	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
+	"github.com/nats-io/nats.go"
 	"github.com/streadway/amqp"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/credentials"
 
+	"github.com/evleria/cats-app/internal/broker"
+	"github.com/evleria/cats-app/internal/certs"
 	"github.com/evleria/cats-app/internal/config"
 	"github.com/evleria/cats-app/internal/consumer"
-	grpcService "github.com/evleria/cats-app/internal/grpc"
-	"github.com/evleria/cats-app/internal/handler"
-	"github.com/evleria/cats-app/internal/producer"
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/leader"
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/internal/pricing"
+	"github.com/evleria/cats-app/internal/ratelimit"
+	"github.com/evleria/cats-app/internal/replay"
 	"github.com/evleria/cats-app/internal/repository"
-	"github.com/evleria/cats-app/internal/service"
-	"github.com/evleria/cats-app/protocol/pb"
 )
 
+// commands are run as `server [config flags] <command> [command flags]`, serve is run when command is omitted
+var commands = map[string]func(cfg *config.Сonfig, configLoader *config.Loader, args []string){
+	"serve":   runServe,
+	"migrate": runMigrate,
+	"seed":    runSeed,
+	"replay":  runReplay,
+	"cats":    runCats,
+}
+
 func main() {
-	cfg := new(config.Сonfig)
-	check(env.Parse(cfg))
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	configLoader := config.NewLoader(flags)
+	check(flags.Parse(os.Args[1:]))
+	cfg, err := configLoader.Load()
+	check(err)
+	logging.SetLevel(cfg.LogLevel)
 
-	mongoClient, mongoDB := getMongo(cfg)
-	defer mongoClient.Disconnect(context.Background()) //nolint:errcheck,gocritic
+	command, args := "serve", flags.Args()
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+	run, ok := commands[command]
+	if !ok {
+		flags.Usage()
+		log.Fatalf("unknown command %q", command)
+	}
+	run(cfg, configLoader, args)
+}
 
-	redisClient := getRedis(cfg)
-	defer redisClient.Close() //nolint:errcheck,gocritic
+func getBroker(cfg *config.Сonfig, redisClient *redis.Client) (broker.Kind, broker.Connections, func()) {
+	brokerKind, err := broker.ParseKind(cfg.Broker)
+	check(err)
 
-	rabbitClient := getRabbit(cfg)
-	defer rabbitClient.Close() //nolint:errcheck,gocritic
+	conns := broker.Connections{Redis: redisClient}
+	closeBroker := func() {}
+	switch {
+	case brokerKind.UsesRabbit():
+		rabbitClient := getRabbit(cfg)
+		conns.Rabbit, err = rabbitClient.Channel()
+		check(err)
+		closeBroker = func() {
+			conns.Rabbit.Close() //nolint:errcheck,gosec
+			rabbitClient.Close() //nolint:errcheck,gosec
+		}
+	case brokerKind == broker.NATS:
+		conns.NATS = getNATS(cfg)
+		closeBroker = conns.NATS.Close
+	case brokerKind == broker.InProcess:
+		conns.InProcess = broker.NewMemory()
+	}
+	return brokerKind, conns, closeBroker
+}
 
-	rabbitChannel, err := rabbitClient.Channel()
+func getInstanceID(cfg *config.Сonfig) string {
+	if cfg.InstanceID != "" {
+		return cfg.InstanceID
+	}
+	hostname, err := os.Hostname()
 	check(err)
-	defer rabbitChannel.Close() //nolint:errcheck,gocritic
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
 
-	go consumePrices(redisClient, rabbitChannel, cfg.ConsumerNumber)
+func getDedupStore(cfg *config.Сonfig, redisClient *redis.Client) consumer.DedupStore {
+	switch cfg.DedupStore {
+	case "memory":
+		return consumer.NewMemoryDedupStore(cfg.DedupTTL)
+	case "redis":
+		return consumer.NewRedisDedupStore(redisClient, fmt.Sprintf("price_%d", cfg.ConsumerNumber), cfg.DedupTTL)
+	default:
+		log.Fatalf("unknown dedup store %q", cfg.DedupStore)
+		return nil
+	}
+}
 
-	catsRepository := repository.NewCatsRepository(mongoDB)
-	priceProducer := producer.NewRedisPriceProducer(redisClient)
-	catsService := service.NewCatsService(catsRepository, priceProducer)
+func getRateLimiter(cfg *config.Сonfig, redisClient *redis.Client) ratelimit.Limiter {
+	switch store := cfg.RateLimitBackend(); store {
+	case "memory":
+		return ratelimit.NewMemoryLimiter()
+	case "redis":
+		return ratelimit.NewRedisLimiter(redisClient)
+	default:
+		log.Fatalf("unknown rate limit store %q", store)
+		return nil
+	}
+}
 
-	e := echo.New()
-	e.Use(middleware.Recover())
+func getIdempotencyKeys(cfg *config.Сonfig, mongoDB *mongo.Database, redisClient *redis.Client) repository.IdempotencyKeys {
+	switch store := cfg.IdempotencyBackend(); store {
+	case "mongo":
+		return repository.NewMongoIdempotencyKeysRepository(mongoDB, cfg.IdempotencyTTL)
+	case "redis":
+		return repository.NewIdempotencyKeysRepository(redisClient, cfg.IdempotencyTTL)
+	default:
+		log.Fatalf("unknown idempotency store %q", store)
+		return nil
+	}
+}
 
-	catsGroup := e.Group("/api/cats")
-	catsGroup.GET("", handler.GetAllCats(catsService))
-	catsGroup.GET("/:id", handler.GetCat(catsService))
-	catsGroup.POST("", handler.AddNewCat(catsService))
-	catsGroup.PUT("/:id/price", handler.UpdatePrice(catsService))
-	catsGroup.DELETE("/:id", handler.DeleteCat(catsService))
+func getElector(cfg *config.Сonfig, mongoDB *mongo.Database, redisClient *redis.Client, name string) leader.Elector {
+	switch store := cfg.LeaderBackend(); store {
+	case "mongo":
+		return leader.NewMongoElector(mongoDB, name, getInstanceID(cfg), cfg.LeaderTTL)
+	case "redis":
+		return leader.NewRedisElector(redisClient, name, getInstanceID(cfg), cfg.LeaderTTL)
+	default:
+		log.Fatalf("unknown leader store %q", store)
+		return nil
+	}
+}
 
-	go startGrpcServer(catsService, ":6000")
+// getReplaySource returns factory of sources replaying price events of a tenant
+func getReplaySource(cfg *config.Сonfig, mongoDB *mongo.Database, redisClient *redis.Client, codec *event.Codec) func(tenantID string) replay.Source {
+	switch source := cfg.ReplayBackend(); source {
+	case "mongo":
+		return func(tenantID string) replay.Source { return replay.NewMongoSource(mongoDB, tenantID) }
+	case "redis":
+		return func(tenantID string) replay.Source { return replay.NewRedisSource(redisClient, tenantID, codec) }
+	default:
+		log.Fatalf("unknown replay source %q", source)
+		return nil
+	}
+}
 
-	check(e.Start(":5000"))
+// getRateLimits returns limits by route class, zero rate or burst disables limiting of a class
+func getRateLimits(cfg *config.Сonfig) map[ratelimit.Class]ratelimit.Limit {
+	return map[ratelimit.Class]ratelimit.Limit{
+		ratelimit.ClassRead:  {Rate: cfg.RateLimitReadRate, Burst: cfg.RateLimitReadBurst},
+		ratelimit.ClassWrite: {Rate: cfg.RateLimitWriteRate, Burst: cfg.RateLimitWriteBurst},
+	}
 }
 
-func startGrpcServer(catsService service.Cats, port string) {
-	listener, err := net.Listen("tcp", port)
+// getRates reads exchange rates of RATES_FILE, it returns nil if the file is not set
+func getRates(cfg *config.Сonfig) *money.FileRates {
+	if cfg.RatesFile == "" {
+		return nil
+	}
+	rates, err := money.NewFileRates(cfg.RatesFile)
 	check(err)
-
-	s := grpc.NewServer()
-	pb.RegisterCatsServiceServer(s, grpcService.NewCatsService(catsService))
-	reflection.Register(s)
-
-	fmt.Printf("Starting gRPC server on port %s\n", port)
-	check(s.Serve(listener))
+	return rates
 }
 
-func consumePrices(redisClient *redis.Client, rabbitChannel *amqp.Channel, consumerNumber int) {
-	queueName := fmt.Sprintf("price_%d", consumerNumber)
-	rabbitPriceProducer, err := producer.NewRabbitPriceProducer(rabbitChannel, "price")
-	check(err)
-	rabbitPriceConsumer, err := consumer.NewRabbitPriceConsumer(rabbitChannel, queueName, "price")
+// getPricingRules reads pricing rules of PRICING_RULES_FILE, it returns nil if the file is not set
+func getPricingRules(cfg *config.Сonfig) *pricing.FileRules {
+	if cfg.PricingRulesFile == "" {
+		return nil
+	}
+	rules, err := pricing.NewFileRules(cfg.PricingRulesFile)
 	check(err)
+	return rules
+}
 
-	redisPriceConsumer := consumer.NewRedisPriceConsumer(redisClient, fmt.Sprintf("%d000-0", time.Now().Unix()))
-	go func() {
-		err := rabbitPriceConsumer.Consume(context.Background(), func(id uuid.UUID, price float64) error {
-			return nil
-		})
-		check(err)
-	}()
-
-	go func() {
-		err := redisPriceConsumer.Consume(context.Background(), func(id uuid.UUID, price float64) error {
-			err := rabbitPriceProducer.Produce(context.Background(), id, price)
-			if err != nil {
-				log.Println(err.Error())
-			}
-			return err
-		})
-		check(err)
-	}()
+// getPricingRuleProvider returns provider of pricing rules, there are no rules without the file
+func getPricingRuleProvider(rules *pricing.FileRules) pricing.Provider {
+	// nil file rules are passed as empty rules rather than an interface holding nil pointer
+	if rules == nil {
+		return pricing.Rules{}
+	}
+	return rules
 }
 
-func getMongo(cfg *config.Сonfig) (*mongo.Client, *mongo.Database) {
-	mongoURI, dbName := getMongoURI(cfg)
+// getConverter returns converter of prices, only conversion to the same currency is possible without rates
+func getConverter(rates *money.FileRates) *money.Converter {
+	// nil file rates are passed as nil provider rather than an interface holding nil pointer
+	if rates == nil {
+		return money.NewConverter(nil)
+	}
+	return money.NewConverter(rates)
+}
 
-	mongoClient, err := mongo.Connect(context.Background(), options.Client().ApplyURI(mongoURI))
+func getMongo(cfg *config.Сonfig) (*mongo.Client, *mongo.Database) {
+	opts := options.Client().ApplyURI(cfg.MongoConnectionURI())
+	if store := getCertStore(cfg, cfg.MongoTLS()); store != nil {
+		// server name is left empty, driver sets it for each host of replica set
+		opts.SetTLSConfig(certs.ClientConfig(store, ""))
+	}
+	mongoClient, err := mongo.Connect(context.Background(), opts)
 	check(err)
 
-	db := mongoClient.Database(dbName)
+	db := mongoClient.Database(cfg.MongoDB)
 	return mongoClient, db
 }
 
-func getMongoURI(cfg *config.Сonfig) (mongoURI, dbName string) {
-	return fmt.Sprintf("mongodb://%s:%s@%s:%d",
-			cfg.MongoUser,
-			cfg.MongoPassword,
-			cfg.MongoHost,
-			cfg.MongoPort),
-		cfg.MongoDB
-}
-
+// getRedis connects to redis when broker or any store uses it, otherwise it returns nil
 func getRedis(cfg *config.Сonfig) *redis.Client {
-	opts := &redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", cfg.RedisHost, cfg.RedisPort),
-		Password: cfg.RedisPass,
+	if !cfg.UsesRedis() {
+		return nil
+	}
+	opts, err := redis.ParseURL(cfg.RedisConnectionURL())
+	check(err)
+	if store := getCertStore(cfg, cfg.RedisTLS()); store != nil {
+		opts.TLSConfig = certs.ClientConfig(store, opts.TLSConfig.ServerName)
 	}
 
 	redisClient := redis.NewClient(opts)
-	_, err := redisClient.Ping(context.Background()).Result()
+	_, err = redisClient.Ping(context.Background()).Result()
 	check(err)
 
 	return redisClient
 }
 
 func getRabbit(cfg *config.Сonfig) *amqp.Connection {
-	connection, err := amqp.Dial(getRabbitURL(cfg))
+	if store := getCertStore(cfg, cfg.RabbitTLS()); store != nil {
+		// server name is left empty, amqp sets it from URL host
+		connection, err := amqp.DialTLS(cfg.RabbitConnectionURL(), certs.ClientConfig(store, ""))
+		check(err)
+		return connection
+	}
+	connection, err := amqp.Dial(cfg.RabbitConnectionURL())
 	check(err)
 	return connection
 }
 
-func getRabbitURL(cfg *config.Сonfig) string {
-	return fmt.Sprintf("amqp://%s:%s@%s:%d/",
-		cfg.RabbitUser,
-		cfg.RabbitPass,
-		cfg.RabbitHost,
-		cfg.RabbitPort,
-	)
+func getNATS(cfg *config.Сonfig) *nats.Conn {
+	if cfg.NatsEmbedded {
+		_, err := broker.StartEmbeddedNATS(cfg.NatsHost, cfg.NatsPort)
+		check(err)
+		logging.Infof("Started embedded NATS server on %s:%d\n", cfg.NatsHost, cfg.NatsPort)
+	}
+
+	conn, err := nats.Connect(cfg.NatsConnectionURL())
+	check(err)
+	return conn
+}
+
+// getCertStore reads certificate files and watches them for changes, it returns nil if none of files is set
+func getCertStore(cfg *config.Сonfig, files certs.Files) *certs.Store {
+	if !files.Enabled() {
+		return nil
+	}
+	store, err := certs.NewStore(files)
+	check(err)
+	go store.Watch(context.Background(), cfg.TLSReloadInterval)
+	return store
+}
+
+// getGrpcClientCredentials returns credentials of REST gateway and CLI calling gRPC server, nil means plaintext
+func getGrpcClientCredentials(cfg *config.Сonfig) credentials.TransportCredentials {
+	if !cfg.GrpcClientUsesTLS() {
+		return nil
+	}
+	store, err := certs.NewStore(cfg.GrpcClientTLS())
+	check(err)
+	if cfg.GrpcClientTLS().Enabled() {
+		go store.Watch(context.Background(), cfg.TLSReloadInterval)
+	}
+	// empty server name is replaced by host of the target by gRPC
+	return credentials.NewTLS(certs.ClientConfig(store, cfg.GrpcClientServerName))
 }
 
 func check(err error) {
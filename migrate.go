@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/evleria/cats-app/internal/config"
+	"github.com/evleria/cats-app/internal/migration"
+)
+
+// runMigrate applies pending database migrations, e.g. `server migrate` or `server migrate -pending` to only list them
+func runMigrate(cfg *config.Сonfig, _ *config.Loader, args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	pendingOnly := flags.Bool("pending", false, "list pending migrations without applying them")
+	check(flags.Parse(args))
+
+	mongoClient, mongoDB := getMongo(cfg)
+	defer mongoClient.Disconnect(context.Background()) //nolint:errcheck,gocritic
+
+	migrator := getMigrator(cfg, mongoDB)
+	if *pendingOnly {
+		pending, err := migrator.Pending(context.Background())
+		check(err)
+		for _, m := range pending {
+			fmt.Printf("pending migration %d: %s\n", m.Version, m.Description)
+		}
+		return
+	}
+	migrate(migrator)
+}
+
+func getMigrator(cfg *config.Сonfig, mongoDB *mongo.Database) *migration.Migrator {
+	return migration.NewMigrator(migration.NewMongoStore(mongoDB), getInstanceID(cfg), cfg.MigrationLockTTL, migration.Mongo(mongoDB))
+}
+
+// migrate applies pending migrations, those applied before a failure stay recorded
+func migrate(migrator *migration.Migrator) {
+	applied, err := migrator.Up(context.Background())
+	for _, m := range applied {
+		fmt.Printf("applied migration %d: %s\n", m.Version, m.Description)
+	}
+	check(err)
+}
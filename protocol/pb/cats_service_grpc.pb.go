@@ -4,10 +4,10 @@ package pb
 
 import (
 	context "context"
-	empty "github.com/golang/protobuf/ptypes/empty"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
 )
 
 // This is a compile-time assertion to ensure that this generated file
@@ -19,11 +19,17 @@ const _ = grpc.SupportPackageIsVersion7
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type CatsServiceClient interface {
-	GetAllCats(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (CatsService_GetAllCatsClient, error)
+	GetAllCats(ctx context.Context, in *GetAllCatsRequest, opts ...grpc.CallOption) (CatsService_GetAllCatsClient, error)
+	SearchCats(ctx context.Context, in *SearchCatsRequest, opts ...grpc.CallOption) (*SearchCatsResponse, error)
 	GetCat(ctx context.Context, in *GetCatRequest, opts ...grpc.CallOption) (*GetCatResponse, error)
 	AddNewCat(ctx context.Context, in *AddNewCatRequest, opts ...grpc.CallOption) (*AddNewCatResponse, error)
-	DeleteCat(ctx context.Context, in *DeleteCatRequest, opts ...grpc.CallOption) (*empty.Empty, error)
-	UpdatePrice(ctx context.Context, in *UpdatePriceRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	DeleteCat(ctx context.Context, in *DeleteCatRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	UpdatePrice(ctx context.Context, in *UpdatePriceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ReserveCat(ctx context.Context, in *ReserveCatRequest, opts ...grpc.CallOption) (*ReserveCatResponse, error)
+	CancelReservation(ctx context.Context, in *CancelReservationRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	PurchaseCat(ctx context.Context, in *PurchaseCatRequest, opts ...grpc.CallOption) (*PurchaseCatResponse, error)
+	// RecordView counts a view of a cat by a client showing it, views are a signal of pricing rules
+	RecordView(ctx context.Context, in *RecordViewRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type catsServiceClient struct {
@@ -34,7 +40,7 @@ func NewCatsServiceClient(cc grpc.ClientConnInterface) CatsServiceClient {
 	return &catsServiceClient{cc}
 }
 
-func (c *catsServiceClient) GetAllCats(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (CatsService_GetAllCatsClient, error) {
+func (c *catsServiceClient) GetAllCats(ctx context.Context, in *GetAllCatsRequest, opts ...grpc.CallOption) (CatsService_GetAllCatsClient, error) {
 	stream, err := c.cc.NewStream(ctx, &CatsService_ServiceDesc.Streams[0], "/CatsService/GetAllCats", opts...)
 	if err != nil {
 		return nil, err
@@ -66,6 +72,15 @@ func (x *catsServiceGetAllCatsClient) Recv() (*GetAllCatsResponse, error) {
 	return m, nil
 }
 
+func (c *catsServiceClient) SearchCats(ctx context.Context, in *SearchCatsRequest, opts ...grpc.CallOption) (*SearchCatsResponse, error) {
+	out := new(SearchCatsResponse)
+	err := c.cc.Invoke(ctx, "/CatsService/SearchCats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *catsServiceClient) GetCat(ctx context.Context, in *GetCatRequest, opts ...grpc.CallOption) (*GetCatResponse, error) {
 	out := new(GetCatResponse)
 	err := c.cc.Invoke(ctx, "/CatsService/GetCat", in, out, opts...)
@@ -84,8 +99,8 @@ func (c *catsServiceClient) AddNewCat(ctx context.Context, in *AddNewCatRequest,
 	return out, nil
 }
 
-func (c *catsServiceClient) DeleteCat(ctx context.Context, in *DeleteCatRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
-	out := new(empty.Empty)
+func (c *catsServiceClient) DeleteCat(ctx context.Context, in *DeleteCatRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
 	err := c.cc.Invoke(ctx, "/CatsService/DeleteCat", in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -93,8 +108,8 @@ func (c *catsServiceClient) DeleteCat(ctx context.Context, in *DeleteCatRequest,
 	return out, nil
 }
 
-func (c *catsServiceClient) UpdatePrice(ctx context.Context, in *UpdatePriceRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
-	out := new(empty.Empty)
+func (c *catsServiceClient) UpdatePrice(ctx context.Context, in *UpdatePriceRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
 	err := c.cc.Invoke(ctx, "/CatsService/UpdatePrice", in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -102,15 +117,57 @@ func (c *catsServiceClient) UpdatePrice(ctx context.Context, in *UpdatePriceRequ
 	return out, nil
 }
 
+func (c *catsServiceClient) ReserveCat(ctx context.Context, in *ReserveCatRequest, opts ...grpc.CallOption) (*ReserveCatResponse, error) {
+	out := new(ReserveCatResponse)
+	err := c.cc.Invoke(ctx, "/CatsService/ReserveCat", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *catsServiceClient) CancelReservation(ctx context.Context, in *CancelReservationRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/CatsService/CancelReservation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *catsServiceClient) PurchaseCat(ctx context.Context, in *PurchaseCatRequest, opts ...grpc.CallOption) (*PurchaseCatResponse, error) {
+	out := new(PurchaseCatResponse)
+	err := c.cc.Invoke(ctx, "/CatsService/PurchaseCat", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *catsServiceClient) RecordView(ctx context.Context, in *RecordViewRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/CatsService/RecordView", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CatsServiceServer is the server API for CatsService service.
 // All implementations must embed UnimplementedCatsServiceServer
 // for forward compatibility
 type CatsServiceServer interface {
-	GetAllCats(*empty.Empty, CatsService_GetAllCatsServer) error
+	GetAllCats(*GetAllCatsRequest, CatsService_GetAllCatsServer) error
+	SearchCats(context.Context, *SearchCatsRequest) (*SearchCatsResponse, error)
 	GetCat(context.Context, *GetCatRequest) (*GetCatResponse, error)
 	AddNewCat(context.Context, *AddNewCatRequest) (*AddNewCatResponse, error)
-	DeleteCat(context.Context, *DeleteCatRequest) (*empty.Empty, error)
-	UpdatePrice(context.Context, *UpdatePriceRequest) (*empty.Empty, error)
+	DeleteCat(context.Context, *DeleteCatRequest) (*emptypb.Empty, error)
+	UpdatePrice(context.Context, *UpdatePriceRequest) (*emptypb.Empty, error)
+	ReserveCat(context.Context, *ReserveCatRequest) (*ReserveCatResponse, error)
+	CancelReservation(context.Context, *CancelReservationRequest) (*emptypb.Empty, error)
+	PurchaseCat(context.Context, *PurchaseCatRequest) (*PurchaseCatResponse, error)
+	// RecordView counts a view of a cat by a client showing it, views are a signal of pricing rules
+	RecordView(context.Context, *RecordViewRequest) (*emptypb.Empty, error)
 	mustEmbedUnimplementedCatsServiceServer()
 }
 
@@ -118,21 +175,36 @@ type CatsServiceServer interface {
 type UnimplementedCatsServiceServer struct {
 }
 
-func (UnimplementedCatsServiceServer) GetAllCats(*empty.Empty, CatsService_GetAllCatsServer) error {
+func (UnimplementedCatsServiceServer) GetAllCats(*GetAllCatsRequest, CatsService_GetAllCatsServer) error {
 	return status.Errorf(codes.Unimplemented, "method GetAllCats not implemented")
 }
+func (UnimplementedCatsServiceServer) SearchCats(context.Context, *SearchCatsRequest) (*SearchCatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchCats not implemented")
+}
 func (UnimplementedCatsServiceServer) GetCat(context.Context, *GetCatRequest) (*GetCatResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetCat not implemented")
 }
 func (UnimplementedCatsServiceServer) AddNewCat(context.Context, *AddNewCatRequest) (*AddNewCatResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddNewCat not implemented")
 }
-func (UnimplementedCatsServiceServer) DeleteCat(context.Context, *DeleteCatRequest) (*empty.Empty, error) {
+func (UnimplementedCatsServiceServer) DeleteCat(context.Context, *DeleteCatRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteCat not implemented")
 }
-func (UnimplementedCatsServiceServer) UpdatePrice(context.Context, *UpdatePriceRequest) (*empty.Empty, error) {
+func (UnimplementedCatsServiceServer) UpdatePrice(context.Context, *UpdatePriceRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdatePrice not implemented")
 }
+func (UnimplementedCatsServiceServer) ReserveCat(context.Context, *ReserveCatRequest) (*ReserveCatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReserveCat not implemented")
+}
+func (UnimplementedCatsServiceServer) CancelReservation(context.Context, *CancelReservationRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelReservation not implemented")
+}
+func (UnimplementedCatsServiceServer) PurchaseCat(context.Context, *PurchaseCatRequest) (*PurchaseCatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PurchaseCat not implemented")
+}
+func (UnimplementedCatsServiceServer) RecordView(context.Context, *RecordViewRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecordView not implemented")
+}
 func (UnimplementedCatsServiceServer) mustEmbedUnimplementedCatsServiceServer() {}
 
 // UnsafeCatsServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -147,7 +219,7 @@ func RegisterCatsServiceServer(s grpc.ServiceRegistrar, srv CatsServiceServer) {
 }
 
 func _CatsService_GetAllCats_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(empty.Empty)
+	m := new(GetAllCatsRequest)
 	if err := stream.RecvMsg(m); err != nil {
 		return err
 	}
@@ -167,6 +239,24 @@ func (x *catsServiceGetAllCatsServer) Send(m *GetAllCatsResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _CatsService_SearchCats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchCatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CatsServiceServer).SearchCats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CatsService/SearchCats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CatsServiceServer).SearchCats(ctx, req.(*SearchCatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CatsService_GetCat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetCatRequest)
 	if err := dec(in); err != nil {
@@ -239,6 +329,78 @@ func _CatsService_UpdatePrice_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CatsService_ReserveCat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReserveCatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CatsServiceServer).ReserveCat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CatsService/ReserveCat",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CatsServiceServer).ReserveCat(ctx, req.(*ReserveCatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CatsService_CancelReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelReservationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CatsServiceServer).CancelReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CatsService/CancelReservation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CatsServiceServer).CancelReservation(ctx, req.(*CancelReservationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CatsService_PurchaseCat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurchaseCatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CatsServiceServer).PurchaseCat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CatsService/PurchaseCat",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CatsServiceServer).PurchaseCat(ctx, req.(*PurchaseCatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CatsService_RecordView_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordViewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CatsServiceServer).RecordView(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/CatsService/RecordView",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CatsServiceServer).RecordView(ctx, req.(*RecordViewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CatsService_ServiceDesc is the grpc.ServiceDesc for CatsService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -246,6 +408,10 @@ var CatsService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "CatsService",
 	HandlerType: (*CatsServiceServer)(nil),
 	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SearchCats",
+			Handler:    _CatsService_SearchCats_Handler,
+		},
 		{
 			MethodName: "GetCat",
 			Handler:    _CatsService_GetCat_Handler,
@@ -262,6 +428,22 @@ var CatsService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdatePrice",
 			Handler:    _CatsService_UpdatePrice_Handler,
 		},
+		{
+			MethodName: "ReserveCat",
+			Handler:    _CatsService_ReserveCat_Handler,
+		},
+		{
+			MethodName: "CancelReservation",
+			Handler:    _CatsService_CancelReservation_Handler,
+		},
+		{
+			MethodName: "PurchaseCat",
+			Handler:    _CatsService_PurchaseCat_Handler,
+		},
+		{
+			MethodName: "RecordView",
+			Handler:    _CatsService_RecordView_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/evleria/cats-app/internal/config"
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/internal/pricing"
+	"github.com/evleria/cats-app/internal/ratelimit"
+)
+
+// reloadOnSignal reloads config on SIGHUP and applies settings that are safe to change while serving,
+// i.e. log level, rate limits and API keys, and reads exchange rates again if they are read from a file.
+// Invalid config or rates are rejected as a whole and the running ones are kept.
+func reloadOnSignal(loader *config.Loader, rateLimits *ratelimit.Rules, rates *money.FileRates, pricingRules *pricing.FileRules) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+		if rates != nil {
+			if err := rates.Reload(); err != nil {
+				logging.Errorf("cannot reload exchange rates: %v\n", err)
+			}
+		}
+		if pricingRules != nil {
+			if err := pricingRules.Reload(); err != nil {
+				logging.Errorf("cannot reload pricing rules: %v\n", err)
+			}
+		}
+		cfg, err := loader.Load()
+		if err != nil {
+			logging.Errorf("cannot reload config: %v\n", err)
+			continue
+		}
+		logging.SetLevel(cfg.LogLevel)
+		rateLimits.Set(getRateLimits(cfg))
+		rateLimits.SetAPIKeys(cfg.RateLimitAPIKeys)
+		logging.Infof("reloaded config, log level %s, rate limits %v\n", cfg.LogLevel, getRateLimits(cfg))
+	}
+}
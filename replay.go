@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/evleria/cats-app/internal/broker"
+	"github.com/evleria/cats-app/internal/config"
+	"github.com/evleria/cats-app/internal/consumer"
+	"github.com/evleria/cats-app/internal/event"
+	"github.com/evleria/cats-app/internal/producer"
+	"github.com/evleria/cats-app/internal/replay"
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+// runReplay replays price stream of a tenant into a handler,
+// e.g. `server replay -tenant shelter-1 -handler price-history -from 2021-08-01T00:00:00Z -rate 500`
+func runReplay(cfg *config.Сonfig, _ *config.Loader, args []string) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	handlerName := flags.String("handler", "", "handler to replay into: price-history, cache-warmup or republish")
+	from := flags.String("from", replay.StreamStart, "first stream ID or RFC 3339 time of the range")
+	to := flags.String("to", replay.StreamEnd, "last stream ID or RFC 3339 time of the range")
+	rate := flags.Float64("rate", 0, "maximal number of events per second, 0 means no limit")
+	tenantID := flags.String("tenant", tenant.Default, "tenant whose price stream is replayed")
+	check(flags.Parse(args))
+	check(tenant.Validate(*tenantID))
+
+	fromID, err := replay.ParseBound(*from)
+	check(err)
+	toID, err := replay.ParseBound(*to)
+	check(err)
+
+	mongoClient, mongoDB := getMongo(cfg)
+	defer mongoClient.Disconnect(context.Background()) //nolint:errcheck,gocritic
+
+	redisClient := getRedis(cfg)
+	if redisClient != nil {
+		defer redisClient.Close() //nolint:errcheck,gocritic
+	}
+
+	brokerKind, conns, closeBroker := getBroker(cfg, redisClient)
+	defer closeBroker()
+
+	codec, err := event.NewCodec(event.Format(cfg.EventFormat), cfg.EventSource)
+	check(err)
+
+	// in-memory dedup cache belongs to a running server, it cannot be warmed up from outside
+	var dedupStore consumer.DedupStore
+	if cfg.DedupStore == "redis" {
+		dedupStore = getDedupStore(cfg, redisClient)
+	}
+	handlers := getReplayHandlers(brokerKind, conns, codec, repository.NewPriceHistoryRepository(mongoDB), dedupStore)
+	handler, ok := handlers[*handlerName]
+	if !ok {
+		check(fmt.Errorf("%w: %q", replay.ErrUnknownHandler, *handlerName))
+	}
+
+	ctx, stop := signal.NotifyContext(tenant.WithID(context.Background(), *tenantID), os.Interrupt)
+	defer stop()
+
+	source := getReplaySource(cfg, mongoDB, redisClient, codec)(*tenantID)
+	opts := replay.Options{From: fromID, To: toID, Rate: *rate}
+	progress := replay.Run(ctx, source, handler, opts, replay.Progress{Handler: *handlerName, From: fromID, To: toID}, func(p replay.Progress) {
+		fmt.Printf("replay %s: %d events, last ID %s, %.1f%%\n", p.State, p.Processed, p.LastID, p.Percent)
+	})
+	if progress.State != replay.StateCompleted {
+		check(fmt.Errorf("replay %s: %s", progress.State, progress.Error))
+	}
+}
+
+// getReplayHandlers returns handlers price stream can be replayed into, the ones unsupported by configuration are omitted
+func getReplayHandlers(brokerKind broker.Kind, conns broker.Connections, codec *event.Codec, priceHistory repository.PriceHistory, dedupStore consumer.DedupStore) map[string]replay.Handler {
+	handlers := map[string]replay.Handler{
+		"price-history": replay.PriceHistory(priceHistory),
+	}
+	if dedupStore != nil {
+		handlers["cache-warmup"] = replay.CacheWarmup(dedupStore)
+	}
+
+	// republishing makes sense only to a fanout different from the replayed redis stream
+	republishKind := brokerKind
+	if brokerKind == broker.RedisRabbit {
+		republishKind = broker.Rabbit
+	}
+	if republishKind != broker.Redis {
+		republishProducer, err := producer.NewPriceProducer(republishKind, conns, codec, nil)
+		check(err)
+		handlers["republish"] = replay.Republish(republishProducer)
+	}
+	return handlers
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpcHealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/evleria/cats-app/internal/broker"
+	"github.com/evleria/cats-app/internal/certs"
+	"github.com/evleria/cats-app/internal/config"
+	"github.com/evleria/cats-app/internal/consumer"
+	"github.com/evleria/cats-app/internal/event"
+	grpcService "github.com/evleria/cats-app/internal/grpc"
+	"github.com/evleria/cats-app/internal/handler"
+	"github.com/evleria/cats-app/internal/health"
+	"github.com/evleria/cats-app/internal/leader"
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/producer"
+	"github.com/evleria/cats-app/internal/ratelimit"
+	"github.com/evleria/cats-app/internal/replay"
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/service"
+	"github.com/evleria/cats-app/internal/tenant"
+	"github.com/evleria/cats-app/protocol/pb"
+)
+
+// startHTTPServer listens synchronously, so the role is reported up only when it accepts connections
+func startHTTPServer(cfg *config.Сonfig, deps handler.Dependencies, component *health.Component, failed chan<- error) func(ctx context.Context) error {
+	e := echo.New()
+	e.HTTPErrorHandler = handler.ErrorHandler
+	// instances are exposed directly, so forwarding headers are not trusted for identifying clients
+	e.IPExtractor = echo.ExtractIPDirect()
+	e.Use(middleware.RequestID(), middleware.Recover())
+	handler.RegisterRoutes(e, deps)
+
+	listener, err := net.Listen("tcp", cfg.HTTPAddr)
+	check(err)
+	server := e.Server
+	if store := getCertStore(cfg, cfg.HTTPTLS()); store != nil {
+		server = e.TLSServer
+		server.TLSConfig = certs.ServerConfig(store)
+		server.TLSConfig.NextProtos = []string{"h2", "http/1.1"}
+		e.TLSListener = tls.NewListener(listener, server.TLSConfig)
+	} else {
+		e.Listener = listener
+	}
+
+	go func() {
+		if err := e.StartServer(server); !errors.Is(err, http.ErrServerClosed) {
+			roleFailed(component, failed, err)
+		}
+	}()
+	component.Set(health.StatusUp, listener.Addr().String())
+
+	return e.Shutdown
+}
+
+// startGrpcServer serves gRPC API along with standard gRPC health service,
+// the latter reports not serving as soon as shutdown begins
+func startGrpcServer(cfg *config.Сonfig, tenants *tenant.Resolver, catsService service.Cats, pricesService service.Prices, schedulesService service.Schedules, idempotencyKeys repository.IdempotencyKeys, rateLimiter ratelimit.Limiter, rateLimits *ratelimit.Rules, component *health.Component, failed chan<- error) func(ctx context.Context) error {
+	listener, err := net.Listen("tcp", cfg.GrpcAddr)
+	check(err)
+
+	// interceptors wrap each other in listed order, so idempotency stores statuses already translated by error mapping
+	// and its keys are scoped by tenant
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			grpcService.LoggingUnaryInterceptor(),
+			grpcService.RecoveryUnaryInterceptor(),
+			grpcService.RateLimitUnaryInterceptor(rateLimiter, rateLimits),
+			grpcService.DeadlineUnaryInterceptor(cfg.GrpcUnaryTimeout),
+			grpcService.TenantUnaryInterceptor(tenants),
+			grpcService.IdempotencyInterceptor(idempotencyKeys),
+			grpcService.ErrorUnaryInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			grpcService.LoggingStreamInterceptor(),
+			grpcService.RecoveryStreamInterceptor(),
+			grpcService.RateLimitStreamInterceptor(rateLimiter, rateLimits),
+			grpcService.DeadlineStreamInterceptor(cfg.GrpcStreamTimeout),
+			grpcService.TenantStreamInterceptor(tenants),
+			grpcService.ErrorStreamInterceptor(),
+		),
+	}
+	if store := getCertStore(cfg, cfg.GrpcTLS()); store != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(certs.ServerConfig(store))))
+	}
+	s := grpc.NewServer(opts...)
+	pb.RegisterCatsServiceServer(s, grpcService.NewCatsService(catsService, pricesService))
+	pb.RegisterPriceSchedulesServiceServer(s, grpcService.NewPriceSchedulesService(schedulesService))
+	healthServer := grpcHealth.NewServer()
+	healthpb.RegisterHealthServer(s, healthServer)
+	reflection.Register(s)
+
+	go func() {
+		if err := s.Serve(listener); err != nil {
+			roleFailed(component, failed, err)
+		}
+	}()
+	component.Set(health.StatusUp, listener.Addr().String())
+	logging.Infof("Starting gRPC server on %s\n", listener.Addr())
+
+	return func(ctx context.Context) error {
+		healthServer.Shutdown()
+		stopped := make(chan struct{})
+		go func() {
+			s.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			// streams still open are cut off, e.g. long listing of cats
+			s.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// startScheduler runs due price schedules every SCHEDULE_CHECK_INTERVAL and automatic pricing rules every PRICING_INTERVAL
+// for all tenants while this instance is a leader, schedules change state conditionally, so a stale leader cannot run
+// a schedule twice either, and the fencing token is checked before every schedule and price change they make
+func startScheduler(cfg *config.Сonfig, schedulerElector leader.Elector, schedulesService service.Schedules, pricingService service.Pricing, component *health.Component) func(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	component.Set(health.StatusUp, "standby")
+	go func() {
+		defer close(done)
+		schedulerElector.Run(ctx, func(ctx context.Context, token int64) error {
+			component.SetDetail("leader")
+			defer component.SetDetail("standby")
+			schedules := time.NewTicker(cfg.ScheduleCheckInterval)
+			defer schedules.Stop()
+			pricing := time.NewTicker(cfg.PricingInterval)
+			defer pricing.Stop()
+
+			// runAsLeader runs a job unless leadership has been lost in the meantime, a failed job is retried on the next tick
+			runAsLeader := func(name string, job func(ctx context.Context) error) error {
+				if err := schedulerElector.Validate(ctx, token); err != nil {
+					return err
+				}
+				if err := job(ctx); err != nil && ctx.Err() == nil {
+					logging.Warnf("cannot run %s: %v\n", name, err)
+				}
+				return nil
+			}
+			for {
+				var err error
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-schedules.C:
+					err = runAsLeader("price schedules", func(ctx context.Context) error {
+						return schedulesService.RunDue(ctx, func(ctx context.Context) error {
+							return schedulerElector.Validate(ctx, token)
+						})
+					})
+				case <-pricing.C:
+					err = runAsLeader("pricing rules", pricingService.RunAll)
+				}
+				if err != nil {
+					return err
+				}
+			}
+		})
+	}()
+
+	return func(stopCtx context.Context) error {
+		cancel()
+		return wait(stopCtx, done)
+	}
+}
+
+// startBridge forwards price events of all tenants from redis streams to rabbitMQ exchanges while this instance is a leader,
+// with other brokers there is nothing to bridge, so the role stays up doing nothing
+func startBridge(cfg *config.Сonfig, conns broker.Connections, codec *event.Codec, bridgeElector leader.Elector, component *health.Component) func(ctx context.Context) error {
+	if bridgeElector == nil {
+		logging.Infof("price bridge is disabled, it is used only by redis-rabbit broker\n")
+		component.Set(health.StatusUp, "disabled")
+		return func(ctx context.Context) error { return nil }
+	}
+
+	// exchange of an event is chosen by its tenant
+	rabbitPriceProducer, err := producer.NewRabbitPriceProducer(conns.Rabbit, producer.PriceTopic, codec)
+	check(err)
+	// all leaders read as the same group member, so a new leader picks up messages left pending by the previous one
+	redisPriceConsumers := make([]consumer.Price, len(cfg.Tenants))
+	for i, id := range cfg.Tenants {
+		stream := tenant.Topic(producer.PriceTopic, id)
+		redisPriceConsumers[i] = consumer.NewTenantPriceConsumer(consumer.NewRedisGroupPriceConsumer(conns.Redis, stream, "price-bridge", "bridge", codec), id)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	component.Set(health.StatusUp, "standby")
+	go func() {
+		defer close(done)
+		bridgeElector.Run(ctx, func(ctx context.Context, token int64) error {
+			component.SetDetail("leader")
+			defer component.SetDetail("standby")
+			return consumeAll(ctx, redisPriceConsumers, func(e event.PriceChanged) error {
+				// fencing token guards against a stale leader that has not noticed losing its lock yet
+				if err := bridgeElector.Validate(ctx, token); err != nil {
+					return err
+				}
+				err := rabbitPriceProducer.Produce(ctx, e)
+				if err != nil {
+					logging.Warnf("cannot bridge price event %v: %v\n", e.ID, err)
+				}
+				return err
+			})
+		})
+	}()
+
+	return func(stopCtx context.Context) error {
+		cancel()
+		return wait(stopCtx, done)
+	}
+}
+
+// consumeAll consumes from all consumers concurrently until ctx is done or any of them stops,
+// the first error stops the rest
+func consumeAll(ctx context.Context, consumers []consumer.Price, callbackFunc func(e event.PriceChanged) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, len(consumers))
+	for _, c := range consumers {
+		go func(c consumer.Price) {
+			errs <- c.Consume(ctx, callbackFunc)
+		}(c)
+	}
+	err := <-errs
+	cancel()
+	for i := 1; i < len(consumers); i++ {
+		<-errs
+	}
+	return err
+}
+
+// startFanoutConsumer records price history of events of all tenants delivered to every instance
+// and runs background jobs: releasing expired reservations and trimming price streams
+func startFanoutConsumer(cfg *config.Сonfig, brokerKind broker.Kind, conns broker.Connections, codec *event.Codec, dedupStore consumer.DedupStore, priceHistory repository.PriceHistory, catsService service.Cats, priceRetentions producer.Retentions, component *health.Component, failed chan<- error) func(ctx context.Context) error {
+	priceConsumers := make(map[string]consumer.Price, len(cfg.Tenants))
+	for _, id := range cfg.Tenants {
+		priceConsumer, err := consumer.NewPriceConsumer(brokerKind, conns, codec, cfg.ConsumerNumber, id)
+		check(err)
+		priceConsumers[id] = consumer.NewDeduplicatingPriceConsumer(priceConsumer, dedupStore)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for id, priceConsumer := range priceConsumers {
+		wg.Add(1)
+		go func(id string, priceConsumer consumer.Price) {
+			defer wg.Done()
+			if err := consumePrices(ctx, id, priceConsumer, priceHistory); ctx.Err() == nil {
+				if err == nil {
+					err = errors.New("price consumer has stopped")
+				}
+				roleFailed(component, failed, fmt.Errorf("tenant %s: %w", id, err))
+			}
+		}(id, priceConsumer)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		releaseExpiredReservations(ctx, catsService, cfg.ReservationCheckInterval)
+	}()
+	for _, retention := range priceRetentions {
+		wg.Add(1)
+		go func(retention *producer.StreamRetention) {
+			defer wg.Done()
+			retention.Run(ctx, cfg.StreamTrimInterval)
+		}(retention)
+	}
+	component.Set(health.StatusUp, fmt.Sprintf("%s consumer %d of %d tenants", brokerKind, cfg.ConsumerNumber, len(cfg.Tenants)))
+
+	return func(stopCtx context.Context) error {
+		cancel()
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		return wait(stopCtx, done)
+	}
+}
+
+func consumePrices(ctx context.Context, tenantID string, priceConsumer consumer.Price, priceHistory repository.PriceHistory) error {
+	recordPriceHistory := replay.PriceHistory(priceHistory)
+	// an event being recorded is finished even if shutdown has begun, so it is not delivered again
+	recordCtx := tenant.WithID(context.Background(), tenantID)
+	return priceConsumer.Consume(ctx, func(e event.PriceChanged) error {
+		return recordPriceHistory(recordCtx, e)
+	})
+}
+
+func releaseExpiredReservations(ctx context.Context, catsService service.Cats, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := catsService.ReleaseExpiredReservations(ctx); err != nil && ctx.Err() == nil {
+			logging.Warnf("cannot release expired reservations: %v\n", err)
+		}
+	}
+}
+
+// wait waits until done is closed or ctx is done
+func wait(ctx context.Context, done <-chan struct{}) error {
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/evleria/cats-app/internal/config"
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/service"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+//go:embed fixtures/cats.json
+var defaultFixtures []byte
+
+// fixtureCat is a cat of fixtures file, birth date is formatted as YYYY-MM-DD
+type fixtureCat struct {
+	Name        string   `json:"name"`
+	Color       string   `json:"color"`
+	Breed       string   `json:"breed"`
+	Sex         string   `json:"sex"`
+	BirthDate   string   `json:"birthDate"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	Price       struct {
+		// Amount is in minor units, e.g. 35000 USD is $350
+		Amount   int64  `json:"amount"`
+		Currency string `json:"currency"`
+	} `json:"price"`
+}
+
+// runSeed inserts fixture cats of a tenant into database, e.g. `server seed -file fixtures/cats.json -tenant shelter-1`.
+// Cats are inserted directly, so no price events are produced for them.
+func runSeed(cfg *config.Сonfig, _ *config.Loader, args []string) {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	file := flags.String("file", "", "JSON file with an array of cats, built-in fixtures are used if empty")
+	tenantID := flags.String("tenant", tenant.Default, "tenant cats are added to")
+	check(flags.Parse(args))
+	check(tenant.Validate(*tenantID))
+
+	data := defaultFixtures
+	if *file != "" {
+		var err error
+		data, err = os.ReadFile(*file)
+		check(err)
+	}
+	cats, err := parseFixtures(data, time.Now())
+	check(err)
+
+	mongoClient, mongoDB := getMongo(cfg)
+	defer mongoClient.Disconnect(context.Background()) //nolint:errcheck,gocritic
+
+	catsRepository := repository.NewCatsRepository(mongoDB)
+	ctx := tenant.WithID(context.Background(), *tenantID)
+	for _, cat := range cats {
+		id, err := catsRepository.Insert(ctx, cat)
+		check(err)
+		fmt.Printf("added %s %s\n", id, cat.Name)
+	}
+}
+
+// parseFixtures converts fixtures to cats, validating them as the service validates new cats
+func parseFixtures(data []byte, now time.Time) ([]entities.Cat, error) {
+	var fixtures []fixtureCat
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("cannot parse fixtures: %w", err)
+	}
+
+	cats := make([]entities.Cat, len(fixtures))
+	for i, f := range fixtures {
+		cats[i] = entities.Cat{
+			Name:        f.Name,
+			Color:       f.Color,
+			Breed:       f.Breed,
+			Sex:         entities.Sex(f.Sex),
+			Description: f.Description,
+			Tags:        f.Tags,
+			Price:       money.Money{Amount: f.Price.Amount, Currency: f.Price.Currency},
+		}
+		if f.BirthDate != "" {
+			birthDate, err := time.Parse("2006-01-02", f.BirthDate)
+			if err != nil {
+				return nil, fmt.Errorf("cat %q: invalid birth date: %w", f.Name, err)
+			}
+			cats[i].BirthDate = &birthDate
+		}
+		if err := service.ValidateNewCat(cats[i], now); err != nil {
+			return nil, fmt.Errorf("cat %d %q: %w", i, f.Name, err)
+		}
+	}
+	return cats, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/money"
+	"github.com/evleria/cats-app/internal/repository/entities"
+	"github.com/evleria/cats-app/internal/service"
+)
+
+var seedTime = time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+
+func TestParseFixtures(t *testing.T) {
+	// Arrange
+	data := []byte(`[
+		{"name": "Barsik", "color": "ginger", "sex": "male", "birthDate": "2019-04-12", "tags": ["calm"], "price": {"amount": 35000, "currency": "USD"}},
+		{"name": "Murka", "price": {"amount": 0, "currency": "EUR"}}
+	]`)
+
+	// Act
+	cats, err := parseFixtures(data, seedTime)
+
+	// Assert
+	require.NoError(t, err)
+	birthDate := time.Date(2019, 4, 12, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, []entities.Cat{
+		{Name: "Barsik", Color: "ginger", Sex: entities.SexMale, BirthDate: &birthDate, Tags: []string{"calm"}, Price: money.Money{Amount: 35000, Currency: "USD"}},
+		{Name: "Murka", Price: money.Money{Amount: 0, Currency: "EUR"}},
+	}, cats)
+}
+
+func TestParseDefaultFixtures(t *testing.T) {
+	// Act
+	cats, err := parseFixtures(defaultFixtures, seedTime)
+
+	// Assert
+	require.NoError(t, err)
+	require.NotEmpty(t, cats)
+}
+
+func TestParseFixturesInvalid(t *testing.T) {
+	for _, test := range []struct {
+		data  string
+		field string
+	}{
+		{`[{"price": {"amount": 100, "currency": "USD"}}]`, "name"},
+		{`[{"name": "Barsik", "price": {"amount": -1, "currency": "USD"}}]`, "price.amount"},
+		{`[{"name": "Barsik", "price": {"amount": 100, "currency": "usd"}}]`, "price.currency"},
+		{`[{"name": "Barsik", "price": {"amount": 100}}]`, "price.currency"},
+		{`[{"name": "Barsik", "birthDate": "2030-01-01", "price": {"amount": 100, "currency": "USD"}}]`, "birthDate"},
+	} {
+		// Act
+		_, err := parseFixtures([]byte(test.data), seedTime)
+
+		// Assert
+		var validationErr *service.ValidationError
+		require.ErrorAs(t, err, &validationErr, test.data)
+		require.Equal(t, test.field, validationErr.Field, test.data)
+	}
+}
+
+func TestParseFixturesMalformed(t *testing.T) {
+	for _, data := range []string{
+		`{"name": "Barsik"}`,
+		`[{"name": "Barsik", "birthDate": "12.04.2019", "price": {"amount": 100, "currency": "USD"}}]`,
+	} {
+		// Act
+		_, err := parseFixtures([]byte(data), seedTime)
+
+		// Assert
+		require.Error(t, err, data)
+	}
+}
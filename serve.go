@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/evleria/cats-app/internal/broker"
+	"github.com/evleria/cats-app/internal/config"
+	"github.com/evleria/cats-app/internal/consumer"
+	"github.com/evleria/cats-app/internal/event"
+	grpcService "github.com/evleria/cats-app/internal/grpc"
+	"github.com/evleria/cats-app/internal/handler"
+	"github.com/evleria/cats-app/internal/health"
+	"github.com/evleria/cats-app/internal/leader"
+	"github.com/evleria/cats-app/internal/logging"
+	"github.com/evleria/cats-app/internal/producer"
+	"github.com/evleria/cats-app/internal/ratelimit"
+	"github.com/evleria/cats-app/internal/replay"
+	"github.com/evleria/cats-app/internal/repository"
+	"github.com/evleria/cats-app/internal/service"
+	"github.com/evleria/cats-app/internal/tenant"
+)
+
+// role is a part of the app a process runs, processes of different roles share storage and broker,
+// so API and event processing can be scaled independently
+type role string
+
+const (
+	// roleHTTP serves REST API and REST gateway
+	roleHTTP role = "http"
+	// roleGRPC serves gRPC API
+	roleGRPC role = "grpc"
+	// roleScheduler applies price schedules and automatic pricing rules of all tenants while the process is a leader
+	roleScheduler role = "scheduler"
+	// roleBridge forwards price events from redis stream to rabbitMQ while the process is a leader,
+	// it does nothing unless redis-rabbit broker is used
+	roleBridge role = "bridge"
+	// roleFanoutConsumer consumes price events delivered to every instance and runs background jobs,
+	// i.e. releasing expired reservations and trimming price stream
+	roleFanoutConsumer role = "fanout-consumer"
+)
+
+// allRoles are listed in order of shutdown, API stops taking requests before events they produce stop being processed
+var allRoles = []role{roleHTTP, roleGRPC, roleScheduler, roleBridge, roleFanoutConsumer}
+
+// roleProcess is a started role, stop makes it finish work in progress until ctx is done
+type roleProcess struct {
+	health *health.Component
+	stop   func(ctx context.Context) error
+}
+
+// runServe runs roles until interrupted, e.g. `server serve -roles http,grpc`.
+// Health of each role is served on HEALTH_ADDR, a failed role shuts the whole process down.
+func runServe(cfg *config.Сonfig, configLoader *config.Loader, args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	rolesFlag := flags.String("roles", strings.Join(cfg.Roles, ","), "comma separated roles to run, overrides ROLES: "+joinRoles(allRoles))
+	check(flags.Parse(args))
+	roles, err := parseRoles(*rolesFlag)
+	check(err)
+
+	check(serve(cfg, configLoader, roles))
+}
+
+func serve(cfg *config.Сonfig, configLoader *config.Loader, roles map[role]bool) error {
+	mongoClient, mongoDB := getMongo(cfg)
+	defer mongoClient.Disconnect(context.Background()) //nolint:errcheck,gocritic
+
+	redisClient := getRedis(cfg)
+	if redisClient != nil {
+		defer redisClient.Close() //nolint:errcheck,gocritic
+	}
+
+	brokerKind, conns, closeBroker := getBroker(cfg, redisClient)
+	defer closeBroker()
+
+	codec, err := event.NewCodec(event.Format(cfg.EventFormat), cfg.EventSource)
+	check(err)
+
+	if cfg.MigrateOnStart {
+		migrate(getMigrator(cfg, mongoDB))
+	}
+
+	registry := health.NewRegistry()
+	pingMongo := func(ctx context.Context) error { return mongoClient.Ping(ctx, nil) }
+	// bridge does not touch mongo, so it is not reported down when only mongo is unavailable
+	checks := map[role]map[string]health.Check{
+		roleHTTP:           {"mongo": pingMongo},
+		roleGRPC:           {"mongo": pingMongo},
+		roleScheduler:      {"mongo": pingMongo},
+		roleBridge:         {},
+		roleFanoutConsumer: {"mongo": pingMongo},
+	}
+	if redisClient != nil {
+		pingRedis := func(ctx context.Context) error { return redisClient.Ping(ctx).Err() }
+		for _, roleChecks := range checks {
+			roleChecks["redis"] = pingRedis
+		}
+	}
+	healthServer := startHealthServer(cfg, registry)
+	defer healthServer.Close() //nolint:errcheck,gocritic
+
+	priceHistoryRepository := repository.NewPriceHistoryRepository(mongoDB)
+	dedupStore := getDedupStore(cfg, redisClient)
+
+	tenants := tenant.NewResolver(cfg.Tenants, cfg.TenantJWTSecret, cfg.TenantJWTClaim, cfg.TenantRequired)
+
+	catsRepository := repository.NewCatsRepository(mongoDB)
+	priceRetentions := getPriceRetentions(cfg, brokerKind, redisClient)
+	priceProducer, err := producer.NewPriceProducer(brokerKind, conns, codec, priceRetentions)
+	check(err)
+	// streams are trimmed by fanout consumer, other processes only keep the bound their producers trim to up to date
+	if !roles[roleFanoutConsumer] {
+		watchCtx, stopWatching := context.WithCancel(context.Background())
+		defer stopWatching()
+		for _, retention := range priceRetentions {
+			go retention.Watch(watchCtx, cfg.StreamTrimInterval)
+		}
+	}
+	statusProducer, err := producer.NewStatusProducer(brokerKind, conns)
+	check(err)
+	catsService := service.NewCatsService(catsRepository, priceProducer, statusProducer, cfg.ReservationTTL)
+	schedulesRepository := repository.NewPriceSchedulesRepository(mongoDB)
+	// schedules, pricing and event consumers change prices through plain cats service, only API requests are repriced
+	schedulesService := service.NewSchedulesService(schedulesRepository, catsService)
+	pricingRules := getPricingRules(cfg)
+	pricingService := service.NewPricingService(catsRepository, schedulesRepository, catsService, getPricingRuleProvider(pricingRules))
+	repricingCatsService := service.NewRepricingCats(catsService, pricingService)
+
+	photosRepository := repository.NewPhotosRepository(mongoDB)
+	photosService := service.NewPhotosService(catsRepository, photosRepository, service.PhotoLimits{
+		MaxSize:       cfg.PhotoMaxSize,
+		AllowedTypes:  cfg.PhotoAllowedTypes,
+		ThumbnailSize: cfg.PhotoThumbnailSize,
+	})
+
+	rates := getRates(cfg)
+	pricesService := service.NewPricesService(getConverter(rates))
+
+	idempotencyKeys := getIdempotencyKeys(cfg, mongoDB, redisClient)
+
+	replayJobs := replay.NewJobs(
+		getReplaySource(cfg, mongoDB, redisClient, codec),
+		getReplayHandlers(brokerKind, conns, codec, priceHistoryRepository, warmupDedupStore(cfg, roles, dedupStore)),
+	)
+
+	rateLimiter := getRateLimiter(cfg, redisClient)
+	rateLimits := ratelimit.NewRules(getRateLimits(cfg))
+	rateLimits.SetAPIKeys(cfg.RateLimitAPIKeys)
+	go reloadOnSignal(configLoader, rateLimits, rates, pricingRules)
+
+	var bridgeElector leader.Elector
+	if brokerKind == broker.RedisRabbit {
+		bridgeElector = getElector(cfg, mongoDB, redisClient, "price-bridge")
+	}
+	schedulerElector := getElector(cfg, mongoDB, redisClient, "price-scheduler")
+	// electors are reported by role, leases are shared, so any process shows leaders of roles run elsewhere
+	electors := map[string]leader.Elector{string(roleScheduler): schedulerElector}
+	if bridgeElector != nil {
+		electors[string(roleBridge)] = bridgeElector
+	}
+
+	// failed receives errors of roles that stopped on their own, one is enough to shut down
+	failed := make(chan error, len(allRoles))
+	processes := make([]roleProcess, 0, len(roles))
+	for _, r := range allRoles {
+		if !roles[r] {
+			continue
+		}
+		component := registry.Register(string(r), checks[r])
+		var stop func(ctx context.Context) error
+		switch r {
+		case roleHTTP:
+			gateway, err := grpcService.NewGateway(context.Background(), cfg.GrpcDialTarget(), getGrpcClientCredentials(cfg))
+			check(err)
+			stop = startHTTPServer(cfg, handler.Dependencies{
+				Tenants:         tenants,
+				Cats:            repricingCatsService,
+				Prices:          pricesService,
+				Photos:          photosService,
+				Schedules:       schedulesService,
+				Pricing:         pricingService,
+				IdempotencyKeys: idempotencyKeys,
+				ReplayJobs:      replayJobs,
+				Electors:        electors,
+				Gateway:         gateway,
+				RateLimiter:     rateLimiter,
+				RateLimits:      rateLimits,
+				PhotoMaxSize:    cfg.PhotoMaxSize,
+				AdminToken:      cfg.AdminToken,
+			}, component, failed)
+		case roleGRPC:
+			stop = startGrpcServer(cfg, tenants, repricingCatsService, pricesService, schedulesService, idempotencyKeys, rateLimiter, rateLimits, component, failed)
+		case roleScheduler:
+			stop = startScheduler(cfg, schedulerElector, schedulesService, pricingService, component)
+		case roleBridge:
+			stop = startBridge(cfg, conns, codec, bridgeElector, component)
+		case roleFanoutConsumer:
+			stop = startFanoutConsumer(cfg, brokerKind, conns, codec, dedupStore, priceHistoryRepository, catsService, priceRetentions, component, failed)
+		}
+		processes = append(processes, roleProcess{health: component, stop: stop})
+	}
+
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	var roleErr error
+	select {
+	case <-ctx.Done():
+	case roleErr = <-failed:
+		logging.Errorf("shutting down, role has failed: %v\n", roleErr)
+	}
+
+	shutdown(cfg, registry, processes)
+	return roleErr
+}
+
+// warmupDedupStore returns dedup store cache-warmup replay fills, nil when it is an in-memory store of another process.
+// Memory store is filled only in the process running fanout consumer, redis store of CONSUMER_NUMBER is shared by all of them.
+func warmupDedupStore(cfg *config.Сonfig, roles map[role]bool, dedupStore consumer.DedupStore) consumer.DedupStore {
+	if cfg.DedupStore != "redis" && !roles[roleFanoutConsumer] {
+		return nil
+	}
+	return dedupStore
+}
+
+// getPriceRetentions returns retentions of price streams of all tenants, nil when streams are not limited
+// or price events do not go through redis streams
+func getPriceRetentions(cfg *config.Сonfig, brokerKind broker.Kind, redisClient *redis.Client) producer.Retentions {
+	if (cfg.StreamMaxLen == 0 && cfg.StreamMaxAge == 0) || (brokerKind != broker.Redis && brokerKind != broker.RedisRabbit) {
+		return nil
+	}
+	streams := make([]string, len(cfg.Tenants))
+	for i, id := range cfg.Tenants {
+		streams[i] = tenant.Topic(producer.PriceTopic, id)
+	}
+	return producer.NewStreamRetentions(redisClient, streams, cfg.StreamMaxLen, cfg.StreamMaxAge)
+}
+
+// shutdown stops roles one by one, all of them share SHUTDOWN_TIMEOUT
+func shutdown(cfg *config.Сonfig, registry *health.Registry, processes []roleProcess) {
+	registry.SetAll(health.StatusStopping)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	for _, p := range processes {
+		if err := p.stop(ctx); err != nil {
+			logging.Warnf("cannot stop %s gracefully: %v\n", p.health.Name(), err)
+		}
+		p.health.Set(health.StatusDown, "stopped")
+		logging.Infof("stopped %s\n", p.health.Name())
+	}
+}
+
+// startHealthServer serves health of roles in plaintext, so probes do not need client certificates
+func startHealthServer(cfg *config.Сonfig, registry *health.Registry) *http.Server {
+	server := &http.Server{Addr: cfg.HealthAddr, Handler: health.Handler(registry)}
+	go func() {
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			check(err)
+		}
+	}()
+	return server
+}
+
+// roleFailed marks a role down and requests shutdown of the process
+func roleFailed(component *health.Component, failed chan<- error, err error) {
+	component.Fail(err)
+	failed <- fmt.Errorf("%s: %w", component.Name(), err)
+}
+
+func parseRoles(value string) (map[role]bool, error) {
+	roles := make(map[role]bool)
+	for _, name := range strings.Split(value, ",") {
+		r := role(strings.TrimSpace(name))
+		if r == "" {
+			continue
+		}
+		known := false
+		for _, existing := range allRoles {
+			known = known || r == existing
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown role %q, must be one of %s", r, joinRoles(allRoles))
+		}
+		roles[r] = true
+	}
+	if len(roles) == 0 {
+		return nil, errors.New("at least one role must be given")
+	}
+	return roles, nil
+}
+
+func joinRoles(roles []role) string {
+	names := make([]string, len(roles))
+	for i, r := range roles {
+		names[i] = string(r)
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/evleria/cats-app/internal/config"
+	"github.com/evleria/cats-app/internal/consumer"
+)
+
+func TestParseRoles(t *testing.T) {
+	for _, test := range []struct {
+		value    string
+		expected map[role]bool
+	}{
+		{"http", map[role]bool{roleHTTP: true}},
+		{"http,grpc", map[role]bool{roleHTTP: true, roleGRPC: true}},
+		{" scheduler , bridge,,fanout-consumer ", map[role]bool{roleScheduler: true, roleBridge: true, roleFanoutConsumer: true}},
+		{"grpc,grpc", map[role]bool{roleGRPC: true}},
+	} {
+		// Act
+		actual, err := parseRoles(test.value)
+
+		// Assert
+		require.NoError(t, err, test.value)
+		require.Equal(t, test.expected, actual, test.value)
+	}
+}
+
+func TestParseRolesInvalid(t *testing.T) {
+	for value, message := range map[string]string{
+		"":          "at least one role",
+		" , ":       "at least one role",
+		"http,ftp":  `unknown role "ftp"`,
+		"HTTP":      `unknown role "HTTP"`,
+		"consumer ": `unknown role "consumer"`,
+	} {
+		// Act
+		_, err := parseRoles(value)
+
+		// Assert
+		require.Error(t, err, value)
+		require.Contains(t, err.Error(), message, value)
+	}
+}
+
+func TestWarmupDedupStore(t *testing.T) {
+	store := consumer.NewMemoryDedupStore(time.Hour)
+	for _, test := range []struct {
+		dedupStore string
+		roles      map[role]bool
+		warmed     bool
+	}{
+		{"memory", map[role]bool{roleHTTP: true, roleFanoutConsumer: true}, true},
+		{"memory", map[role]bool{roleHTTP: true}, false},
+		{"redis", map[role]bool{roleHTTP: true}, true},
+	} {
+		// Act
+		actual := warmupDedupStore(&config.Сonfig{DedupStore: test.dedupStore}, test.roles, store)
+
+		// Assert
+		require.Equal(t, test.warmed, actual != nil, "%s %v", test.dedupStore, test.roles)
+	}
+}